@@ -0,0 +1,170 @@
+package example
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.codemanch.com/commons/codec"
+)
+
+//reflectServer has the same fields as Server without the generated methods, so it is handled by reflection
+type reflectServer Server
+
+var started = time.Date(2021, 5, 25, 10, 30, 0, 0, time.UTC)
+
+var sampleServers = []Server{
+	{},
+	{
+		Name:      "api-1",
+		Host:      "<api>&\u2028\"quoted\"\t\x01\xff",
+		Port:      443,
+		Secure:    true,
+		Weight:    1e-7,
+		Ratio:     0.1,
+		MaxConns:  65535,
+		Tags:      []string{"a", "b"},
+		Labels:    map[string]string{"zone": "a", "env": "prod"},
+		Endpoints: []Endpoint{{Path: "/health", Timeout: 30}},
+		Primary:   &Endpoint{Path: "/", Timeout: -1},
+		Started:   started,
+		Price:     codec.MustParseDecimal("19.90"),
+		Note:      "\u20ac unicode",
+	},
+	{Name: "big", Weight: 1e21, Ratio: 3.4e38, Port: -1},
+}
+
+func TestParity_Encode(t *testing.T) {
+	c := codec.NewJSONCodec(nil)
+	for i, s := range sampleServers {
+		want, err := json.Marshal(reflectServer(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := c.EncodeToBytes(s)
+		if err != nil {
+			t.Fatalf("EncodeToBytes() #%d error = %v", i, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("EncodeToBytes() #%d\n got = %s\nwant = %s", i, got, want)
+		}
+	}
+}
+
+func TestParity_Decode(t *testing.T) {
+	inputs := []string{
+		`{}`,
+		`null`,
+		`{"name":"api-1","PORT":443,"secure":true,"weight":1.5,"ratio":0.25,"maxConns":10,"tags":["a"],` +
+			`"labels":{"k":"v"},"endpoints":[{"path":"/a","timeout":5}],"primary":{"path":"/"},` +
+			`"started":"2021-05-25T10:30:00Z","price":12.5,"Note":"n","unknown":{"nested":[1,2]},"Internal":"x"}`,
+		`{"name":"api-2","primary":null,"tags":null,"host":null,"price":"-0.010"}`,
+	}
+	for _, in := range inputs {
+		var gen Server
+		var ref reflectServer
+		genErr := codec.NewJSONCodec(nil).DecodeString(in, &gen)
+		refErr := codec.NewJSONCodec(nil).DecodeString(in, &ref)
+		if (genErr == nil) != (refErr == nil) || genErr != nil && genErr.Error() != refErr.Error() {
+			t.Errorf("DecodeString(%s) error = %v, reflective error = %v", in, genErr, refErr)
+		}
+		if !reflect.DeepEqual(reflectServer(gen), ref) {
+			t.Errorf("DecodeString(%s)\n got = %+v\nwant = %+v", in, gen, ref)
+		}
+	}
+}
+
+func TestParity_DecodeErrors(t *testing.T) {
+	inputs := []string{
+		`{"name":"x","unknown":1}`,
+		`{"name":"x","port":"80"}`,
+		`{"name":"x","maxConns":70000}`,
+		`[]`,
+		`{"name":"x" "port":1}`,
+		`{"name":"x",}`,
+		`{"tags":["a",]}`,
+		`{"name":"\x"}`,
+		`{"port":01}`,
+		`{"price":"1,5"}`,
+		`{"name":"x"`,
+	}
+	for _, in := range inputs {
+		var gen Server
+		var ref reflectServer
+		genErr := codec.NewJSONCodec(codec.StrictDecoding()).DecodeString(in, &gen)
+		refErr := codec.NewJSONCodec(codec.StrictDecoding()).DecodeString(in, &ref)
+		if genErr == nil || refErr == nil {
+			t.Errorf("DecodeString(%s) error = %v, reflective error = %v", in, genErr, refErr)
+		}
+	}
+}
+
+func TestParity_Validate(t *testing.T) {
+	servers := []Server{
+		sampleServers[1],
+		{Name: "Invalid Name", Port: 70000, Tags: []string{"a", "b", "c", "d"},
+			Endpoints: []Endpoint{{Timeout: -1}}, Primary: &Endpoint{}, Price: codec.MustParseDecimal("-1.005")},
+		{},
+	}
+	for i, s := range servers {
+		genErr := codec.Validate(&s)
+		ref := reflectServer(s)
+		refErr := codec.Validate(&ref)
+		if (genErr == nil) != (refErr == nil) || genErr != nil && genErr.Error() != refErr.Error() {
+			t.Errorf("Validate() #%d\n got = %v\nwant = %v", i, genErr, refErr)
+		}
+	}
+}
+
+func TestParity_ApplyDefaults(t *testing.T) {
+	var gen Server
+	var ref reflectServer
+	if err := codec.ApplyDefaults(&gen); err != nil {
+		t.Fatal(err)
+	}
+	if err := codec.ApplyDefaults(&ref); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reflectServer(gen), ref) || gen.Port != 8080 || gen.Host != "localhost" ||
+		gen.Price.String() != "0.99" {
+		t.Errorf("ApplyDefaults() = %+v, want %+v", gen, ref)
+	}
+}
+
+func BenchmarkEncode_Generated(b *testing.B) {
+	c := codec.NewJSONCodec(nil)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.EncodeToBytes(sampleServers[1])
+	}
+}
+
+func BenchmarkEncode_Reflective(b *testing.B) {
+	c := codec.NewJSONCodec(nil)
+	s := reflectServer(sampleServers[1])
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.EncodeToBytes(s)
+	}
+}
+
+func BenchmarkDecode_Generated(b *testing.B) {
+	c := codec.NewJSONCodec(nil)
+	data, _ := c.EncodeToBytes(sampleServers[1])
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var s Server
+		_ = c.DecodeBytes(data, &s)
+	}
+}
+
+func BenchmarkDecode_Reflective(b *testing.B) {
+	c := codec.NewJSONCodec(nil)
+	data, _ := c.EncodeToBytes(sampleServers[1])
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var s reflectServer
+		_ = c.DecodeBytes(data, &s)
+	}
+}
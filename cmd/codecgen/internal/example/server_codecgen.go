@@ -0,0 +1,270 @@
+// Code generated by codecgen -type=Server,Endpoint; DO NOT EDIT.
+
+package example
+
+import (
+	"regexp"
+	"strconv"
+
+	"go.codemanch.com/commons/codec"
+)
+
+var codecgenServerKeys = []string{
+	"name",
+	"host",
+	"port",
+	"secure",
+	"weight",
+	"ratio",
+	"maxConns",
+	"tags",
+	"labels",
+	"endpoints",
+	"primary",
+	"started",
+	"price",
+	"Note",
+}
+var codecgenServerNamePattern = regexp.MustCompile("^[a-z][a-z0-9-]*$")
+var codecgenServerPriceMin = codec.MustParseDecimal("0")
+var codecgenServerPriceMax = codec.MustParseDecimal("1000")
+
+// AppendJSON appends the JSON encoding of Server to buf
+func (v Server) AppendJSON(buf []byte) ([]byte, error) {
+	var err error
+	start := len(buf)
+	buf = append(buf, ",\"name\":"...)
+	buf = codec.AppendJSONString(buf, v.Name)
+	buf = append(buf, ",\"host\":"...)
+	buf = codec.AppendJSONString(buf, v.Host)
+	buf = append(buf, ",\"port\":"...)
+	buf = strconv.AppendInt(buf, int64(v.Port), 10)
+	if v.Secure {
+		buf = append(buf, ",\"secure\":"...)
+		buf = strconv.AppendBool(buf, v.Secure)
+	}
+	buf = append(buf, ",\"weight\":"...)
+	if buf, err = codec.AppendJSONFloat(buf, float64(v.Weight), 64); err != nil {
+		return buf, err
+	}
+	if v.Ratio != 0 {
+		buf = append(buf, ",\"ratio\":"...)
+		if buf, err = codec.AppendJSONFloat(buf, float64(v.Ratio), 32); err != nil {
+			return buf, err
+		}
+	}
+	buf = append(buf, ",\"maxConns\":"...)
+	buf = strconv.AppendUint(buf, uint64(v.MaxConns), 10)
+	if len(v.Tags) != 0 {
+		buf = append(buf, ",\"tags\":"...)
+		if v.Tags == nil {
+			buf = append(buf, "null"...)
+		} else {
+			buf = append(buf, '[')
+			for i, e := range v.Tags {
+				if i > 0 {
+					buf = append(buf, ',')
+				}
+				buf = codec.AppendJSONString(buf, e)
+			}
+			buf = append(buf, ']')
+		}
+	}
+	if len(v.Labels) != 0 {
+		buf = append(buf, ",\"labels\":"...)
+		if buf, err = codec.AppendJSONValue(buf, v.Labels); err != nil {
+			return buf, err
+		}
+	}
+	buf = append(buf, ",\"endpoints\":"...)
+	if v.Endpoints == nil {
+		buf = append(buf, "null"...)
+	} else {
+		buf = append(buf, '[')
+		for i, e := range v.Endpoints {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			if buf, err = codec.AppendJSONValue(buf, e); err != nil {
+				return buf, err
+			}
+		}
+		buf = append(buf, ']')
+	}
+	if v.Primary != nil {
+		buf = append(buf, ",\"primary\":"...)
+		if buf, err = codec.AppendJSONValue(buf, v.Primary); err != nil {
+			return buf, err
+		}
+	}
+	buf = append(buf, ",\"started\":"...)
+	if buf, err = codec.AppendJSONValue(buf, v.Started); err != nil {
+		return buf, err
+	}
+	if !codec.IsEmptyJSONValue(v.Price) {
+		buf = append(buf, ",\"price\":"...)
+		if buf, err = codec.AppendJSONValue(buf, v.Price); err != nil {
+			return buf, err
+		}
+	}
+	buf = append(buf, ",\"Note\":"...)
+	buf = codec.AppendJSONString(buf, v.Note)
+	if len(buf) > start {
+		buf[start] = '{'
+	} else {
+		buf = append(buf, '{')
+	}
+	return append(buf, '}'), err
+}
+
+// DecodeJSON decodes the next value of r in to Server
+func (v *Server) DecodeJSON(r *codec.JSONReader, opts *codec.DecoderOptions) error {
+	if !r.ObjectStart("Server") {
+		return r.Err()
+	}
+	for r.More() {
+		switch r.Key(codecgenServerKeys, opts) {
+		case "name":
+			r.String("name", &v.Name)
+		case "host":
+			r.String("host", &v.Host)
+		case "port":
+			if n, ok := r.Int("port", strconv.IntSize); ok {
+				v.Port = int(n)
+			}
+		case "secure":
+			r.Bool("secure", &v.Secure)
+		case "weight":
+			if n, ok := r.Float("weight", 64); ok {
+				v.Weight = float64(n)
+			}
+		case "ratio":
+			if n, ok := r.Float("ratio", 32); ok {
+				v.Ratio = float32(n)
+			}
+		case "maxConns":
+			if n, ok := r.Uint("maxConns", 16); ok {
+				v.MaxConns = uint16(n)
+			}
+		case "tags":
+			if !r.ArrayStart("tags") {
+				if r.Err() == nil {
+					v.Tags = nil
+				}
+				break
+			}
+			if v.Tags == nil {
+				v.Tags = make([]string, 0)
+			}
+			v.Tags = v.Tags[:0]
+			for r.More() {
+				var e string
+				r.String("tags", &e)
+				v.Tags = append(v.Tags, e)
+			}
+			r.ArrayEnd()
+		case "labels":
+			r.Value(&v.Labels, opts)
+		case "endpoints":
+			r.Value(&v.Endpoints, opts)
+		case "primary":
+			r.Value(&v.Primary, opts)
+		case "started":
+			r.Value(&v.Started, opts)
+		case "price":
+			r.Value(&v.Price, opts)
+		case "Note":
+			r.String("Note", &v.Note)
+		}
+	}
+	return r.ObjectEnd()
+}
+
+// ApplyDefaults sets the default values on the fields of Server that have the zero value
+func (v *Server) ApplyDefaults() error {
+	if v.Host == "" {
+		v.Host = "localhost"
+	}
+	if v.Port == 0 {
+		v.Port = 8080
+	}
+	if err := codec.ApplyDefaults(&v.Primary); err != nil {
+		return err
+	}
+	if err := codec.ApplyDefaults(&v.Started); err != nil {
+		return err
+	}
+	if codec.IsZero(v.Price) {
+		v.Price = codec.MustParseDecimal("0.99")
+	}
+	return nil
+}
+
+// Validate checks the constraints declared on the fields of Server
+func (v *Server) Validate() error {
+	var errs codec.ValidationErrors
+	errs = codec.AppendErrors(errs, codec.CheckRequired("name", v.Name == ""))
+	errs = codec.AppendErrors(errs, codec.CheckPattern("name", v.Name, codecgenServerNamePattern))
+	errs = codec.AppendErrors(errs, codec.CheckMin("port", float64(v.Port), 1))
+	errs = codec.AppendErrors(errs, codec.CheckMax("port", float64(v.Port), 65535))
+	errs = codec.AppendErrors(errs, codec.CheckMaxLength("tags", len(v.Tags), 3))
+	errs = codec.AppendErrors(errs, codec.ValidateNested("endpoints", &v.Endpoints))
+	errs = codec.AppendErrors(errs, codec.ValidateNested("primary", &v.Primary))
+	errs = codec.AppendErrors(errs, codec.ValidateNested("started", &v.Started))
+	errs = codec.AppendErrors(errs, codec.CheckDecimalMin("price", v.Price, codecgenServerPriceMin))
+	errs = codec.AppendErrors(errs, codec.CheckDecimalMax("price", v.Price, codecgenServerPriceMax))
+	errs = codec.AppendErrors(errs, codec.CheckScale("price", v.Price, 2))
+	return errs.ErrorOrNil()
+}
+
+var codecgenEndpointKeys = []string{
+	"path",
+	"timeout",
+}
+
+// AppendJSON appends the JSON encoding of Endpoint to buf
+func (v Endpoint) AppendJSON(buf []byte) ([]byte, error) {
+	var err error
+	start := len(buf)
+	buf = append(buf, ",\"path\":"...)
+	buf = codec.AppendJSONString(buf, v.Path)
+	buf = append(buf, ",\"timeout\":"...)
+	buf = strconv.AppendInt(buf, int64(v.Timeout), 10)
+	if len(buf) > start {
+		buf[start] = '{'
+	} else {
+		buf = append(buf, '{')
+	}
+	return append(buf, '}'), err
+}
+
+// DecodeJSON decodes the next value of r in to Endpoint
+func (v *Endpoint) DecodeJSON(r *codec.JSONReader, opts *codec.DecoderOptions) error {
+	if !r.ObjectStart("Endpoint") {
+		return r.Err()
+	}
+	for r.More() {
+		switch r.Key(codecgenEndpointKeys, opts) {
+		case "path":
+			r.String("path", &v.Path)
+		case "timeout":
+			if n, ok := r.Int("timeout", 64); ok {
+				v.Timeout = int64(n)
+			}
+		}
+	}
+	return r.ObjectEnd()
+}
+
+// ApplyDefaults sets the default values on the fields of Endpoint that have the zero value
+func (v *Endpoint) ApplyDefaults() error {
+	return nil
+}
+
+// Validate checks the constraints declared on the fields of Endpoint
+func (v *Endpoint) Validate() error {
+	var errs codec.ValidationErrors
+	errs = codec.AppendErrors(errs, codec.CheckRequired("path", v.Path == ""))
+	errs = codec.AppendErrors(errs, codec.CheckMin("timeout", float64(v.Timeout), 0))
+	return errs.ErrorOrNil()
+}
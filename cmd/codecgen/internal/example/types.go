@@ -0,0 +1,36 @@
+//Package example holds the types used to verify that the code generated by codecgen matches the reflective codec.
+package example
+
+import (
+	"time"
+
+	"go.codemanch.com/commons/codec"
+)
+
+//go:generate go run go.codemanch.com/commons/cmd/codecgen -type=Server,Endpoint
+
+//Server is a sample type with a mix of scalar, nested and collection fields
+type Server struct {
+	Name       string            `json:"name" required:"true" pattern:"^[a-z][a-z0-9-]*$"`
+	Host       string            `json:"host" default:"localhost"`
+	Port       int               `json:"port" default:"8080" min:"1" max:"65535"`
+	Secure     bool              `json:"secure,omitempty"`
+	Weight     float64           `json:"weight"`
+	Ratio      float32           `json:"ratio,omitempty"`
+	MaxConns   uint16            `json:"maxConns"`
+	Tags       []string          `json:"tags,omitempty" max:"3"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Endpoints  []Endpoint        `json:"endpoints"`
+	Primary    *Endpoint         `json:"primary,omitempty"`
+	Started    time.Time         `json:"started"`
+	Price      codec.Decimal     `json:"price,omitempty" default:"0.99" min:"0" max:"1000" scale:"2"`
+	Note       string
+	Internal   string `json:"-"`
+	unexported int
+}
+
+//Endpoint is a sample nested type
+type Endpoint struct {
+	Path    string `json:"path" required:"true"`
+	Timeout int64  `json:"timeout" min:"0"`
+}
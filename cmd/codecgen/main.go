@@ -0,0 +1,680 @@
+//codecgen generates reflection free JSON encoders, decoders, default setters and validators for struct types.
+//The generated methods implement codec.JSONAppender, codec.JSONReaderDecoder, codec.Defaulter and codec.Validator
+//and are picked up by the codec.JSONCodec automatically. The output is the same as the reflective codec.
+//
+//Usage with go generate
+//	//go:generate go run go.codemanch.com/commons/cmd/codecgen -type=Server,Endpoint
+//
+//Fields of the builtin string, bool, integer and float types are handled by the generated code. Fields of other types
+//are delegated to the codec package which uses the generated methods of the field type if present and reflection
+//otherwise.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.codemanch.com/commons/codec"
+)
+
+const codecImport = "go.codemanch.com/commons/codec"
+
+var intBits = map[string]string{
+	"int": "strconv.IntSize", "int8": "8", "int16": "16", "int32": "32", "int64": "64",
+	"uint": "strconv.IntSize", "uint8": "8", "uint16": "16", "uint32": "32", "uint64": "64", "uintptr": "64",
+	"float32": "32", "float64": "64", "byte": "8", "rune": "32",
+}
+
+//fieldKind classifies the fields by the code that is generated for them
+type fieldKind int
+
+const (
+	otherKind fieldKind = iota
+	stringKind
+	boolKind
+	intKind
+	uintKind
+	floatKind
+)
+
+//field holds the details of a struct field needed to generate the code
+type field struct {
+	goName     string
+	jsonName   string
+	goType     string
+	kind       fieldKind
+	bits       string
+	omitEmpty  bool
+	hasLen     bool
+	isNillable bool
+	//scalarElems is set for slices, arrays and maps of builtin scalars which need no nested validation
+	scalarElems bool
+	//elem is set for slices and arrays that are encoded element by element
+	elem *field
+	//isDecimal is set for codec.Decimal fields which support the min, max, scale and default constraints
+	isDecimal  bool
+	required   bool
+	min        *float64
+	max        *float64
+	minText    string
+	maxText    string
+	scale      string
+	pattern    string
+	hasPattern bool
+	defaultVal string
+	hasDefault bool
+}
+
+//structType holds the fields of a struct type to generate the code for
+type structType struct {
+	name   string
+	fields []*field
+}
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of struct type names; required")
+	output := flag.String("output", "", "output file name; default <first type>_codecgen.go in the package directory")
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	names := strings.Split(*typeNames, ",")
+	outFile := *output
+	if outFile == "" {
+		outFile = filepath.Join(dir, strings.ToLower(names[0])+"_codecgen.go")
+	}
+	src, err := generate(dir, names, filepath.Base(outFile))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "codecgen:", err)
+		os.Exit(1)
+	}
+	if err = ioutil.WriteFile(outFile, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "codecgen:", err)
+		os.Exit(1)
+	}
+}
+
+//generate parses the package in dir and returns the formatted source for the types
+func generate(dir string, names []string, outFile string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != outFile
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		pkg = p
+	}
+	var types []*structType
+	for _, name := range names {
+		st, err := findStruct(pkg, strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, st)
+	}
+	g := &generator{imports: map[string]bool{codecImport: true}}
+	for _, st := range types {
+		g.genType(st)
+	}
+	return g.source(pkg.Name, strings.Join(os.Args[1:], " "))
+}
+
+//findStruct looks up the struct type name in the package and resolves its fields
+func findStruct(pkg *ast.Package, name string) (*structType, error) {
+	var st *ast.StructType
+	for _, f := range pkg.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if ts, ok := n.(*ast.TypeSpec); ok && ts.Name.Name == name {
+				st, _ = ts.Type.(*ast.StructType)
+				return false
+			}
+			return st == nil
+		})
+	}
+	if st == nil {
+		return nil, fmt.Errorf("struct type %s not found", name)
+	}
+	result := &structType{name: name}
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			return nil, fmt.Errorf("%s: embedded fields are not supported", name)
+		}
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			s, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return nil, err
+			}
+			tag = reflect.StructTag(s)
+		}
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			fd, err := newField(n.Name, f.Type, tag)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %v", name, n.Name, err)
+			}
+			if fd != nil {
+				result.fields = append(result.fields, fd)
+			}
+		}
+	}
+	return result, nil
+}
+
+//newField creates the field for the struct field. nil is returned for fields that are not encoded
+func newField(goName string, expr ast.Expr, tag reflect.StructTag) (*field, error) {
+	jsonTag, hasJSON := tag.Lookup("json")
+	if jsonTag == "-" {
+		return nil, nil
+	}
+	fd := &field{goName: goName, jsonName: goName, goType: exprString(expr)}
+	if hasJSON {
+		parts := strings.Split(jsonTag, ",")
+		if parts[0] != "" {
+			fd.jsonName = parts[0]
+		}
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "omitempty":
+				fd.omitEmpty = true
+			case "string":
+				return nil, errors.New("the string option of the json tag is not supported")
+			}
+		}
+	}
+	fd.kind, fd.bits = scalarKind(expr)
+	fd.isDecimal = isDecimal(expr)
+	switch t := expr.(type) {
+	case *ast.ArrayType:
+		fd.hasLen = true
+		fd.isNillable = t.Len == nil
+		fd.scalarElems = isBuiltinScalar(t.Elt)
+		//byte slices are encoded as base64 strings by encoding/json
+		if ident, ok := t.Elt.(*ast.Ident); !ok || ident.Name != "byte" && ident.Name != "uint8" {
+			fd.elem = &field{goType: exprString(t.Elt)}
+			fd.elem.kind, fd.elem.bits = scalarKind(t.Elt)
+		}
+	case *ast.MapType:
+		fd.hasLen = true
+		fd.isNillable = true
+		fd.scalarElems = isBuiltinScalar(t.Value)
+	case *ast.StarExpr, *ast.InterfaceType:
+		fd.isNillable = true
+	}
+	var err error
+	fd.required, _ = strconv.ParseBool(tag.Get("required"))
+	if fd.min, err = parseBound(tag, "min"); err != nil {
+		return nil, err
+	}
+	if fd.max, err = parseBound(tag, "max"); err != nil {
+		return nil, err
+	}
+	if (fd.min != nil || fd.max != nil) && fd.kind == otherKind && !fd.hasLen && !fd.isDecimal {
+		return nil, fmt.Errorf("min and max are only supported on builtin numbers, decimals, strings, slices, arrays " +
+			"and maps")
+	}
+	fd.minText, fd.maxText = tag.Get("min"), tag.Get("max")
+	if fd.isDecimal {
+		for _, bound := range []string{fd.minText, fd.maxText} {
+			if _, err = codec.ParseDecimal(bound); bound != "" && err != nil {
+				return nil, err
+			}
+		}
+	}
+	if s, ok := tag.Lookup("scale"); ok {
+		if !fd.isDecimal {
+			return nil, errors.New("scale is only supported on codec.Decimal fields")
+		}
+		if n, err := strconv.ParseInt(s, 10, 32); err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid scale constraint %q", s)
+		}
+		fd.scale = s
+	}
+	if fd.pattern, fd.hasPattern = tag.Lookup("pattern"); fd.hasPattern {
+		if fd.kind != stringKind {
+			return nil, errors.New("pattern is only supported on string fields")
+		}
+		if _, err = regexp.Compile(fd.pattern); err != nil {
+			return nil, err
+		}
+	}
+	if fd.defaultVal, fd.hasDefault = tag.Lookup("default"); fd.hasDefault {
+		if fd.kind == otherKind && !fd.isDecimal {
+			return nil, errors.New("default is only supported on builtin strings, booleans, numbers and decimals")
+		}
+		if _, err = defaultLiteral(fd); err != nil {
+			return nil, fmt.Errorf("invalid default: %v", err)
+		}
+	}
+	return fd, nil
+}
+
+//scalarKind returns the kind and the bit size of the builtin scalar type expr. otherKind is returned for other types
+func scalarKind(expr ast.Expr) (fieldKind, string) {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return otherKind, ""
+	}
+	switch ident.Name {
+	case "string":
+		return stringKind, ""
+	case "bool":
+		return boolKind, ""
+	case "int", "int8", "int16", "int32", "int64", "rune":
+		return intKind, intBits[ident.Name]
+	case "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte":
+		return uintKind, intBits[ident.Name]
+	case "float32", "float64":
+		return floatKind, intBits[ident.Name]
+	}
+	return otherKind, ""
+}
+
+//isDecimal checks if expr is the codec.Decimal type
+func isDecimal(expr ast.Expr) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Decimal" {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "codec"
+}
+
+//isBuiltinScalar checks if expr is one of the builtin string, bool or number types
+func isBuiltinScalar(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, isNumber := intBits[ident.Name]
+	return isNumber || ident.Name == "string" || ident.Name == "bool"
+}
+
+func parseBound(tag reflect.StructTag, name string) (*float64, error) {
+	s, ok := tag.Lookup(name)
+	if !ok {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s constraint %q", name, s)
+	}
+	return &f, nil
+}
+
+//defaultLiteral returns the Go literal for the default value of the field
+func defaultLiteral(fd *field) (string, error) {
+	bits, _ := strconv.Atoi(fd.bits)
+	if bits == 0 {
+		bits = 64
+	}
+	if fd.isDecimal {
+		_, err := codec.ParseDecimal(fd.defaultVal)
+		return "codec.MustParseDecimal(" + strconv.Quote(fd.defaultVal) + ")", err
+	}
+	switch fd.kind {
+	case stringKind:
+		return strconv.Quote(fd.defaultVal), nil
+	case boolKind:
+		b, err := strconv.ParseBool(fd.defaultVal)
+		return strconv.FormatBool(b), err
+	case intKind:
+		n, err := strconv.ParseInt(fd.defaultVal, 10, bits)
+		return strconv.FormatInt(n, 10), err
+	case uintKind:
+		n, err := strconv.ParseUint(fd.defaultVal, 10, bits)
+		return strconv.FormatUint(n, 10), err
+	case floatKind:
+		f, err := strconv.ParseFloat(fd.defaultVal, bits)
+		return strconv.FormatFloat(f, 'g', -1, bits), err
+	}
+	return "", errors.New("unsupported type")
+}
+
+//zeroCheck returns the Go expression that checks if the field has the zero value
+func zeroCheck(fd *field) string {
+	switch fd.kind {
+	case stringKind:
+		return "v." + fd.goName + ` == ""`
+	case boolKind:
+		return "!v." + fd.goName
+	case intKind, uintKind, floatKind:
+		return "v." + fd.goName + " == 0"
+	}
+	return "codec.IsZero(v." + fd.goName + ")"
+}
+
+//nonEmptyCheck returns the Go expression that checks if the field is not empty as defined by omitempty
+func nonEmptyCheck(fd *field) string {
+	switch {
+	case fd.kind == stringKind:
+		return "v." + fd.goName + ` != ""`
+	case fd.kind == boolKind:
+		return "v." + fd.goName
+	case fd.kind != otherKind:
+		return "v." + fd.goName + " != 0"
+	case fd.hasLen:
+		return "len(v." + fd.goName + ") != 0"
+	case fd.isNillable:
+		return "v." + fd.goName + " != nil"
+	}
+	return "!codec.IsEmptyJSONValue(v." + fd.goName + ")"
+}
+
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = format.Node(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+//generator accumulates the generated code and the imports needed
+type generator struct {
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+func (g *generator) p(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+	g.buf.WriteByte('\n')
+}
+
+func (g *generator) source(pkgName, args string) ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by codecgen %s; DO NOT EDIT.\n\npackage %s\n\nimport (\n", args, pkgName)
+	var imports []string
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		if imp == codecImport {
+			//third party imports are grouped after the standard library imports
+			continue
+		}
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	fmt.Fprintf(&out, "\n\t%q\n", codecImport)
+	out.WriteString(")\n")
+	out.Write(g.buf.Bytes())
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+func (g *generator) genType(st *structType) {
+	g.genKeys(st)
+	g.genEncoder(st)
+	g.genDecoder(st)
+	g.genDefaults(st)
+	g.genValidator(st)
+}
+
+func (g *generator) genKeys(st *structType) {
+	g.p("")
+	g.p("var codecgen%sKeys = []string{", st.name)
+	for _, fd := range st.fields {
+		g.p("%q,", fd.jsonName)
+	}
+	g.p("}")
+	for _, fd := range st.fields {
+		if fd.hasPattern {
+			g.imports["regexp"] = true
+			g.p("var codecgen%s%sPattern = regexp.MustCompile(%q)", st.name, fd.goName, fd.pattern)
+		}
+		if fd.isDecimal && fd.min != nil {
+			g.p("var codecgen%s%sMin = codec.MustParseDecimal(%q)", st.name, fd.goName, fd.minText)
+		}
+		if fd.isDecimal && fd.max != nil {
+			g.p("var codecgen%s%sMax = codec.MustParseDecimal(%q)", st.name, fd.goName, fd.maxText)
+		}
+	}
+}
+
+func (g *generator) genEncoder(st *structType) {
+	g.p("")
+	g.p("//AppendJSON appends the JSON encoding of %s to buf", st.name)
+	g.p("func (v %s) AppendJSON(buf []byte) ([]byte, error) {", st.name)
+	g.p("var err error")
+	g.p("start := len(buf)")
+	for _, fd := range st.fields {
+		//every key is written with a leading comma. The first comma is replaced with the opening brace
+		name, _ := json.Marshal(fd.jsonName)
+		key := strconv.Quote("," + string(name) + ":")
+		if fd.omitEmpty {
+			g.p("if %s {", nonEmptyCheck(fd))
+		}
+		g.p("buf = append(buf, %s...)", key)
+		if fd.elem != nil {
+			if fd.isNillable {
+				g.p("if v.%s == nil {", fd.goName)
+				g.p(`buf = append(buf, "null"...)`)
+				g.p("} else {")
+			}
+			g.p("buf = append(buf, '[')")
+			g.p("for i, e := range v.%s {", fd.goName)
+			g.p("if i > 0 {")
+			g.p("buf = append(buf, ',')")
+			g.p("}")
+			g.encodeValue(fd.elem, "e")
+			g.p("}")
+			g.p("buf = append(buf, ']')")
+			if fd.isNillable {
+				g.p("}")
+			}
+		} else {
+			g.encodeValue(fd, "v."+fd.goName)
+		}
+		if fd.omitEmpty {
+			g.p("}")
+		}
+	}
+	g.p("if len(buf) > start {")
+	g.p("buf[start] = '{'")
+	g.p("} else {")
+	g.p("buf = append(buf, '{')")
+	g.p("}")
+	g.p("return append(buf, '}'), err")
+	g.p("}")
+}
+
+//encodeValue generates the code that appends the JSON encoding of the expression expr of the type of fd to buf
+func (g *generator) encodeValue(fd *field, expr string) {
+	switch fd.kind {
+	case stringKind:
+		g.p("buf = codec.AppendJSONString(buf, %s)", expr)
+	case boolKind:
+		g.imports["strconv"] = true
+		g.p("buf = strconv.AppendBool(buf, %s)", expr)
+	case intKind:
+		g.imports["strconv"] = true
+		g.p("buf = strconv.AppendInt(buf, int64(%s), 10)", expr)
+	case uintKind:
+		g.imports["strconv"] = true
+		g.p("buf = strconv.AppendUint(buf, uint64(%s), 10)", expr)
+	case floatKind:
+		g.p("if buf, err = codec.AppendJSONFloat(buf, float64(%s), %s); err != nil {", expr, fd.bits)
+		g.p("return buf, err")
+		g.p("}")
+	default:
+		g.p("if buf, err = codec.AppendJSONValue(buf, %s); err != nil {", expr)
+		g.p("return buf, err")
+		g.p("}")
+	}
+}
+
+//decodeScalar generates the code that reads the next value of r in to the expression target of the builtin scalar
+//type of fd
+func (g *generator) decodeScalar(fd *field, name, target string) {
+	switch fd.kind {
+	case stringKind:
+		g.p("r.String(%q, &%s)", name, target)
+	case boolKind:
+		g.p("r.Bool(%q, &%s)", name, target)
+	default:
+		fn := "Int"
+		if fd.kind == uintKind {
+			fn = "Uint"
+		} else if fd.kind == floatKind {
+			fn = "Float"
+		}
+		if fd.bits == "strconv.IntSize" {
+			g.imports["strconv"] = true
+		}
+		g.p("if n, ok := r.%s(%q, %s); ok {", fn, name, fd.bits)
+		g.p("%s = %s(n)", target, fd.goType)
+		g.p("}")
+	}
+}
+
+func (g *generator) genDecoder(st *structType) {
+	g.p("")
+	g.p("//DecodeJSON decodes the next value of r in to %s", st.name)
+	g.p("func (v *%s) DecodeJSON(r *codec.JSONReader, opts *codec.DecoderOptions) error {", st.name)
+	g.p("if !r.ObjectStart(%q) {", st.name)
+	g.p("return r.Err()")
+	g.p("}")
+	g.p("for r.More() {")
+	g.p("switch r.Key(codecgen%sKeys, opts) {", st.name)
+	for _, fd := range st.fields {
+		g.p("case %q:", fd.jsonName)
+		switch {
+		case fd.kind != otherKind:
+			g.decodeScalar(fd, fd.jsonName, "v."+fd.goName)
+		case fd.elem != nil && fd.elem.kind != otherKind && fd.isNillable:
+			//slices of builtin scalars are decoded element by element, a null resets the slice as done by encoding/json
+			g.p("if !r.ArrayStart(%q) {", fd.jsonName)
+			g.p("if r.Err() == nil {")
+			g.p("v.%s = nil", fd.goName)
+			g.p("}")
+			g.p("break")
+			g.p("}")
+			g.p("if v.%s == nil {", fd.goName)
+			g.p("v.%s = make(%s, 0)", fd.goName, fd.goType)
+			g.p("}")
+			g.p("v.%s = v.%s[:0]", fd.goName, fd.goName)
+			g.p("for r.More() {")
+			g.p("var e %s", fd.elem.goType)
+			g.decodeScalar(fd.elem, fd.jsonName, "e")
+			g.p("v.%s = append(v.%s, e)", fd.goName, fd.goName)
+			g.p("}")
+			g.p("r.ArrayEnd()")
+		default:
+			g.p("r.Value(&v.%s, opts)", fd.goName)
+		}
+	}
+	g.p("}")
+	g.p("}")
+	g.p("return r.ObjectEnd()")
+	g.p("}")
+}
+
+func (g *generator) genDefaults(st *structType) {
+	g.p("")
+	g.p("//ApplyDefaults sets the default values on the fields of %s that have the zero value", st.name)
+	g.p("func (v *%s) ApplyDefaults() error {", st.name)
+	for _, fd := range st.fields {
+		if fd.hasDefault {
+			lit, _ := defaultLiteral(fd)
+			g.p("if %s {", zeroCheck(fd))
+			g.p("v.%s = %s", fd.goName, lit)
+			g.p("}")
+		} else if fd.kind == otherKind && !fd.hasLen && !fd.isDecimal {
+			g.p("if err := codec.ApplyDefaults(&v.%s); err != nil {", fd.goName)
+			g.p("return err")
+			g.p("}")
+		}
+	}
+	g.p("return nil")
+	g.p("}")
+}
+
+func (g *generator) genValidator(st *structType) {
+	g.p("")
+	g.p("//Validate checks the constraints declared on the fields of %s", st.name)
+	g.p("func (v *%s) Validate() error {", st.name)
+	g.p("var errs codec.ValidationErrors")
+	for _, fd := range st.fields {
+		path := strconv.Quote(fd.jsonName)
+		if fd.required {
+			g.p("errs = codec.AppendErrors(errs, codec.CheckRequired(%s, %s))", path, zeroCheck(fd))
+		}
+		if fd.hasPattern {
+			g.p("errs = codec.AppendErrors(errs, codec.CheckPattern(%s, v.%s, codecgen%s%sPattern))", path, fd.goName,
+				st.name, fd.goName)
+		}
+		if fd.isDecimal {
+			g.genDecimalValidator(st, fd, path)
+			continue
+		}
+		var val, check string
+		switch {
+		case fd.kind == stringKind:
+			val, check = "utf8.RuneCountInString(v."+fd.goName+")", "Length"
+		case fd.hasLen:
+			val, check = "len(v."+fd.goName+")", "Length"
+		default:
+			val = "float64(v." + fd.goName + ")"
+		}
+		if fd.kind == stringKind && (fd.min != nil || fd.max != nil) {
+			g.imports["unicode/utf8"] = true
+		}
+		if fd.min != nil {
+			g.p("errs = codec.AppendErrors(errs, codec.CheckMin%s(%s, %s, %s))", check, path, val,
+				strconv.FormatFloat(*fd.min, 'g', -1, 64))
+		}
+		if fd.max != nil {
+			g.p("errs = codec.AppendErrors(errs, codec.CheckMax%s(%s, %s, %s))", check, path, val,
+				strconv.FormatFloat(*fd.max, 'g', -1, 64))
+		}
+		if fd.kind == otherKind && !fd.scalarElems {
+			g.p("errs = codec.AppendErrors(errs, codec.ValidateNested(%s, &v.%s))", path, fd.goName)
+		}
+	}
+	g.p("return errs.ErrorOrNil()")
+	g.p("}")
+}
+
+//genDecimalValidator generates the checks of the min, max and scale constraints of a codec.Decimal field
+func (g *generator) genDecimalValidator(st *structType, fd *field, path string) {
+	if fd.min != nil {
+		g.p("errs = codec.AppendErrors(errs, codec.CheckDecimalMin(%s, v.%s, codecgen%s%sMin))", path, fd.goName,
+			st.name, fd.goName)
+	}
+	if fd.max != nil {
+		g.p("errs = codec.AppendErrors(errs, codec.CheckDecimalMax(%s, v.%s, codecgen%s%sMax))", path, fd.goName,
+			st.name, fd.goName)
+	}
+	if fd.scale != "" {
+		g.p("errs = codec.AppendErrors(errs, codec.CheckScale(%s, v.%s, %s))", path, fd.goName, fd.scale)
+	}
+}
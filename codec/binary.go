@@ -0,0 +1,337 @@
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/ascii85"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//BinaryEncoding specifies the binary to text encoding used by a BinaryCodec
+type BinaryEncoding int
+
+const (
+	//Base64 standard encoding with padding as per RFC 4648
+	Base64 BinaryEncoding = iota
+	//Base64URL URL and file name safe encoding with padding as per RFC 4648
+	Base64URL
+	//Base32 standard encoding with padding as per RFC 4648
+	Base32
+	//Hex lower case hexadecimal encoding
+	Hex
+	//Base58 encoding using the bitcoin alphabet
+	Base58
+	//Ascii85 encoding as used by btoa and PostScript
+	Ascii85
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+//BinaryCodec struct encodes binary data as text. The value encoded must be a []byte, a string, an io.Reader or an
+//encoding.BinaryMarshaler. The value decoded in to must be a *[]byte, a *string, an io.Writer or an
+//encoding.BinaryUnmarshaler. Read and Write stream the content when an io.Writer or io.Reader is provided, except for
+//Base58 which needs the whole content as it is not a block encoding.
+type BinaryCodec struct {
+	encoding BinaryEncoding
+}
+
+//NewBinaryCodec function creates a BinaryCodec for the encoding e
+func NewBinaryCodec(e BinaryEncoding) *BinaryCodec {
+	return &BinaryCodec{encoding: e}
+}
+
+//NewWriter returns a writer that encodes the data written to it and writes the text to w. The writer must be closed
+//to flush any partially written block.
+func (c *BinaryCodec) NewWriter(w io.Writer) io.WriteCloser {
+	switch c.encoding {
+	case Base64URL:
+		return base64.NewEncoder(base64.URLEncoding, w)
+	case Base32:
+		return base32.NewEncoder(base32.StdEncoding, w)
+	case Hex:
+		return nopWriteCloser{hex.NewEncoder(w)}
+	case Base58:
+		return &base58Writer{w: w}
+	case Ascii85:
+		return ascii85.NewEncoder(w)
+	}
+	return base64.NewEncoder(base64.StdEncoding, w)
+}
+
+//NewReader returns a reader that decodes the text read from r
+func (c *BinaryCodec) NewReader(r io.Reader) io.Reader {
+	switch c.encoding {
+	case Base64URL:
+		return base64.NewDecoder(base64.URLEncoding, r)
+	case Base32:
+		return base32.NewDecoder(base32.StdEncoding, r)
+	case Hex:
+		return hex.NewDecoder(r)
+	case Base58:
+		return &base58Reader{r: r}
+	case Ascii85:
+		return ascii85.NewDecoder(r)
+	}
+	return base64.NewDecoder(base64.StdEncoding, r)
+}
+
+//EncodeToString function encodes the bytes of v to text
+func (c *BinaryCodec) EncodeToString(v interface{}) (string, error) {
+	b, err := binaryBytes(v)
+	if err != nil {
+		return textutils.EmptyStr, err
+	}
+	return c.encode(b), nil
+}
+
+//EncodeToBytes function encodes the bytes of v to text
+func (c *BinaryCodec) EncodeToBytes(v interface{}) ([]byte, error) {
+	s, err := c.EncodeToString(v)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+//Write function encodes v and writes the text to w. If v is an io.Reader its content is streamed.
+func (c *BinaryCodec) Write(v interface{}, w io.Writer) error {
+	wc := c.NewWriter(w)
+	var err error
+	if r, ok := v.(io.Reader); ok {
+		_, err = io.Copy(wc, r)
+	} else {
+		var b []byte
+		if b, err = binaryBytes(v); err == nil {
+			_, err = wc.Write(b)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return wc.Close()
+}
+
+//DecodeString function decodes the text s in to v
+func (c *BinaryCodec) DecodeString(s string, v interface{}) error {
+	b, err := c.decode(s)
+	if err != nil {
+		return err
+	}
+	return setBinary(b, v)
+}
+
+//DecodeBytes function decodes the text b in to v
+func (c *BinaryCodec) DecodeBytes(b []byte, v interface{}) error {
+	return c.DecodeString(string(b), v)
+}
+
+//Read function decodes the text read from r in to v. If v is an io.Writer the decoded content is streamed to it.
+//This function does not close the reader and it is the responsibility of the caller to close the reader
+func (c *BinaryCodec) Read(r io.Reader, v interface{}) error {
+	dr := c.NewReader(r)
+	if w, ok := v.(io.Writer); ok {
+		_, err := io.Copy(w, dr)
+		return err
+	}
+	b, err := ioutil.ReadAll(dr)
+	if err != nil {
+		return err
+	}
+	return setBinary(b, v)
+}
+
+func (c *BinaryCodec) encode(b []byte) string {
+	switch c.encoding {
+	case Base64URL:
+		return base64.URLEncoding.EncodeToString(b)
+	case Base32:
+		return base32.StdEncoding.EncodeToString(b)
+	case Hex:
+		return hex.EncodeToString(b)
+	case Base58:
+		return encodeBase58(b)
+	case Ascii85:
+		dst := make([]byte, ascii85.MaxEncodedLen(len(b)))
+		return string(dst[:ascii85.Encode(dst, b)])
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func (c *BinaryCodec) decode(s string) ([]byte, error) {
+	switch c.encoding {
+	case Base64URL:
+		return base64.URLEncoding.DecodeString(s)
+	case Base32:
+		return base32.StdEncoding.DecodeString(s)
+	case Hex:
+		return hex.DecodeString(s)
+	case Base58:
+		return decodeBase58(s)
+	case Ascii85:
+		dst := make([]byte, 4*len(s))
+		n, _, err := ascii85.Decode(dst, []byte(s), true)
+		return dst[:n], err
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+//binaryBytes returns the bytes to encode for v
+func binaryBytes(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case []byte:
+		return t, nil
+	case string:
+		return []byte(t), nil
+	case *[]byte:
+		return *t, nil
+	case *string:
+		return []byte(*t), nil
+	case encoding.BinaryMarshaler:
+		return t.MarshalBinary()
+	case io.Reader:
+		return ioutil.ReadAll(t)
+	}
+	return nil, fmt.Errorf("codec: cannot encode %T as binary, a []byte, string, io.Reader or "+
+		"encoding.BinaryMarshaler is required", v)
+}
+
+//setBinary stores the decoded bytes b in v
+func setBinary(b []byte, v interface{}) error {
+	switch t := v.(type) {
+	case *[]byte:
+		*t = b
+	case *string:
+		*t = string(b)
+	case encoding.BinaryUnmarshaler:
+		return t.UnmarshalBinary(b)
+	case io.Writer:
+		_, err := t.Write(b)
+		return err
+	default:
+		return fmt.Errorf("codec: cannot decode binary in to %T, a *[]byte, *string, io.Writer or "+
+			"encoding.BinaryUnmarshaler is required", v)
+	}
+	return nil
+}
+
+//encodeBase58 encodes b treating it as a big endian number. Leading zero bytes are encoded as '1'
+func encodeBase58(b []byte) string {
+	zeros := 0
+	for zeros < len(b) && b[zeros] == 0 {
+		zeros++
+	}
+	//log(256)/log(58) is less than 1.37
+	digits := make([]byte, 0, (len(b)-zeros)*137/100+1)
+	for _, x := range b[zeros:] {
+		carry := int(x)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+	var sb strings.Builder
+	sb.Grow(zeros + len(digits))
+	for i := 0; i < zeros; i++ {
+		sb.WriteByte(base58Alphabet[0])
+	}
+	for i := len(digits) - 1; i >= 0; i-- {
+		sb.WriteByte(base58Alphabet[digits[i]])
+	}
+	return sb.String()
+}
+
+//decodeBase58 decodes the base58 text s. Whitespace is not allowed
+func decodeBase58(s string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	//log(58)/log(256) is less than 0.74
+	out := make([]byte, 0, (len(s)-zeros)*74/100+1)
+	for i := zeros; i < len(s); i++ {
+		carry := strings.IndexByte(base58Alphabet, s[i])
+		if carry < 0 {
+			return nil, fmt.Errorf("codec: illegal base58 data at input byte %d", i)
+		}
+		for j := range out {
+			carry += int(out[j]) * 58
+			out[j] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			out = append(out, byte(carry))
+			carry >>= 8
+		}
+	}
+	result := make([]byte, zeros+len(out))
+	for i := range out {
+		result[len(result)-1-i] = out[i]
+	}
+	return result, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+//base58Writer buffers the data written as base58 encodes the content as a single number
+type base58Writer struct {
+	w      io.Writer
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (bw *base58Writer) Write(p []byte) (int, error) {
+	if bw.closed {
+		return 0, errors.New("codec: write to closed base58 writer")
+	}
+	return bw.buf.Write(p)
+}
+
+func (bw *base58Writer) Close() error {
+	if bw.closed {
+		return nil
+	}
+	bw.closed = true
+	_, err := io.WriteString(bw.w, encodeBase58(bw.buf.Bytes()))
+	return err
+}
+
+//base58Reader reads the whole text on the first read as base58 decodes the content as a single number
+type base58Reader struct {
+	r       io.Reader
+	decoded *bytes.Reader
+}
+
+func (br *base58Reader) Read(p []byte) (int, error) {
+	if br.decoded == nil {
+		text, err := ioutil.ReadAll(br.r)
+		if err != nil {
+			return 0, err
+		}
+		b, err := decodeBase58(strings.TrimSpace(string(text)))
+		if err != nil {
+			return 0, err
+		}
+		br.decoded = bytes.NewReader(b)
+	}
+	return br.decoded.Read(p)
+}
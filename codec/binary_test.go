@@ -0,0 +1,75 @@
+package codec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBinaryCodec(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding BinaryEncoding
+		input    []byte
+		want     string
+	}{
+		{name: "Base64", encoding: Base64, input: []byte("hello world"), want: "aGVsbG8gd29ybGQ="},
+		{name: "Base64URL", encoding: Base64URL, input: []byte{0xfb, 0xff}, want: "-_8="},
+		{name: "Base32", encoding: Base32, input: []byte("hello world"), want: "NBSWY3DPEB3W64TMMQ======"},
+		{name: "Hex", encoding: Hex, input: []byte("hello world"), want: "68656c6c6f20776f726c64"},
+		{name: "Base58", encoding: Base58, input: []byte("hello world"), want: "StV1DL6CwTryKyV"},
+		{name: "Base58LeadingZeros", encoding: Base58, input: []byte{0, 0, 1}, want: "112"},
+		{name: "Base58Empty", encoding: Base58, input: []byte{}, want: ""},
+		{name: "Ascii85", encoding: Ascii85, input: []byte("hello world"), want: "BOu!rD]j7BEbo7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewBinaryCodec(tt.encoding)
+			got, err := c.EncodeToString(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("EncodeToString() = %q, want %q", got, tt.want)
+			}
+			var decoded []byte
+			if err = c.DecodeString(got, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decoded, tt.input) {
+				t.Errorf("DecodeString() = %v, want %v", decoded, tt.input)
+			}
+
+			//streaming variants
+			buf := &bytes.Buffer{}
+			if err = c.Write(bytes.NewReader(tt.input), buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Write() = %q, want %q", buf.String(), tt.want)
+			}
+			out := &bytes.Buffer{}
+			if err = c.Read(strings.NewReader(tt.want), out); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out.Bytes(), tt.input) {
+				t.Errorf("Read() = %v, want %v", out.Bytes(), tt.input)
+			}
+		})
+	}
+}
+
+func TestBinaryCodec_Errors(t *testing.T) {
+	c := NewBinaryCodec(Base58)
+	var b []byte
+	if err := c.DecodeString("0OIl", &b); err == nil {
+		t.Error("DecodeString() expected error for characters outside the base58 alphabet")
+	}
+	if _, err := c.EncodeToString(42); err == nil {
+		t.Error("EncodeToString() expected error for unsupported type")
+	}
+	var n int
+	if err := c.DecodeString("2", &n); err == nil {
+		t.Error("DecodeString() expected error for unsupported type")
+	}
+}
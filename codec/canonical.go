@@ -0,0 +1,198 @@
+package codec
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+const hexDigits = "0123456789abcdef"
+
+//CanonicalJSON function encodes v in the JSON Canonicalization Scheme (RFC 8785).
+//Object keys are sorted, numbers are formatted as done by ECMAScript and no insignificant whitespace is written.
+//v is encoded as done by the JSON codec, with the generated encoders, the field formats and the union discriminators,
+//so that a value has the same canonical form here and with the Canonical encoder option.
+func CanonicalJSON(v interface{}) ([]byte, error) {
+	b, err := marshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalizeJSON(b)
+}
+
+//CanonicalDigest function returns the SHA-256 digest of the canonical JSON form of v
+func CanonicalDigest(v interface{}) ([sha256.Size]byte, error) {
+	b, err := CanonicalJSON(v)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(b), nil
+}
+
+//canonicalizeJSON rewrites the JSON in b in its canonical form
+func canonicalizeJSON(b []byte) ([]byte, error) {
+	var tree interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := writeCanonical(buf, tree); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//writeCanonical writes the node of a decoded JSON tree in the canonical form
+func writeCanonical(buf *bytes.Buffer, node interface{}) error {
+	switch v := node.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case string:
+		writeCanonicalString(buf, v)
+	case json.Number:
+		f, err := strconv.ParseFloat(v.String(), 64)
+		if err != nil {
+			return err
+		}
+		s, err := formatCanonicalNumber(f)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case []interface{}:
+		buf.WriteByte(textutils.OpenBracketChar)
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteByte(textutils.CommaChar)
+			}
+			if err := writeCanonical(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(textutils.CloseBracketChar)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return lessUTF16(keys[i], keys[j])
+		})
+		buf.WriteByte(textutils.OpenBraceChar)
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(textutils.CommaChar)
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(textutils.ColonChar)
+			if err := writeCanonical(buf, v[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(textutils.CloseBraceChar)
+	}
+	return nil
+}
+
+//lessUTF16 compares the strings by their UTF-16 code units as required for the sorting of keys
+func lessUTF16(a, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+//writeCanonicalString writes the string s escaping only the characters that are required to be escaped
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte(textutils.DoubleQuoteChar)
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[r>>4])
+				buf.WriteByte(hexDigits[r&0xF])
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte(textutils.DoubleQuoteChar)
+}
+
+//formatCanonicalNumber formats f as done by the ECMAScript Number.prototype.toString
+func formatCanonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return textutils.EmptyStr, errors.New("json: NaN and Infinity are not valid in canonical JSON")
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	var sb strings.Builder
+	if f < 0 {
+		sb.WriteByte(textutils.HyphenChar)
+		f = -f
+	}
+	//shortest representation that round trips in the form d.ddde±xx
+	e := strconv.FormatFloat(f, 'e', -1, 64)
+	expIdx := strings.IndexByte(e, textutils.ELowerChar)
+	digits := strings.Replace(e[:expIdx], textutils.PeriodStr, textutils.EmptyStr, 1)
+	exp, _ := strconv.Atoi(e[expIdx+1:])
+	k := len(digits)
+	n := exp + 1
+	switch {
+	case k <= n && n <= 21:
+		sb.WriteString(digits)
+		sb.WriteString(strings.Repeat("0", n-k))
+	case 0 < n && n <= 21:
+		sb.WriteString(digits[:n])
+		sb.WriteByte(textutils.PeriodChar)
+		sb.WriteString(digits[n:])
+	case -6 < n && n <= 0:
+		sb.WriteString("0.")
+		sb.WriteString(strings.Repeat("0", -n))
+		sb.WriteString(digits)
+	default:
+		sb.WriteByte(digits[0])
+		if k > 1 {
+			sb.WriteByte(textutils.PeriodChar)
+			sb.WriteString(digits[1:])
+		}
+		sb.WriteByte(textutils.ELowerChar)
+		if n-1 > 0 {
+			sb.WriteByte(textutils.PlusChar)
+		}
+		sb.WriteString(strconv.Itoa(n - 1))
+	}
+	return sb.String(), nil
+}
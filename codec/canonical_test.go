@@ -0,0 +1,91 @@
+package codec
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "RFC8785Sample",
+			input: `{"numbers":[333333333.33333329,1E30,4.50,2e-3,0.000000000000000000000000001],"string":"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/","literals":[null,true,false]}`,
+			want:  `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		{
+			name:  "SortByUTF16",
+			input: `{"\u20ac":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","\ud83d\ude00":"Emoji: Grinning Face","\u0080":"Control","\u00f6":"Latin Small Letter O With Diaeresis"}`,
+			want:  "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\",\"\U0001f600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CanonicalJSON(json.RawMessage(tt.input))
+			if err != nil {
+				t.Fatalf("CanonicalJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("CanonicalJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCanonicalNumber(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{0, "0"},
+		{-1.5, "-1.5"},
+		{100, "100"},
+		{1e20, "100000000000000000000"},
+		{1e21, "1e+21"},
+		{0.000001, "0.000001"},
+		{1e-7, "1e-7"},
+		{9007199254740993, "9007199254740992"},
+		{5e-324, "5e-324"},
+		{1.7976931348623157e308, "1.7976931348623157e+308"},
+	}
+	for _, tt := range tests {
+		if got, _ := formatCanonicalNumber(tt.in); got != tt.want {
+			t.Errorf("formatCanonicalNumber(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalDigest(t *testing.T) {
+	a, err := CanonicalDigest(map[string]interface{}{"b": 1, "a": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := CanonicalDigest(json.RawMessage(`{ "a" : "x", "b" : 1.0 }`))
+	if a != b {
+		t.Errorf("CanonicalDigest() = %s, want %s", hex.EncodeToString(a[:]), hex.EncodeToString(b[:]))
+	}
+}
+
+func TestCanonicalJSON_MatchesCodec(t *testing.T) {
+	v := schedule{Start: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), Interval: time.Hour, Timeout: time.Minute}
+	got, err := CanonicalJSON(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := NewJSONCodec(nil).WithEncoderOptions(&EncoderOptions{Canonical: true}).EncodeToBytes(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("CanonicalJSON() = %s, want %s", got, want)
+	}
+	if wantStart := `"start":1622505600`; !json.Valid(got) || !strings.Contains(string(got), wantStart) {
+		t.Errorf("CanonicalJSON() = %s, want the unix format %s", got, wantStart)
+	}
+}
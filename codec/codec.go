@@ -39,8 +39,26 @@ type FieldMeta struct {
 	Dimension   int
 	Required    bool
 	TargetNames map[string]string
-}
-
+	//Index sequence of the field used with reflect.Value.FieldByIndex
+	Index []int
+	//Constraints declared on the field using struct tags
+	Constraints *Constraints
+	//OmitEmpty is set for the fields with the omitempty option in the json tag
+	OmitEmpty bool
+	//DiffIgnored is set for the fields tagged with diff:"-" which are skipped by Diff
+	DiffIgnored bool
+	//Sensitive is set for the fields tagged with sensitive which are masked by encoders using the Redacted option
+	Sensitive bool
+	//OmitSensitive is set for the fields tagged with sensitive:"omit" which are dropped instead of masked
+	OmitSensitive bool
+	//stringMeta holds the attributes used when the value of the field is written as a string
+	stringMeta *StringFieldMeta
+}
+
+//StringFieldMeta struct holds the attributes used when the value of a field is written as a string.
+//Format is read from the format tag and selects the encoding of time.Time and time.Duration values, see FormatRFC3339
+//and the others, or the bytes format of config.Bind. Length is the maximum length from the max tag of a string field
+//and 0 if it is not bounded.
 type StringFieldMeta struct {
 	FieldMeta
 	DefaultVal string
@@ -135,13 +153,13 @@ type BooleanFieldMeta struct {
 //StringEncoder interface
 type StringEncoder interface {
 	//EncodeToString will encode  a type to string
-	EncodeToString(v interface{}) string
+	EncodeToString(v interface{}) (string, error)
 }
 
 //BytesEncoder interface
 type BytesEncoder interface {
 	// EncodeToBytes will encode the provided type to []byte
-	EncodeToBytes(v interface{}) []byte
+	EncodeToBytes(v interface{}) ([]byte, error)
 }
 
 //StringDecoder interface
@@ -196,11 +214,15 @@ func Get() baseCodec {
 }
 
 type validationError struct {
+	field   string
 	message string
 }
 
 func (v validationError) Error() string {
-	return v.message
+	if v.field == textutils.EmptyStr {
+		return v.message
+	}
+	return v.field + textutils.ColonStr + textutils.WhiteSpaceStr + v.message
 }
 
 func (d baseCodec) DecodeString(s string, v interface{}) error {
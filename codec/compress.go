@@ -0,0 +1,180 @@
+package codec
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//CompressionAlgo specifies the compression algorithm applied by a compressed codec
+type CompressionAlgo int
+
+const (
+	//Gzip compression as per RFC 1952
+	Gzip CompressionAlgo = iota
+	//Deflate raw compression as per RFC 1951
+	Deflate
+	//Zlib compression as per RFC 1950
+	Zlib
+)
+
+//DefaultMaxDecompressedSize is the size limit in bytes of the content read by a compressed codec after decompression
+const DefaultMaxDecompressedSize int64 = 64 << 20
+
+//compressedCodec wraps a Codec compressing the encoded output and decompressing the input before decoding
+type compressedCodec struct {
+	codec   Codec
+	algo    CompressionAlgo
+	maxSize int64
+}
+
+//Compressed function wraps the Codec c so that the output of Write is compressed using algo.
+//Read detects gzip content by its magic bytes and decompresses it irrespective of algo. zlib content is detected by
+//its header and, as raw deflate has no header, raw deflate is only attempted if algo is Deflate. As a zlib header may
+//also start plain text, zlib and raw deflate are used only if the whole input is a valid compressed stream. Content
+//that is not compressed is passed to c as is, which allows reading data stored before the codec was wrapped.
+//The content read is limited to DefaultMaxDecompressedSize bytes after decompression.
+func Compressed(c Codec, algo CompressionAlgo) Codec {
+	return CompressedWithLimit(c, algo, DefaultMaxDecompressedSize)
+}
+
+//CompressedWithLimit function wraps the Codec c as done by Compressed. Read fails if the content exceeds maxSize
+//bytes after decompression, which protects against small inputs expanding without bound.
+func CompressedWithLimit(c Codec, algo CompressionAlgo, maxSize int64) Codec {
+	return &compressedCodec{
+		codec:   c,
+		algo:    algo,
+		maxSize: maxSize,
+	}
+}
+
+func (cc *compressedCodec) EncodeToString(v interface{}) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := cc.Write(v, buf); err != nil {
+		return textutils.EmptyStr, err
+	}
+	return buf.String(), nil
+}
+
+func (cc *compressedCodec) EncodeToBytes(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := cc.Write(v, buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//Write encodes v using the wrapped codec and writes the compressed output to w
+func (cc *compressedCodec) Write(v interface{}, w io.Writer) error {
+	var cw io.WriteCloser
+	var err error
+	switch cc.algo {
+	case Gzip:
+		cw = gzip.NewWriter(w)
+	case Deflate:
+		cw, err = flate.NewWriter(w, flate.DefaultCompression)
+	case Zlib:
+		cw = zlib.NewWriter(w)
+	default:
+		err = errors.New("compress: unknown compression algorithm")
+	}
+	if err != nil {
+		return err
+	}
+	if err = cc.codec.Write(v, cw); err != nil {
+		_ = cw.Close()
+		return err
+	}
+	return cw.Close()
+}
+
+func (cc *compressedCodec) DecodeString(s string, v interface{}) error {
+	return cc.Read(strings.NewReader(s), v)
+}
+
+func (cc *compressedCodec) DecodeBytes(b []byte, v interface{}) error {
+	return cc.Read(bytes.NewReader(b), v)
+}
+
+//Read detects the compression of the content in r, decompresses it and decodes it using the wrapped codec
+//This function does not close the reader and it is the responsibility of the caller to close the reader
+func (cc *compressedCodec) Read(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(io.LimitReader(r, cc.maxSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(b)) > cc.maxSize {
+		return cc.sizeError()
+	}
+	switch {
+	case isGzip(b):
+		if b, err = cc.decompress(b, func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		}); err != nil {
+			return err
+		}
+	case isZlib(b):
+		b, err = cc.decompressOrPass(b, zlib.NewReader)
+	case cc.algo == Deflate && len(b) > 0:
+		b, err = cc.decompressOrPass(b, func(r io.Reader) (io.ReadCloser, error) {
+			return flate.NewReader(r), nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return cc.codec.DecodeBytes(b, v)
+}
+
+//errInvalidStream reports a compressed stream that cannot be fully decompressed
+var errInvalidStream = errors.New("compress: invalid compressed stream")
+
+//decompressOrPass decompresses b or returns b as is if it is not a valid compressed stream
+func (cc *compressedCodec) decompressOrPass(b []byte, open func(io.Reader) (io.ReadCloser, error)) ([]byte, error) {
+	out, err := cc.decompress(b, open)
+	if err == errInvalidStream {
+		return b, nil
+	}
+	return out, err
+}
+
+//decompress decompresses b which must hold a single complete compressed stream of at most maxSize bytes
+func (cc *compressedCodec) decompress(b []byte, open func(io.Reader) (io.ReadCloser, error)) ([]byte, error) {
+	br := bytes.NewReader(b)
+	dr, err := open(br)
+	if err != nil {
+		return nil, errInvalidStream
+	}
+	defer dr.Close()
+	out, err := ioutil.ReadAll(io.LimitReader(dr, cc.maxSize+1))
+	if int64(len(out)) > cc.maxSize {
+		return nil, cc.sizeError()
+	}
+	if err != nil || br.Len() != 0 {
+		return nil, errInvalidStream
+	}
+	return out, nil
+}
+
+func (cc *compressedCodec) sizeError() error {
+	return fmt.Errorf("compress: content exceeds %d bytes", cc.maxSize)
+}
+
+//isGzip checks for the gzip magic bytes 0x1f 0x8b followed by the deflate compression method
+func isGzip(h []byte) bool {
+	return len(h) >= 3 && h[0] == 0x1f && h[1] == 0x8b && h[2] == 8
+}
+
+//isZlib checks for a zlib header with the deflate compression method, a valid header checksum and no preset
+//dictionary
+func isZlib(h []byte) bool {
+	return len(h) >= 2 && h[0]&0x0f == 8 && h[0]>>4 <= 7 && (uint16(h[0])<<8|uint16(h[1]))%31 == 0 && h[1]&0x20 == 0
+}
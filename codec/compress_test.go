@@ -0,0 +1,80 @@
+package codec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompressed(t *testing.T) {
+	in := testServer{Host: "localhost", Port: 8080, Retries: []int{1, 2, 3}}
+	plain, _ := NewJSONCodec(nil).EncodeToBytes(in)
+	for _, algo := range []CompressionAlgo{Gzip, Deflate, Zlib} {
+		b, err := Compressed(NewJSONCodec(nil), algo).EncodeToBytes(in)
+		if err != nil {
+			t.Fatalf("EncodeToBytes() algo %d error = %v", algo, err)
+		}
+		//Gzip and Zlib content must be detected irrespective of the algo of the reading codec
+		readers := []CompressionAlgo{algo}
+		if algo != Deflate {
+			readers = append(readers, Gzip, Zlib)
+		}
+		for _, r := range readers {
+			var got testServer
+			if err = Compressed(NewJSONCodec(nil), r).DecodeBytes(b, &got); err != nil {
+				t.Fatalf("DecodeBytes() algo %d reader %d error = %v", algo, r, err)
+			}
+			if !reflect.DeepEqual(got, in) {
+				t.Errorf("DecodeBytes() algo %d reader %d = %v, want %v", algo, r, got, in)
+			}
+		}
+	}
+	var got testServer
+	if err := Compressed(NewJSONCodec(nil), Gzip).DecodeBytes(plain, &got); err != nil || !reflect.DeepEqual(got, in) {
+		t.Errorf("DecodeBytes() uncompressed = %v, %v", got, err)
+	}
+}
+
+//textCodec decodes the content as is in to a *string
+type textCodec struct {
+	baseCodec
+}
+
+func (textCodec) DecodeBytes(b []byte, v interface{}) error {
+	*v.(*string) = string(b)
+	return nil
+}
+
+func TestCompressed_Detection(t *testing.T) {
+	for _, text := range []string{"x^ not zlib", "H\r not zlib", `{"host":"localhost"}`, "plain text"} {
+		for _, algo := range []CompressionAlgo{Gzip, Deflate, Zlib} {
+			var got string
+			if err := Compressed(textCodec{}, algo).DecodeString(text, &got); err != nil || got != text {
+				t.Errorf("DecodeString(%q) algo %d = %q, %v", text, algo, got, err)
+			}
+		}
+	}
+	b, err := Compressed(NewJSONCodec(nil), Gzip).EncodeToBytes(testServer{Host: "localhost"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got testServer
+	if err = Compressed(NewJSONCodec(nil), Gzip).DecodeBytes(b[:len(b)-4], &got); err == nil {
+		t.Error("DecodeBytes() must fail for a truncated gzip stream")
+	}
+}
+
+func TestCompressed_Limit(t *testing.T) {
+	bomb, err := Compressed(NewJSONCodec(nil), Gzip).EncodeToBytes(strings.Repeat("a", 1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	err = CompressedWithLimit(NewJSONCodec(nil), Gzip, 64<<10).DecodeBytes(bomb, &got)
+	if err == nil || !strings.Contains(err.Error(), "exceeds 65536 bytes") {
+		t.Errorf("DecodeBytes() error = %v, want the size limit error", err)
+	}
+	if err = Compressed(NewJSONCodec(nil), Gzip).DecodeBytes(bomb, &got); err != nil || len(got) != 1<<20 {
+		t.Errorf("DecodeBytes() = %d bytes, %v", len(got), err)
+	}
+}
@@ -0,0 +1,469 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//RoundingMode defines how a Decimal is rounded when digits are dropped
+type RoundingMode int
+
+const (
+	//RoundHalfUp rounds to the nearest neighbour, ties are rounded away from zero
+	RoundHalfUp RoundingMode = iota
+	//RoundHalfEven rounds to the nearest neighbour, ties are rounded to the even neighbour (banker's rounding)
+	RoundHalfEven
+	//RoundHalfDown rounds to the nearest neighbour, ties are rounded towards zero
+	RoundHalfDown
+	//RoundUp rounds away from zero
+	RoundUp
+	//RoundDown rounds towards zero, i.e. truncates
+	RoundDown
+	//RoundCeiling rounds towards positive infinity
+	RoundCeiling
+	//RoundFloor rounds towards negative infinity
+	RoundFloor
+)
+
+//MaxDecimalScale is the largest absolute scale of a Decimal and the largest absolute exponent accepted by
+//ParseDecimal. It limits the memory and the time spent on untrusted input such as 1e400000000.
+const MaxDecimalScale = 4096
+
+//ErrDivisionByZero is returned when a Decimal is divided by zero
+var ErrDivisionByZero = errors.New("decimal: division by zero")
+
+//ErrScaleOutOfRange is returned when the scale of a Decimal would exceed MaxDecimalScale
+var ErrScaleOutOfRange = errors.New("decimal: scale out of range")
+
+var (
+	decimalType = reflect.TypeOf(Decimal{})
+	bigTen      = big.NewInt(10)
+)
+
+//Decimal struct is an arbitrary precision decimal number with a fixed scale, suitable for monetary amounts.
+//The value is unscaled * 10^-scale, e.g. 12.50 has the unscaled value 1250 and the scale 2. The zero value is 0.
+//Decimal values are immutable, all the operations return a new value.
+//
+//Decimal values are encoded as JSON strings, e.g. "12.50", so that no precision is lost by consumers parsing JSON
+//numbers as floats. Both strings and numbers are accepted when decoding. The text and binary encodings are used by the
+//form, binary and protobuf codecs. The protobuf encoding is a message with the value as string field 1, as done by
+//google.type.Decimal.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+//NewDecimal function creates the Decimal unscaled * 10^-scale. A negative scale multiplies unscaled by 10^-scale.
+//ErrScaleOutOfRange is returned if the absolute value of scale is larger than MaxDecimalScale.
+func NewDecimal(unscaled int64, scale int32) (Decimal, error) {
+	return NewDecimalFromBigInt(big.NewInt(unscaled), scale)
+}
+
+//NewDecimalFromBigInt function creates the Decimal unscaled * 10^-scale. unscaled is copied.
+//ErrScaleOutOfRange is returned if the absolute value of scale is larger than MaxDecimalScale.
+func NewDecimalFromBigInt(unscaled *big.Int, scale int32) (Decimal, error) {
+	if err := checkScale(int64(scale)); err != nil {
+		return Decimal{}, err
+	}
+	return newDecimal(new(big.Int).Set(unscaled), scale), nil
+}
+
+//newDecimal creates the Decimal u * 10^-scale taking the ownership of u. scale must be within MaxDecimalScale
+func newDecimal(u *big.Int, scale int32) Decimal {
+	if scale < 0 {
+		u.Mul(u, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{unscaled: u, scale: scale}
+}
+
+//checkScale returns ErrScaleOutOfRange if the absolute value of scale is larger than MaxDecimalScale
+func checkScale(scale int64) error {
+	if scale > MaxDecimalScale || scale < -MaxDecimalScale {
+		return ErrScaleOutOfRange
+	}
+	return nil
+}
+
+//NewDecimalFromFloat function creates the Decimal with the shortest representation of f, e.g. 0.1 results in 0.1
+//and not in the exact binary value of the float. NaN and infinities are rejected.
+func NewDecimalFromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("decimal: cannot convert %v", f)
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+//ParseDecimal function parses a decimal number in the plain, e.g. -12.50, or the exponent notation, e.g. 1.25e1.
+//The scale of the result is the number of fractional digits, trailing zeros included. Numbers with an exponent or a
+//scale beyond MaxDecimalScale are rejected.
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exp := s, int64(0)
+	if idx := strings.IndexAny(s, "eE"); idx != -1 {
+		var err error
+		if exp, err = strconv.ParseInt(s[idx+1:], 10, 32); err != nil {
+			return Decimal{}, fmt.Errorf("decimal: invalid exponent in %q", s)
+		}
+		if exp > MaxDecimalScale || exp < -MaxDecimalScale {
+			return Decimal{}, fmt.Errorf("decimal: exponent out of range in %q", s)
+		}
+		mantissa = s[:idx]
+	}
+	digits := mantissa
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		digits = digits[1:]
+	}
+	intPart, fracPart := digits, textutils.EmptyStr
+	if idx := strings.IndexByte(digits, '.'); idx != -1 {
+		intPart, fracPart = digits[:idx], digits[idx+1:]
+	}
+	if intPart == textutils.EmptyStr && fracPart == textutils.EmptyStr || !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, fmt.Errorf("decimal: invalid number %q", s)
+	}
+	u, _ := new(big.Int).SetString(intPart+fracPart, 10)
+	if mantissa[0] == '-' {
+		u.Neg(u)
+	}
+	scale := int64(len(fracPart)) - exp
+	if checkScale(scale) != nil {
+		return Decimal{}, fmt.Errorf("decimal: scale out of range in %q", s)
+	}
+	return newDecimal(u, int32(scale)), nil
+}
+
+//MustParseDecimal function parses s as done by ParseDecimal and panics if it is not a valid number.
+//It is meant for constants, e.g. var rate = codec.MustParseDecimal("0.19")
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+//pow10 returns 10^n
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+//int returns the unscaled value. The zero value of Decimal has a nil unscaled value
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+//Scale returns the number of fractional digits of d
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+//Unscaled returns a copy of the unscaled value of d
+func (d Decimal) Unscaled() *big.Int {
+	return new(big.Int).Set(d.int())
+}
+
+//Sign returns -1, 0 or +1 depending on the sign of d
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+//IsZero checks if d is 0 irrespective of its scale
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+//rescale returns the unscaled value of d with the larger scale s
+func (d Decimal) rescale(s int32) *big.Int {
+	if s == d.scale {
+		return d.int()
+	}
+	return new(big.Int).Mul(d.int(), pow10(s-d.scale))
+}
+
+//align returns the unscaled values of d and o with the same scale
+func (d Decimal) align(o Decimal) (*big.Int, *big.Int, int32) {
+	s := d.scale
+	if o.scale > s {
+		s = o.scale
+	}
+	return d.rescale(s), o.rescale(s), s
+}
+
+//Cmp compares d and o and returns -1 if d < o, 0 if d == o and +1 if d > o. The scale is not compared.
+func (d Decimal) Cmp(o Decimal) int {
+	a, b, _ := d.align(o)
+	return a.Cmp(b)
+}
+
+//Equal checks if d and o have the same value irrespective of their scale, e.g. 1.5 is equal to 1.50
+func (d Decimal) Equal(o Decimal) bool {
+	return d.Cmp(o) == 0
+}
+
+//Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+//Abs returns the absolute value of d
+func (d Decimal) Abs() Decimal {
+	return Decimal{unscaled: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+//Add returns d + o with the larger scale of both
+func (d Decimal) Add(o Decimal) Decimal {
+	a, b, s := d.align(o)
+	return Decimal{unscaled: new(big.Int).Add(a, b), scale: s}
+}
+
+//Sub returns d - o with the larger scale of both
+func (d Decimal) Sub(o Decimal) Decimal {
+	a, b, s := d.align(o)
+	return Decimal{unscaled: new(big.Int).Sub(a, b), scale: s}
+}
+
+//Mul returns d * o with the sum of the scales. Use Round to get back to the scale needed.
+//ErrScaleOutOfRange is returned if the sum of the scales is larger than MaxDecimalScale.
+func (d Decimal) Mul(o Decimal) (Decimal, error) {
+	scale := int64(d.scale) + int64(o.scale)
+	if err := checkScale(scale); err != nil {
+		return Decimal{}, err
+	}
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), o.int()), scale: int32(scale)}, nil
+}
+
+//Div returns d / o rounded to scale using the mode. ErrDivisionByZero is returned if o is 0 and ErrScaleOutOfRange
+//if scale is larger than MaxDecimalScale.
+func (d Decimal) Div(o Decimal, scale int32, mode RoundingMode) (Decimal, error) {
+	if o.IsZero() {
+		return Decimal{}, ErrDivisionByZero
+	}
+	if err := checkScale(int64(scale)); err != nil {
+		return Decimal{}, err
+	}
+	if scale < 0 {
+		scale = 0
+	}
+	//d / o = (du / ou) * 10^(os - ds), hence the result unscaled is du * 10^(scale + os - ds) / ou
+	num, den := new(big.Int).Set(d.int()), new(big.Int).Set(o.int())
+	if exp := int64(scale) + int64(o.scale) - int64(d.scale); exp >= 0 {
+		num.Mul(num, pow10(int32(exp)))
+	} else {
+		den.Mul(den, pow10(int32(-exp)))
+	}
+	return Decimal{unscaled: roundQuo(num, den, mode), scale: scale}, nil
+}
+
+//Round returns d with the scale provided. Digits are dropped using the mode if the scale of d is larger, trailing
+//zeros are added if it is smaller. ErrScaleOutOfRange is returned if scale is larger than MaxDecimalScale.
+func (d Decimal) Round(scale int32, mode RoundingMode) (Decimal, error) {
+	if err := checkScale(int64(scale)); err != nil {
+		return Decimal{}, err
+	}
+	if scale < 0 {
+		scale = 0
+	}
+	if scale >= d.scale {
+		return Decimal{unscaled: d.rescale(scale), scale: scale}, nil
+	}
+	return Decimal{unscaled: roundQuo(d.int(), pow10(d.scale-scale), mode), scale: scale}, nil
+}
+
+//roundQuo returns num / den rounded to an integer using the mode
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	neg := num.Sign()*den.Sign() < 0
+	absDen := new(big.Int).Abs(den)
+	q, r := new(big.Int).QuoRem(new(big.Int).Abs(num), absDen, new(big.Int))
+	if r.Sign() != 0 {
+		half := r.Lsh(r, 1).Cmp(absDen)
+		var inc bool
+		switch mode {
+		case RoundHalfUp:
+			inc = half >= 0
+		case RoundHalfEven:
+			inc = half > 0 || half == 0 && q.Bit(0) == 1
+		case RoundHalfDown:
+			inc = half > 0
+		case RoundUp:
+			inc = true
+		case RoundCeiling:
+			inc = !neg
+		case RoundFloor:
+			inc = neg
+		}
+		if inc {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	if neg {
+		q.Neg(q)
+	}
+	return q
+}
+
+//Float64 returns the float64 closest to d
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+//String returns d in the plain notation with all its fractional digits, e.g. -12.50
+func (d Decimal) String() string {
+	u := d.int()
+	digits := new(big.Int).Abs(u).String()
+	var sb strings.Builder
+	if u.Sign() < 0 {
+		sb.WriteByte('-')
+	}
+	if d.scale == 0 {
+		sb.WriteString(digits)
+		return sb.String()
+	}
+	if pad := int(d.scale) - len(digits) + 1; pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+	sb.WriteString(digits[:point])
+	sb.WriteByte('.')
+	sb.WriteString(digits[point:])
+	return sb.String()
+}
+
+//MarshalText implements encoding.TextMarshaler
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+//UnmarshalText implements encoding.TextUnmarshaler
+func (d *Decimal) UnmarshalText(b []byte) error {
+	v, err := ParseDecimal(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+//MarshalJSON implements json.Marshaler. The value is written as a JSON string
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return AppendJSONString(nil, d.String()), nil
+}
+
+//UnmarshalJSON implements json.Unmarshaler. Both JSON strings and numbers are accepted, null is ignored
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		var err error
+		if s, err = strconv.Unquote(s); err != nil {
+			return fmt.Errorf("decimal: invalid JSON string %s", b)
+		}
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+//MarshalBinary implements encoding.BinaryMarshaler. The encoding is the scale as a varint followed by a sign byte and
+//the big endian magnitude of the unscaled value
+func (d Decimal) MarshalBinary() ([]byte, error) {
+	buf := appendVarint(nil, uint64(d.scale))
+	u := d.int()
+	if u.Sign() < 0 {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	return append(buf, u.Bytes()...), nil
+}
+
+//UnmarshalBinary implements encoding.BinaryUnmarshaler
+func (d *Decimal) UnmarshalBinary(b []byte) error {
+	scale, n := binary.Uvarint(b)
+	if n <= 0 || scale > MaxDecimalScale || len(b) == n || b[n] > 1 {
+		return errors.New("decimal: invalid binary encoding")
+	}
+	u := new(big.Int).SetBytes(b[n+1:])
+	if b[n] == 1 {
+		u.Neg(u)
+	}
+	*d = Decimal{unscaled: u, scale: int32(scale)}
+	return nil
+}
+
+//CheckDecimalMin function returns a violation for path if val is less than min
+func CheckDecimalMin(path string, val, min Decimal) error {
+	if val.Cmp(min) < 0 {
+		return validationError{field: path, message: "must be greater than or equal to " + min.String()}
+	}
+	return nil
+}
+
+//CheckDecimalMax function returns a violation for path if val is greater than max
+func CheckDecimalMax(path string, val, max Decimal) error {
+	if val.Cmp(max) > 0 {
+		return validationError{field: path, message: "must be less than or equal to " + max.String()}
+	}
+	return nil
+}
+
+//CheckScale function returns a violation for path if val cannot be represented with scale fractional digits.
+//Trailing zeros are ignored, e.g. 1.50 is valid for the scale 1.
+func CheckScale(path string, val Decimal, scale int32) error {
+	if scale >= val.scale {
+		return nil
+	}
+	if rounded, _ := val.Round(scale, RoundDown); !rounded.Equal(val) {
+		return validationError{field: path, message: "must have at most " + strconv.Itoa(int(scale)) +
+			" fractional digits"}
+	}
+	return nil
+}
+
+//checkDecimal checks the min, max and scale constraints on the Decimal d
+func checkDecimal(name string, d Decimal, c *Constraints) ValidationErrors {
+	var errs ValidationErrors
+	if c.minDecimal != nil {
+		errs = AppendErrors(errs, CheckDecimalMin(name, d, *c.minDecimal))
+	}
+	if c.maxDecimal != nil {
+		errs = AppendErrors(errs, CheckDecimalMax(name, d, *c.maxDecimal))
+	}
+	if c.Scale != nil {
+		errs = AppendErrors(errs, CheckScale(name, d, *c.Scale))
+	}
+	return errs
+}
+
+//parseDecimalBound reads a numeric bound tag as a Decimal so that Decimal fields are compared without rounding
+func parseDecimalBound(tag reflect.StructTag, name string) *Decimal {
+	s, ok := tag.Lookup(name)
+	if !ok {
+		return nil
+	}
+	d, err := ParseDecimal(s)
+	if err != nil {
+		//the error is reported by parseBound
+		return nil
+	}
+	return &d
+}
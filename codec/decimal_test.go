@@ -0,0 +1,236 @@
+package codec
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		scale   int32
+		wantErr bool
+	}{
+		{input: "12.50", want: "12.50", scale: 2},
+		{input: "-0.005", want: "-0.005", scale: 3},
+		{input: "+7", want: "7", scale: 0},
+		{input: ".5", want: "0.5", scale: 1},
+		{input: "1.25e1", want: "12.5", scale: 1},
+		{input: "15E-3", want: "0.015", scale: 3},
+		{input: "3e2", want: "300", scale: 0},
+		{input: "123456789012345678901234567890.123456789", want: "123456789012345678901234567890.123456789", scale: 9},
+		{input: "", wantErr: true},
+		{input: "-", wantErr: true},
+		{input: ".", wantErr: true},
+		{input: "1,5", wantErr: true},
+		{input: "1e", wantErr: true},
+		{input: "NaN", wantErr: true},
+		{input: "1e4096", want: "1" + strings.Repeat("0", 4096), scale: 0},
+		{input: "1e-4096", want: "0." + strings.Repeat("0", 4095) + "1", scale: 4096},
+		{input: "1e4097", wantErr: true},
+		{input: "1e400000000", wantErr: true},
+		{input: "0.1e-4096", wantErr: true},
+		{input: "0." + strings.Repeat("0", 4097), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDecimal(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDecimal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (got.String() != tt.want || got.Scale() != tt.scale) {
+				t.Errorf("ParseDecimal() = %s scale %d, want %s scale %d", got, got.Scale(), tt.want, tt.scale)
+			}
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	a, b := MustParseDecimal("10.25"), MustParseDecimal("-3.1")
+	tests := []struct {
+		name string
+		got  Decimal
+		want string
+	}{
+		{name: "Add", got: a.Add(b), want: "7.15"},
+		{name: "Sub", got: a.Sub(b), want: "13.35"},
+		{name: "Mul", got: mustDecimal(a.Mul(b)), want: "-31.775"},
+		{name: "Neg", got: b.Neg(), want: "3.1"},
+		{name: "Abs", got: b.Abs(), want: "3.1"},
+		{name: "ZeroValue", got: Decimal{}.Add(a), want: "10.25"},
+		{name: "Float", got: mustDecimalFromFloat(t, 0.1), want: "0.1"},
+	}
+	for _, tt := range tests {
+		if tt.got.String() != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+	if q, err := MustParseDecimal("10").Div(MustParseDecimal("3"), 4, RoundHalfUp); err != nil || q.String() != "3.3333" {
+		t.Errorf("Div() = %s, %v", q, err)
+	}
+	if q, err := MustParseDecimal("-2").Div(MustParseDecimal("0.3"), 2, RoundHalfUp); err != nil || q.String() != "-6.67" {
+		t.Errorf("Div() = %s, %v", q, err)
+	}
+	if _, err := a.Div(Decimal{}, 2, RoundHalfUp); err != ErrDivisionByZero {
+		t.Errorf("Div() by zero error = %v", err)
+	}
+	if !MustParseDecimal("1.5").Equal(MustParseDecimal("1.500")) || a.Cmp(b) != 1 || !MustParseDecimal("0.00").IsZero() {
+		t.Error("Equal() and Cmp() must ignore the scale")
+	}
+}
+
+func mustDecimal(d Decimal, err error) Decimal {
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestDecimal_ScaleBounds(t *testing.T) {
+	tiny := MustParseDecimal("1e-4000")
+	tests := []struct {
+		name string
+		op   func() (Decimal, error)
+	}{
+		{name: "Mul", op: func() (Decimal, error) { return tiny.Mul(tiny) }},
+		{name: "Div", op: func() (Decimal, error) { return tiny.Div(tiny, math.MaxInt32, RoundHalfUp) }},
+		{name: "Round", op: func() (Decimal, error) { return tiny.Round(MaxDecimalScale+1, RoundHalfUp) }},
+		{name: "RoundNegative", op: func() (Decimal, error) { return tiny.Round(math.MinInt32, RoundHalfUp) }},
+		{name: "NewDecimal", op: func() (Decimal, error) { return NewDecimal(1, MaxDecimalScale+1) }},
+		{name: "NewDecimalNegative", op: func() (Decimal, error) { return NewDecimal(1, -MaxDecimalScale-1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.op(); err != ErrScaleOutOfRange {
+				t.Errorf("%s() error = %v, want %v", tt.name, err, ErrScaleOutOfRange)
+			}
+		})
+	}
+	//every Decimal that can be created can be encoded and decoded again
+	d := mustDecimal(MustParseDecimal("1e-2048").Mul(MustParseDecimal("1e-2048")))
+	b, _ := d.MarshalBinary()
+	var got Decimal
+	if err := got.UnmarshalBinary(b); err != nil || !got.Equal(d) {
+		t.Errorf("UnmarshalBinary() = %s, %v", got, err)
+	}
+	if d, err := NewDecimal(12, -2); err != nil || d.String() != "1200" {
+		t.Errorf("NewDecimal() = %s, %v", d, err)
+	}
+}
+
+func mustDecimalFromFloat(t *testing.T, f float64) Decimal {
+	d, err := NewDecimalFromFloat(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDecimal_Round(t *testing.T) {
+	inputs := []string{"2.5", "-2.5", "1.45", "-1.45", "0.001", "3.999"}
+	tests := []struct {
+		mode  RoundingMode
+		scale int32
+		want  []string
+	}{
+		{mode: RoundHalfUp, want: []string{"3", "-3", "1", "-1", "0", "4"}},
+		{mode: RoundHalfEven, want: []string{"2", "-2", "1", "-1", "0", "4"}},
+		{mode: RoundHalfDown, want: []string{"2", "-2", "1", "-1", "0", "4"}},
+		{mode: RoundUp, want: []string{"3", "-3", "2", "-2", "1", "4"}},
+		{mode: RoundDown, want: []string{"2", "-2", "1", "-1", "0", "3"}},
+		{mode: RoundCeiling, want: []string{"3", "-2", "2", "-1", "1", "4"}},
+		{mode: RoundFloor, want: []string{"2", "-3", "1", "-2", "0", "3"}},
+		{mode: RoundHalfEven, scale: 1, want: []string{"2.5", "-2.5", "1.4", "-1.4", "0.0", "4.0"}},
+		{mode: RoundHalfUp, scale: 4, want: []string{"2.5000", "-2.5000", "1.4500", "-1.4500", "0.0010", "3.9990"}},
+	}
+	for _, tt := range tests {
+		for i, in := range inputs {
+			if got := mustDecimal(MustParseDecimal(in).Round(tt.scale, tt.mode)).String(); got != tt.want[i] {
+				t.Errorf("Round(%s, %d, mode %d) = %s, want %s", in, tt.scale, tt.mode, got, tt.want[i])
+			}
+		}
+	}
+}
+
+type invoice struct {
+	ID     string   `json:"id" protobuf:"1"`
+	Amount Decimal  `json:"amount" protobuf:"2" min:"0.01" max:"99999999999999999999.99" scale:"2"`
+	Rate   *Decimal `json:"rate,omitempty" protobuf:"3" default:"0.19"`
+}
+
+func TestDecimal_Codecs(t *testing.T) {
+	rate := MustParseDecimal("0.075")
+	in := invoice{ID: "a", Amount: MustParseDecimal("1234567890123456789.05"), Rate: &rate}
+	tests := []struct {
+		name  string
+		codec Codec
+		want  string
+	}{
+		{name: "JSON", codec: NewJSONCodec(nil), want: `{"id":"a","amount":"1234567890123456789.05","rate":"0.075"}`},
+		{name: "Form", codec: NewFormCodec(nil), want: "amount=1234567890123456789.05&id=a&rate=0.075"},
+		{name: "Protobuf", codec: NewProtobufCodec()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.codec.EncodeToBytes(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != "" && string(b) != tt.want {
+				t.Errorf("EncodeToBytes() = %s, want %s", b, tt.want)
+			}
+			var out invoice
+			if err = tt.codec.DecodeBytes(b, &out); err != nil {
+				t.Fatal(err)
+			}
+			if !Equal(in, out) {
+				t.Errorf("DecodeBytes() = %+v, want %+v", out, in)
+			}
+		})
+	}
+	b, err := MustParseDecimal("-12.345").MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d Decimal
+	if err = d.UnmarshalBinary(b); err != nil || d.String() != "-12.345" {
+		t.Errorf("UnmarshalBinary() = %s, %v", d, err)
+	}
+	if err = NewJSONCodec(nil).DecodeString(`{"amount":12.5}`, &d); err == nil {
+		t.Error("DecodeString() in to a Decimal must fail for an object")
+	}
+	if err = d.UnmarshalBinary([]byte{0xff, 0xff, 0x03, 0, 1}); err == nil {
+		t.Error("UnmarshalBinary() must fail for a scale out of range")
+	}
+	var out invoice
+	if err = NewJSONCodec(nil).DecodeString(`{"amount":"1e400000000"}`, &out); err == nil ||
+		!strings.Contains(err.Error(), "exponent out of range") {
+		t.Errorf("DecodeString() error = %v", err)
+	}
+	if err = NewJSONCodec(nil).DecodeString(`{"amount":12.5}`, &out); err != nil || out.Amount.String() != "12.5" ||
+		out.Rate.String() != "0.19" {
+		t.Errorf("DecodeString() = %+v, %v", out, err)
+	}
+}
+
+func TestDecimal_Validate(t *testing.T) {
+	tests := []struct {
+		amount  string
+		wantErr string
+	}{
+		{amount: "0.01"},
+		{amount: "10.50"},
+		{amount: "10.500"},
+		{amount: "99999999999999999999.99"},
+		{amount: "0.001", wantErr: "amount: must be greater than or equal to 0.01; amount: must have at most 2 fractional digits"},
+		{amount: "100000000000000000000", wantErr: "amount: must be less than or equal to 99999999999999999999.99"},
+	}
+	for _, tt := range tests {
+		err := Validate(invoice{Amount: MustParseDecimal(tt.amount)})
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("Validate(%s) error = %v, want %s", tt.amount, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//diffTag is the struct tag used to exclude a field from Diff using diff:"-"
+const diffTag = "diff"
+
+//ChangeType identifies the kind of a Change
+type ChangeType string
+
+const (
+	//Added is used when a value exists only in the new version
+	Added ChangeType = "added"
+	//Removed is used when a value exists only in the old version
+	Removed ChangeType = "removed"
+	//Modified is used when the value differs between the versions
+	Modified ChangeType = "modified"
+)
+
+//Change struct describes the difference of a single value between two versions.
+//Path uses the JSON names of the fields separated by "." and "[key]" for the elements of slices, arrays and maps.
+type Change struct {
+	Path string      `json:"path" yaml:"path"`
+	Type ChangeType  `json:"type" yaml:"type"`
+	Old  interface{} `json:"old,omitempty" yaml:"old,omitempty"`
+	New  interface{} `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+//Changes is the list of differences returned by Diff
+type Changes []Change
+
+//Render encodes the changes using the encoder provided, for example a JSONCodec
+func (c Changes) Render(e Encoder) ([]byte, error) {
+	return e.EncodeToBytes(c)
+}
+
+//Diff function compares a and b and returns the changes needed to turn a in to b. Structs are walked using the
+//cached FieldMeta and fields tagged with diff:"-" are skipped. Types with an Equal or a Cmp method, such as time.Time
+//and big.Int, are compared using it and structs having only unexported fields are compared using reflect.DeepEqual.
+//NaN is considered equal to NaN and a nil slice or map is considered equal to an empty one.
+//The changes are ordered by field declaration, element index and sorted map keys.
+//The old and new values of the fields tagged as sensitive are reported as DefaultMask, or omitted for
+//sensitive:"omit", and the values holding sensitive fields are passed through Redact. Cyclic values are supported.
+func Diff(a, b interface{}) Changes {
+	changes := diffValues(textutils.EmptyStr, reflect.ValueOf(a), reflect.ValueOf(b), nil, make(map[diffVisit]bool))
+	for i := range changes {
+		changes[i].Old = Redact(changes[i].Old)
+		changes[i].New = Redact(changes[i].New)
+	}
+	return changes
+}
+
+//diffVisit identifies a pair of pointers or maps already compared, which stops the walk of cyclic values
+type diffVisit struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+//Equal function checks if a and b are deeply equal as defined by Diff
+func Equal(a, b interface{}) bool {
+	return len(Diff(a, b)) == 0
+}
+
+func diffValues(path string, a, b reflect.Value, changes Changes, visited map[diffVisit]bool) Changes {
+	if !a.IsValid() || !b.IsValid() {
+		switch {
+		case a.IsValid() && !isNil(a):
+			return append(changes, Change{Path: path, Type: Removed, Old: valueOf(a)})
+		case b.IsValid() && !isNil(b):
+			return append(changes, Change{Path: path, Type: Added, New: valueOf(b)})
+		}
+		return changes
+	}
+	if a.Type() != b.Type() {
+		return append(changes, Change{Path: path, Type: Modified, Old: valueOf(a), New: valueOf(b)})
+	}
+	if eq, ok := callCompare(a, b); ok {
+		if !eq {
+			changes = append(changes, Change{Path: path, Type: Modified, Old: valueOf(a), New: valueOf(b)})
+		}
+		return changes
+	}
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil():
+			changes = append(changes, Change{Path: path, Type: Added, New: valueOf(b)})
+		case b.IsNil():
+			changes = append(changes, Change{Path: path, Type: Removed, Old: valueOf(a)})
+		default:
+			if a.Kind() == reflect.Ptr {
+				v := diffVisit{a: a.Pointer(), b: b.Pointer(), typ: a.Type()}
+				if visited[v] {
+					return changes
+				}
+				visited[v] = true
+			}
+			changes = diffValues(path, a.Elem(), b.Elem(), changes, visited)
+		}
+	case reflect.Struct:
+		fieldMetas := GetFieldMetas(a.Type())
+		if len(fieldMetas) == 0 && a.NumField() > 0 {
+			//the state is held in unexported fields which can only be compared as a whole
+			if a.CanInterface() && b.CanInterface() && !reflect.DeepEqual(a.Interface(), b.Interface()) {
+				changes = append(changes, Change{Path: path, Type: Modified, Old: valueOf(a), New: valueOf(b)})
+			}
+			return changes
+		}
+		for _, f := range fieldMetas {
+			if f.DiffIgnored {
+				continue
+			}
+			//a nil embedded pointer yields an invalid value which is handled as a missing field
+			fa, _ := fieldByIndex(a, f.Index)
+			fb, _ := fieldByIndex(b, f.Index)
+			name := joinPath(path, f.TargetName(JSONTarget))
+			if !f.Sensitive {
+				changes = diffValues(name, fa, fb, changes, visited)
+				continue
+			}
+			for _, c := range diffValues(name, fa, fb, nil, visited) {
+				c.Old, c.New = maskChange(c.Old, f.OmitSensitive), maskChange(c.New, f.OmitSensitive)
+				changes = append(changes, c)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if a.Type().Elem().Kind() == reflect.Uint8 && a.Kind() == reflect.Slice {
+			//byte slices are compared as a single value
+			if !bytes.Equal(a.Bytes(), b.Bytes()) {
+				changes = append(changes, Change{Path: path, Type: Modified, Old: valueOf(a), New: valueOf(b)})
+			}
+			return changes
+		}
+		for i := 0; i < a.Len() || i < b.Len(); i++ {
+			var ea, eb reflect.Value
+			if i < a.Len() {
+				ea = a.Index(i)
+			}
+			if i < b.Len() {
+				eb = b.Index(i)
+			}
+			changes = diffValues(path+"["+strconv.Itoa(i)+"]", ea, eb, changes, visited)
+		}
+	case reflect.Map:
+		if !a.IsNil() && !b.IsNil() {
+			v := diffVisit{a: a.Pointer(), b: b.Pointer(), typ: a.Type()}
+			if visited[v] {
+				return changes
+			}
+			visited[v] = true
+		}
+		for _, k := range sortedKeys(a, b) {
+			kp := path + "[" + fmt.Sprint(k.Interface()) + "]"
+			va, vb := a.MapIndex(k), b.MapIndex(k)
+			switch {
+			case !va.IsValid():
+				changes = append(changes, Change{Path: kp, Type: Added, New: valueOf(vb)})
+			case !vb.IsValid():
+				changes = append(changes, Change{Path: kp, Type: Removed, Old: valueOf(va)})
+			default:
+				changes = diffValues(kp, va, vb, changes, visited)
+			}
+		}
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if a.Pointer() != b.Pointer() {
+			changes = append(changes, Change{Path: path, Type: Modified})
+		}
+	case reflect.Float32, reflect.Float64:
+		fa, fb := a.Float(), b.Float()
+		if fa != fb && !(math.IsNaN(fa) && math.IsNaN(fb)) {
+			changes = append(changes, Change{Path: path, Type: Modified, Old: valueOf(a), New: valueOf(b)})
+		}
+	case reflect.Complex64, reflect.Complex128:
+		ca, cb := a.Complex(), b.Complex()
+		if ca != cb && !(cmplx.IsNaN(ca) && cmplx.IsNaN(cb)) {
+			changes = append(changes, Change{Path: path, Type: Modified, Old: valueOf(a), New: valueOf(b)})
+		}
+	default:
+		if valueOf(a) != valueOf(b) {
+			changes = append(changes, Change{Path: path, Type: Modified, Old: valueOf(a), New: valueOf(b)})
+		}
+	}
+	return changes
+}
+
+//callCompare compares a and b using the Equal or the Cmp method of their type, or of the pointer to it as for
+//big.Int. false is returned as the second value if neither func (T) Equal(T) bool nor func (T) Cmp(T) int exists
+func callCompare(a, b reflect.Value) (bool, bool) {
+	if !a.CanInterface() || !b.CanInterface() {
+		return false, false
+	}
+	if eq, ok := callMethod(a, b); ok {
+		return eq, true
+	}
+	if a.Kind() == reflect.Ptr || reflect.PtrTo(a.Type()).NumMethod() == a.Type().NumMethod() {
+		return false, false
+	}
+	//the methods with a pointer receiver are called on copies so that unaddressable values are supported
+	pa, pb := reflect.New(a.Type()), reflect.New(b.Type())
+	pa.Elem().Set(a)
+	pb.Elem().Set(b)
+	return callMethod(pa, pb)
+}
+
+//callMethod calls the Equal or the Cmp method of the type of a if it has the expected signature
+func callMethod(a, b reflect.Value) (bool, bool) {
+	if a.Kind() == reflect.Ptr && (a.IsNil() || b.IsNil()) {
+		return false, false
+	}
+	t := a.Type()
+	if m, ok := t.MethodByName("Equal"); ok && isCompareMethod(m.Type, t, reflect.Bool) {
+		return m.Func.Call([]reflect.Value{a, b})[0].Bool(), true
+	}
+	if m, ok := t.MethodByName("Cmp"); ok && isCompareMethod(m.Type, t, reflect.Int) {
+		return m.Func.Call([]reflect.Value{a, b})[0].Int() == 0, true
+	}
+	return false, false
+}
+
+//isCompareMethod checks if the method type mt has the signature func (t) (t) out
+func isCompareMethod(mt, t reflect.Type, out reflect.Kind) bool {
+	return mt.NumIn() == 2 && mt.In(1) == t && mt.NumOut() == 1 && mt.Out(0).Kind() == out
+}
+
+//sortedKeys returns the union of the keys of the maps a and b sorted by their string representation
+func sortedKeys(a, b reflect.Value) []reflect.Value {
+	keys := a.MapKeys()
+	for _, k := range b.MapKeys() {
+		if !a.MapIndex(k).IsValid() {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	return keys
+}
+
+//maskChange returns the value reported for a sensitive field, DefaultMask or nil if the field is omitted or v is nil
+func maskChange(v interface{}, omit bool) interface{} {
+	if v == nil || omit {
+		return nil
+	}
+	return DefaultMask
+}
+
+func isNil(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func valueOf(rv reflect.Value) interface{} {
+	if !rv.IsValid() || !rv.CanInterface() {
+		return nil
+	}
+	return rv.Interface()
+}
@@ -0,0 +1,212 @@
+package codec
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type diffAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type diffUser struct {
+	Name      string            `json:"name"`
+	Age       int               `json:"age"`
+	Address   *diffAddress      `json:"address"`
+	Tags      []string          `json:"tags"`
+	Meta      map[string]string `json:"meta"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+	Version   int               `json:"version" diff:"-"`
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	base := diffUser{
+		Name:      "alice",
+		Age:       30,
+		Address:   &diffAddress{City: "Pune", Zip: "411001"},
+		Tags:      []string{"a", "b"},
+		Meta:      map[string]string{"k": "v", "x": "y"},
+		UpdatedAt: now,
+		Version:   1,
+	}
+	tests := []struct {
+		name   string
+		modify func(u *diffUser)
+		want   Changes
+	}{
+		{
+			name:   "Equal",
+			modify: func(u *diffUser) {},
+		},
+		{
+			name: "Ignored",
+			modify: func(u *diffUser) {
+				u.Version = 2
+			},
+		},
+		{
+			name: "SameInstantOtherZone",
+			modify: func(u *diffUser) {
+				u.UpdatedAt = now.In(time.FixedZone("IST", 19800))
+			},
+		},
+		{
+			name: "Scalars",
+			modify: func(u *diffUser) {
+				u.Name = "bob"
+				u.Age = 31
+			},
+			want: Changes{
+				{Path: "name", Type: Modified, Old: "alice", New: "bob"},
+				{Path: "age", Type: Modified, Old: 30, New: 31},
+			},
+		},
+		{
+			name: "Nested",
+			modify: func(u *diffUser) {
+				u.Address = &diffAddress{City: "Mumbai", Zip: "411001"}
+			},
+			want: Changes{{Path: "address.city", Type: Modified, Old: "Pune", New: "Mumbai"}},
+		},
+		{
+			name: "NilPointer",
+			modify: func(u *diffUser) {
+				u.Address = nil
+			},
+			want: Changes{{Path: "address", Type: Removed, Old: &diffAddress{City: "Pune", Zip: "411001"}}},
+		},
+		{
+			name: "Collections",
+			modify: func(u *diffUser) {
+				u.Tags = []string{"a", "c", "d"}
+				u.Meta = map[string]string{"k": "v2", "z": "1"}
+			},
+			want: Changes{
+				{Path: "tags[1]", Type: Modified, Old: "b", New: "c"},
+				{Path: "tags[2]", Type: Added, New: "d"},
+				{Path: "meta[k]", Type: Modified, Old: "v", New: "v2"},
+				{Path: "meta[x]", Type: Removed, Old: "y"},
+				{Path: "meta[z]", Type: Added, New: "1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := base
+			modified.Address = &diffAddress{City: "Pune", Zip: "411001"}
+			tt.modify(&modified)
+			got := Diff(base, modified)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.want)
+			}
+			if Equal(base, modified) != (len(tt.want) == 0) {
+				t.Errorf("Equal() = %v, want %v", Equal(base, modified), len(tt.want) == 0)
+			}
+		})
+	}
+}
+
+func TestChanges_Render(t *testing.T) {
+	changes := Diff(diffAddress{City: "Pune"}, diffAddress{City: "Mumbai"})
+	got, err := changes.Render(NewJSONCodec(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"path":"city","type":"modified","old":"Pune","new":"Mumbai"}]`
+	if string(got) != want {
+		t.Errorf("Render() = %s, want %s", got, want)
+	}
+}
+
+type diffNode struct {
+	Name string    `json:"name"`
+	Next *diffNode `json:"next"`
+}
+
+type diffAccount struct {
+	User     string       `json:"user"`
+	Password string       `json:"password" sensitive:"true"`
+	Token    string       `json:"token" sensitive:"omit"`
+	Keys     []string     `json:"keys" sensitive:"true"`
+	Owner    *diffAccount `json:"owner"`
+}
+
+func TestDiff_Cycles(t *testing.T) {
+	a := &diffNode{Name: "a"}
+	a.Next = &diffNode{Name: "b", Next: a}
+	b := &diffNode{Name: "a"}
+	b.Next = &diffNode{Name: "c", Next: b}
+	want := Changes{{Path: "next.name", Type: Modified, Old: "b", New: "c"}}
+	if got := Diff(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	m := map[string]interface{}{"k": 1}
+	m["self"] = m
+	if !Equal(m, m) {
+		t.Error("Equal() = false for a cyclic map")
+	}
+}
+
+func TestDiff_Sensitive(t *testing.T) {
+	owner := &diffAccount{User: "root", Password: "p0"}
+	a := diffAccount{User: "alice", Password: "p1", Token: "t1", Keys: []string{"k1"}}
+	b := diffAccount{User: "alice", Password: "p2", Token: "t2", Keys: []string{"k1", "k2"}, Owner: owner}
+	want := Changes{
+		{Path: "password", Type: Modified, Old: DefaultMask, New: DefaultMask},
+		{Path: "token", Type: Modified},
+		{Path: "keys[1]", Type: Added, New: DefaultMask},
+		{Path: "owner", Type: Added, New: &diffAccount{User: "root", Password: DefaultMask}},
+	}
+	if got := Diff(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if owner.Password != "p0" {
+		t.Errorf("Diff() modified the value, password = %s", owner.Password)
+	}
+}
+
+type diffQuantity struct {
+	Amount *big.Int       `json:"amount"`
+	Total  big.Int        `json:"total"`
+	Zone   *time.Location `json:"zone"`
+	Ratio  float64        `json:"ratio"`
+}
+
+func TestDiff_Opaque(t *testing.T) {
+	ist := time.FixedZone("IST", 19800)
+	base := diffQuantity{Amount: big.NewInt(10), Total: *big.NewInt(20), Zone: ist, Ratio: math.NaN()}
+	tests := []struct {
+		name  string
+		other diffQuantity
+		want  []string
+	}{
+		{
+			name:  "Equal",
+			other: diffQuantity{Amount: big.NewInt(10), Total: *big.NewInt(20), Zone: ist, Ratio: math.NaN()},
+		},
+		{
+			name:  "Modified",
+			other: diffQuantity{Amount: big.NewInt(11), Total: *big.NewInt(21), Zone: time.UTC, Ratio: 1},
+			want:  []string{"amount", "total", "zone", "ratio"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range Diff(base, tt.other) {
+				if c.Type != Modified {
+					t.Errorf("Diff() change %s has type %s", c.Path, c.Type)
+				}
+				got = append(got, c.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
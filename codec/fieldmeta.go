@@ -0,0 +1,153 @@
+package codec
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//knownTypes holds the FieldMeta resolved for a struct type so that the reflection walk happens only once per type
+var knownTypes sync.Map
+
+//targetTags are the struct tags that are inspected to resolve the name of a field for a specific encoding
+var targetTags = []string{"json", "yaml"}
+
+//GetFieldMetas function returns the FieldMeta of all the encodable fields of the struct type t.
+//Fields of anonymous struct members without a name in their tag are promoted to the parent as done by encoding/json.
+//The result is cached and must not be modified by the caller. A nil slice is returned if t is not a struct.
+func GetFieldMetas(t reflect.Type) []*FieldMeta {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if cached, ok := knownTypes.Load(t); ok {
+		return cached.([]*FieldMeta)
+	}
+	fields := resolveFieldMetas(t, nil)
+	cached, _ := knownTypes.LoadOrStore(t, fields)
+	return cached.([]*FieldMeta)
+}
+
+//LookupField function returns the FieldMeta of the struct type t that is identified by name for the target encoding.
+//An exact match is preferred and a case insensitive match is used as fallback, similar to encoding/json.
+func LookupField(t reflect.Type, target, name string) *FieldMeta {
+	var fallback *FieldMeta
+	for _, f := range GetFieldMetas(t) {
+		n := f.TargetName(target)
+		if n == name {
+			return f
+		}
+		if fallback == nil && strings.EqualFold(n, name) {
+			fallback = f
+		}
+	}
+	return fallback
+}
+
+//StringMeta returns the StringFieldMeta of the field. The result is shared and must not be modified by the caller.
+func (f *FieldMeta) StringMeta() *StringFieldMeta {
+	if f.stringMeta == nil {
+		return &StringFieldMeta{FieldMeta: *f}
+	}
+	return f.stringMeta
+}
+
+//newStringFieldMeta creates the StringFieldMeta of the field fm from its tags
+func newStringFieldMeta(fm *FieldMeta, tag reflect.StructTag) *StringFieldMeta {
+	sm := &StringFieldMeta{
+		FieldMeta:  *fm,
+		DefaultVal: fm.Constraints.DefaultVal,
+		Pattern:    tag.Get("pattern"),
+		Format:     tag.Get("format"),
+		OmitEmpty:  fm.OmitEmpty,
+	}
+	if fm.Type.Kind() == reflect.String && fm.Constraints.Max != nil {
+		sm.Length = int(*fm.Constraints.Max)
+	}
+	return sm
+}
+
+//TargetName returns the name of the field for the target encoding. If no name is specified for the target then the
+//default name of the field is returned
+func (f *FieldMeta) TargetName(target string) string {
+	if n, ok := f.TargetNames[target]; ok {
+		return n
+	}
+	return f.Name
+}
+
+//resolveFieldMetas walks the fields of the struct type t and creates the FieldMeta for each of them.
+func resolveFieldMetas(t reflect.Type, parentIndex []int) []*FieldMeta {
+	var fields []*FieldMeta
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != textutils.EmptyStr && !sf.Anonymous {
+			//unexported field
+			continue
+		}
+		index := make([]int, len(parentIndex)+1)
+		copy(index, parentIndex)
+		index[len(parentIndex)] = i
+
+		jsonName, jsonOpts := parseTag(sf.Tag.Get("json"))
+		if jsonName == textutils.HyphenStr && jsonOpts == textutils.EmptyStr {
+			continue
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && jsonName == textutils.EmptyStr && ft.Kind() == reflect.Struct {
+			fields = append(fields, resolveFieldMetas(ft, index)...)
+			continue
+		}
+		if sf.PkgPath != textutils.EmptyStr {
+			continue
+		}
+		fm := &FieldMeta{
+			Name:        sf.Name,
+			FieldName:   sf.Name,
+			Type:        sf.Type,
+			Index:       index,
+			TargetNames: make(map[string]string),
+		}
+		fm.Required, fm.Constraints = parseConstraints(sf.Tag, sf.Type)
+		fm.OmitEmpty = hasTagOption(jsonOpts, "omitempty")
+		fm.DiffIgnored = sf.Tag.Get(diffTag) == textutils.HyphenStr
+		fm.Sensitive, fm.OmitSensitive = parseSensitive(sf.Tag)
+		fm.stringMeta = newStringFieldMeta(fm, sf.Tag)
+		for _, tag := range targetTags {
+			tv := sf.Tag.Get(tag)
+			if tv == textutils.HyphenStr {
+				continue
+			}
+			if n, _ := parseTag(tv); n != textutils.EmptyStr {
+				fm.TargetNames[tag] = n
+			}
+		}
+		fields = append(fields, fm)
+	}
+	return fields
+}
+
+//hasTagOption checks if the comma separated options contain opt
+func hasTagOption(options, opt string) bool {
+	for _, o := range strings.Split(options, textutils.CommaStr) {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+//parseTag splits a struct tag value into the name and the remaining comma separated options
+func parseTag(tag string) (string, string) {
+	if idx := strings.Index(tag, textutils.CommaStr); idx != -1 {
+		return tag[:idx], tag[idx+1:]
+	}
+	return tag, textutils.EmptyStr
+}
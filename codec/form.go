@@ -0,0 +1,395 @@
+package codec
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//MimeForm is the media type of the FormCodec
+const MimeForm = "application/x-www-form-urlencoded"
+
+//maxFormElements is the number of slice elements that the indexes in the keys of a form may allocate in total, which
+//limits the memory allocated for a request whatever the number of keys
+const maxFormElements = 10000
+
+//FormCodec struct encodes and decodes structs as URL encoded forms and query strings. The keys are the JSON names of
+//the fields. Nested values use the following keys
+//	address.city or address[city]   field of a nested struct
+//	filter[status]                  entry of a map
+//	items[0].name or items[0][name] field of an element of a slice of structs
+//	tags=a&tags=b or tags[]=a       elements of a slice of scalars
+//The default values and the constraints are handled as done by the JSONCodec.
+type FormCodec struct {
+	decoderOptions *DecoderOptions
+	encoderOptions *EncoderOptions
+}
+
+//NewFormCodec function creates a FormCodec with the DecoderOptions specified. Only DisallowUnknownFields applies to
+//forms, as all the values are strings that are converted to the field types.
+func NewFormCodec(options *DecoderOptions) *FormCodec {
+	if options == nil {
+		options = &DecoderOptions{}
+	}
+	return &FormCodec{
+		decoderOptions: options,
+		encoderOptions: &EncoderOptions{},
+	}
+}
+
+//WithEncoderOptions function returns a copy of the codec that uses the EncoderOptions specified. Only Redacted applies
+//to forms.
+func (c *FormCodec) WithEncoderOptions(options *EncoderOptions) *FormCodec {
+	if options == nil {
+		options = &EncoderOptions{}
+	}
+	return &FormCodec{
+		decoderOptions: c.decoderOptions,
+		encoderOptions: options,
+	}
+}
+
+//DecodeQuery function decodes the query string of the request r in to v using a FormCodec with the default options
+func DecodeQuery(r *http.Request, v interface{}) error {
+	return NewFormCodec(nil).DecodeString(r.URL.RawQuery, v)
+}
+
+//EncodeToString function encodes the struct v as a URL encoded form with the keys sorted
+func (c *FormCodec) EncodeToString(v interface{}) (string, error) {
+	values, err := c.EncodeValues(v)
+	if err != nil {
+		return textutils.EmptyStr, err
+	}
+	return values.Encode(), nil
+}
+
+//EncodeToBytes function encodes the struct v as a URL encoded form with the keys sorted
+func (c *FormCodec) EncodeToBytes(v interface{}) ([]byte, error) {
+	s, err := c.EncodeToString(v)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+//EncodeValues function returns the form values of the struct v. Fields with the omitempty JSON option are skipped if
+//they are empty.
+func (c *FormCodec) EncodeValues(v interface{}) (url.Values, error) {
+	rv := reflect.ValueOf(v)
+	if c.encoderOptions.Redacted && rv.IsValid() && HasSensitiveFields(rv.Type()) {
+		rv = redactValue(rv, c.encoderOptions.mask(), make(map[pointerKey]reflect.Value))
+	}
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return url.Values{}, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("form: cannot encode %T, a struct is required", v)
+	}
+	values := url.Values{}
+	return values, encodeFormStruct(values, textutils.EmptyStr, rv)
+}
+
+//Write function encodes the struct v as a URL encoded form and writes it to w
+func (c *FormCodec) Write(v interface{}, w io.Writer) error {
+	s, err := c.EncodeToString(v)
+	if err == nil {
+		_, err = io.WriteString(w, s)
+	}
+	return err
+}
+
+//DecodeString function decodes the URL encoded form s in to v
+func (c *FormCodec) DecodeString(s string, v interface{}) error {
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return err
+	}
+	return c.DecodeValues(values, v)
+}
+
+//DecodeBytes function decodes the URL encoded form b in to v
+func (c *FormCodec) DecodeBytes(b []byte, v interface{}) error {
+	return c.DecodeString(string(b), v)
+}
+
+//Read function reads the URL encoded form from r and decodes it in to v.
+//This function does not close the reader and it is the responsibility of the caller to close the reader
+func (c *FormCodec) Read(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err == nil {
+		err = c.DecodeBytes(b, v)
+	}
+	return err
+}
+
+//DecodeValues function decodes the form values in to the struct pointed by v. The default values declared on the
+//fields are applied before decoding and the constraints are validated after decoding.
+func (c *FormCodec) DecodeValues(values url.Values, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("form: cannot decode in to %T, a non nil pointer to a struct is required", v)
+	}
+	if err := ApplyDefaults(v); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	//sorted so that the errors reported are deterministic
+	sort.Strings(keys)
+	budget := maxFormElements
+	for _, key := range keys {
+		path, err := parseFormKey(key)
+		if err != nil {
+			return err
+		}
+		if err = c.setFormValue(rv.Elem(), key, path, values[key], textutils.EmptyStr, &budget); err != nil {
+			return err
+		}
+	}
+	return Validate(v)
+}
+
+//parseFormKey splits the key in to its path segments. e.g. items[0].name is split in to items, 0 and name
+func parseFormKey(key string) ([]string, error) {
+	var path []string
+	for i := 0; i < len(key); {
+		switch key[i] {
+		case '[':
+			end := strings.IndexByte(key[i:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("form: missing ] in key %q", key)
+			}
+			path = append(path, key[i+1:i+end])
+			i += end + 1
+		case '.':
+			i++
+		default:
+			end := strings.IndexAny(key[i:], ".[")
+			if end == -1 {
+				end = len(key) - i
+			}
+			path = append(path, key[i:i+end])
+			i += end
+		}
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("form: invalid key %q", key)
+	}
+	return path, nil
+}
+
+//setFormValue walks rv along the path allocating the values needed and sets the values at the end of the path.
+//format is the format declared on the last struct field walked. budget is the number of slice elements that can still
+//be allocated for the indexes of the keys.
+func (c *FormCodec) setFormValue(rv reflect.Value, key string, path []string, values []string, format string,
+	budget *int) error {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	if len(path) == 0 {
+		return setFormScalar(rv, key, values, format)
+	}
+	seg := path[0]
+	switch rv.Kind() {
+	case reflect.Struct:
+		f := LookupField(rv.Type(), JSONTarget, seg)
+		if f == nil {
+			if c.decoderOptions.DisallowUnknownFields {
+				return fmt.Errorf("form: unknown field %q in key %q", seg, key)
+			}
+			return nil
+		}
+		return c.setFormValue(fieldByIndexAlloc(rv, f.Index), key, path[1:], values, f.StringMeta().Format, budget)
+	case reflect.Map:
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+		k := reflect.New(rv.Type().Key()).Elem()
+		if err := SetFromString(k, seg); err != nil {
+			return fmt.Errorf("form: invalid map key in %q: %v", key, err)
+		}
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		if existing := rv.MapIndex(k); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := c.setFormValue(elem, key, path[1:], values, format, budget); err != nil {
+			return err
+		}
+		rv.SetMapIndex(k, elem)
+		return nil
+	case reflect.Slice, reflect.Array:
+		if seg == textutils.EmptyStr && len(path) == 1 {
+			//items[]=a&items[]=b
+			return setFormScalar(rv, key, values, format)
+		}
+		idx, err := strconv.Atoi(seg)
+		if err != nil || idx < 0 {
+			return fmt.Errorf("form: invalid index %q in key %q", seg, key)
+		}
+		if rv.Kind() == reflect.Array {
+			if idx >= rv.Len() {
+				return fmt.Errorf("form: index %d out of range in key %q", idx, key)
+			}
+		} else if idx >= rv.Len() {
+			if idx-rv.Len() >= *budget {
+				return fmt.Errorf("form: index %d in key %q exceeds the limit of %d elements", idx, key, maxFormElements)
+			}
+			*budget -= idx + 1 - rv.Len()
+			grown := reflect.MakeSlice(rv.Type(), idx+1, idx+1)
+			reflect.Copy(grown, rv)
+			rv.Set(grown)
+		}
+		return c.setFormValue(rv.Index(idx), key, path[1:], values, format, budget)
+	}
+	return fmt.Errorf("form: key %q does not match the type %s", key, rv.Type())
+}
+
+//setFormScalar sets the values on rv. Slices of scalars get all the values and other types get the first value.
+//Times and durations are parsed using the format
+func setFormScalar(rv reflect.Value, key string, values []string, format string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if isTextUnmarshaler(rv) || isTimeType(rv.Type()) {
+		if err := setFormatted(rv, values[0], format); err != nil {
+			return fmt.Errorf("form: invalid value for %q: %v", key, err)
+		}
+		return nil
+	}
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		for _, s := range values {
+			e := reflect.New(rv.Type().Elem()).Elem()
+			if err := setFormatted(e, s, format); err != nil {
+				return fmt.Errorf("form: invalid value for %q: %v", key, err)
+			}
+			rv.Set(reflect.Append(rv, e))
+		}
+		return nil
+	}
+	if rv.Kind() == reflect.Slice {
+		rv.SetBytes([]byte(values[0]))
+		return nil
+	}
+	if err := setFormatted(rv, values[0], format); err != nil {
+		return fmt.Errorf("form: invalid value for %q: %v", key, err)
+	}
+	return nil
+}
+
+func isTextUnmarshaler(rv reflect.Value) bool {
+	return rv.CanAddr() && rv.Addr().Type().Implements(textUnmarshalerType)
+}
+
+//fieldByIndexAlloc returns the nested field of the struct rv allocating the nil embedded pointers on the way
+func fieldByIndexAlloc(rv reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv
+}
+
+func encodeFormStruct(values url.Values, prefix string, rv reflect.Value) error {
+	for _, f := range GetFieldMetas(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.Index)
+		if !ok || f.OmitEmpty && IsEmptyJSONValue(fv.Interface()) {
+			continue
+		}
+		key := joinPath(prefix, f.TargetName(JSONTarget))
+		if err := encodeFormValue(values, key, fv, f.StringMeta().Format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//encodeFormValue adds the values of rv with the key. Times and durations are formatted using the format
+func encodeFormValue(values url.Values, key string, rv reflect.Value, format string) error {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if format != textutils.EmptyStr && isTimeType(rv.Type()) {
+		s, err := formatTimeValue(rv, format)
+		if err != nil {
+			return err
+		}
+		values.Add(key, s)
+		return nil
+	}
+	if tm, ok := rv.Interface().(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		if err != nil {
+			return err
+		}
+		values.Add(key, string(b))
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		return encodeFormStruct(values, key, rv)
+	case reflect.Map:
+		for _, k := range sortedKeys(rv, rv) {
+			err := encodeFormValue(values, key+"["+fmt.Sprint(k.Interface())+"]", rv.MapIndex(k), format)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			values.Add(key, string(rv.Bytes()))
+			return nil
+		}
+		for i := 0; i < rv.Len(); i++ {
+			e := rv.Index(i)
+			var err error
+			if isContainer(e.Type()) {
+				err = encodeFormValue(values, key+"["+strconv.Itoa(i)+"]", e, format)
+			} else {
+				err = encodeFormValue(values, key, e, format)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.String:
+		values.Add(key, rv.String())
+	case reflect.Bool:
+		values.Add(key, strconv.FormatBool(rv.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		values.Add(key, strconv.FormatInt(rv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		values.Add(key, strconv.FormatUint(rv.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		values.Add(key, strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()))
+	default:
+		return fmt.Errorf("form: cannot encode %s of type %s", key, rv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package codec
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type formItem struct {
+	Name string `json:"name" required:"true"`
+	Qty  int    `json:"qty" default:"1"`
+}
+
+type formSearch struct {
+	Query  string            `json:"q"`
+	Page   int               `json:"page" default:"1" min:"1"`
+	Filter map[string]string `json:"filter,omitempty"`
+	Tags   []string          `json:"tags,omitempty"`
+	Items  []formItem        `json:"items,omitempty"`
+	Owner  *formItem         `json:"owner,omitempty"`
+	Since  time.Time         `json:"since"`
+	Token  string            `json:"token,omitempty" sensitive:"true"`
+}
+
+func TestFormCodec_DecodeLimit(t *testing.T) {
+	type groups struct {
+		Groups map[string][]string `json:"groups"`
+	}
+	var got groups
+	if err := NewFormCodec(nil).DecodeString("groups[a][9]=x&groups[a][10]=y&groups[b][0]=z", &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Groups["a"]) != 11 || got.Groups["a"][10] != "y" || got.Groups["b"][0] != "z" {
+		t.Errorf("DecodeString() = %+v", got)
+	}
+	//every key stays below the limit but the total exceeds it
+	err := NewFormCodec(nil).DecodeString("groups[a][6000]=x&groups[b][6000]=y", &got)
+	if err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Errorf("DecodeString() error = %v", err)
+	}
+}
+
+func TestFormCodec_Decode(t *testing.T) {
+	since := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		input   string
+		options *DecoderOptions
+		want    formSearch
+		wantErr string
+	}{
+		{
+			name:  "Nested",
+			input: "q=go&filter[status]=open&filter[owner]=me&items[1].name=b&items[0][name]=a&items[0].qty=3",
+			want: formSearch{Query: "go", Page: 1, Filter: map[string]string{"status": "open", "owner": "me"},
+				Items: []formItem{{Name: "a", Qty: 3}, {Name: "b", Qty: 0}}},
+		},
+		{
+			name:  "Repeated",
+			input: "tags=a&tags=b&tags[]=c&page=2&owner.name=x&since=2021-06-01T00:00:00Z",
+			want: formSearch{Page: 2, Tags: []string{"a", "b", "c"}, Owner: &formItem{Name: "x"},
+				Since: since},
+		},
+		{name: "Unknown", input: "q=a&sort=asc", want: formSearch{Query: "a", Page: 1}},
+		{name: "StrictUnknown", input: "q=a&sort=asc", options: StrictDecoding(),
+			wantErr: `form: unknown field "sort" in key "sort"`},
+		{name: "InvalidNumber", input: "page=x", wantErr: `form: invalid value for "page"`},
+		{name: "Validation", input: "page=0&items[0].qty=2", wantErr: "items[0].name: is required"},
+		{name: "BadIndex", input: "items[-1].name=a", wantErr: `form: invalid index "-1"`},
+		{name: "IndexLimit", input: "items[10000].name=a", wantErr: "exceeds the limit of 10000 elements"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got formSearch
+			err := NewFormCodec(tt.options).DecodeString(tt.input, &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DecodeString() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeString()\n got = %+v\nwant = %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormCodec_Encode(t *testing.T) {
+	in := formSearch{
+		Query:  "a b",
+		Page:   2,
+		Filter: map[string]string{"status": "open"},
+		Tags:   []string{"x", "y"},
+		Items:  []formItem{{Name: "i", Qty: 1}},
+		Since:  time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		Token:  "secret",
+	}
+	got, err := NewFormCodec(nil).WithEncoderOptions(&EncoderOptions{Redacted: true}).EncodeToString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "filter%5Bstatus%5D=open&items%5B0%5D.name=i&items%5B0%5D.qty=1&page=2&q=a+b" +
+		"&since=2021-06-01T00%3A00%3A00Z&tags=x&tags=y&token=%2A%2A%2A%2A%2A%2A"
+	if got != want {
+		t.Errorf("EncodeToString()\n got = %s\nwant = %s", got, want)
+	}
+	var out formSearch
+	if err = NewFormCodec(nil).DecodeString(got, &out); err != nil {
+		t.Fatal(err)
+	}
+	in.Token = DefaultMask
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("DecodeString()\n got = %+v\nwant = %+v", out, in)
+	}
+}
+
+func TestDecodeQuery(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/search?q=go&filter[status]=open", nil)
+	var got formSearch
+	if err := DecodeQuery(r, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Query != "go" || got.Filter["status"] != "open" || got.Page != 1 {
+		t.Errorf("DecodeQuery() = %+v", got)
+	}
+}
@@ -0,0 +1,157 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+//This file contains the interfaces implemented by the code generated by cmd/codecgen and the helper functions used
+//by the generated code. The helpers produce the same output as encoding/json so that generated and reflective
+//encoding are interchangeable.
+
+//JSONAppender interface is implemented by types with a generated JSON encoder. The JSONCodec uses it instead of
+//reflection.
+type JSONAppender interface {
+	//AppendJSON appends the JSON encoding of the value to buf
+	AppendJSON(buf []byte) ([]byte, error)
+}
+
+//JSONReaderDecoder interface is implemented by types with a generated JSON decoder. The JSONCodec uses it instead of
+//reflection.
+type JSONReaderDecoder interface {
+	//DecodeJSON decodes the next value of r in to the receiver
+	DecodeJSON(r *JSONReader, opts *DecoderOptions) error
+}
+
+//AppendJSONString function appends the JSON string for s to buf escaping the characters as done by encoding/json
+func AppendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				buf = append(buf, '\\', b)
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if c == '\u2028' || c == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
+//AppendJSONFloat function appends the JSON number for f to buf formatted as done by encoding/json.
+//bits must be 32 for float32 values and 64 for float64 values
+func AppendJSONFloat(buf []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return buf, fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, bits)
+	if format == 'e' {
+		//clean up e-09 to e-9
+		n := len(buf)
+		if n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf, nil
+}
+
+//AppendJSONValue function appends the JSON encoding of v to buf. If v implements JSONAppender it is used, otherwise
+//encoding/json is used.
+func AppendJSONValue(buf []byte, v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case JSONAppender:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return append(buf, "null"...), nil
+		}
+		return t.AppendJSON(buf)
+	case time.Time:
+		b, err := t.MarshalJSON()
+		if err != nil {
+			return buf, err
+		}
+		return append(buf, b...), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return buf, err
+	}
+	return append(buf, b...), nil
+}
+
+//IsEmptyJSONValue function checks if v is empty as defined by the omitempty option of encoding/json
+func IsEmptyJSONValue(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	case reflect.Invalid:
+		return true
+	}
+	return false
+}
+
+//IsZero function checks if v has the zero value of its type
+func IsZero(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return !rv.IsValid() || rv.IsZero()
+}
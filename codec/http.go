@@ -0,0 +1,204 @@
+package codec
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//DefaultMaxBodySize is the size limit in bytes of the request body applied by DecodeRequest
+const DefaultMaxBodySize int64 = 1 << 20
+
+//HTTPError struct is the structured error returned by DecodeRequest and written by WriteResponse and WriteError
+type HTTPError struct {
+	Status  int      `json:"status" protobuf:"1"`
+	Message string   `json:"message" protobuf:"2"`
+	Details []string `json:"details,omitempty" protobuf:"3"`
+}
+
+func (e *HTTPError) Error() string {
+	if len(e.Details) == 0 {
+		return e.Message
+	}
+	return e.Message + textutils.ColonStr + textutils.WhiteSpaceStr + strings.Join(e.Details, "; ")
+}
+
+//NewHTTPError function creates an HTTPError with the status and the message specified
+func NewHTTPError(status int, message string, details ...string) *HTTPError {
+	return &HTTPError{Status: status, Message: message, Details: details}
+}
+
+//DecodeRequest function decodes the body of r in to v using the codec registered for the Content-Type of the request.
+//A request without a Content-Type is decoded as JSON. The body is limited to DefaultMaxBodySize bytes.
+//The errors returned are HTTPError with status 415 for an unsupported media type, 413 for a body exceeding the limit
+//and 400 for malformed or invalid content. The validation errors are listed in the details.
+func DecodeRequest(r *http.Request, v interface{}) error {
+	return DecodeRequestWithLimit(r, v, DefaultMaxBodySize)
+}
+
+//DecodeRequestWithLimit function decodes the body of r in to v as DecodeRequest does limiting the body to maxBytes
+func DecodeRequestWithLimit(r *http.Request, v interface{}, maxBytes int64) error {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == textutils.EmptyStr {
+		contentType = MimeJSON
+	}
+	c, ok := GetCodec(contentType)
+	if !ok {
+		return NewHTTPError(http.StatusUnsupportedMediaType, "unsupported media type "+strconv.Quote(contentType))
+	}
+	if r.Body == nil {
+		return NewHTTPError(http.StatusBadRequest, "request body is empty")
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+	if err != nil {
+		return NewHTTPError(http.StatusBadRequest, "failed to read the request body", err.Error())
+	}
+	if int64(len(body)) > maxBytes {
+		return NewHTTPError(http.StatusRequestEntityTooLarge,
+			"request body exceeds the limit of "+strconv.FormatInt(maxBytes, 10)+" bytes")
+	}
+	if err = c.DecodeBytes(body, v); err != nil {
+		return decodeError(err)
+	}
+	//the codec may not validate the decoded value
+	if err = Validate(v); err != nil {
+		return decodeError(err)
+	}
+	return nil
+}
+
+//decodeError converts the error returned by a decoder to a 400 HTTPError listing the validation errors in the details
+func decodeError(err error) *HTTPError {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	var ve ValidationErrors
+	if errors.As(err, &ve) {
+		details := make([]string, len(ve))
+		for i, e := range ve {
+			details[i] = e.Error()
+		}
+		return NewHTTPError(http.StatusBadRequest, "invalid request", details...)
+	}
+	return NewHTTPError(http.StatusBadRequest, "malformed request body", err.Error())
+}
+
+//WriteResponse function encodes v using the codec that best matches the Accept header of r and writes it with the
+//status specified. A request without an Accept header gets JSON. If no registered codec is acceptable a 406 HTTPError
+//listing the supported media types is written as JSON and returned.
+func WriteResponse(w http.ResponseWriter, r *http.Request, status int, v interface{}) error {
+	mediaType, c := negotiate(r.Header.Get("Accept"))
+	if c == nil {
+		httpErr := NewHTTPError(http.StatusNotAcceptable, "none of the accepted media types are supported",
+			MediaTypes()...)
+		c, _ = GetCodec(MimeJSON)
+		_ = write(w, MimeJSON, c, httpErr.Status, httpErr)
+		return httpErr
+	}
+	return write(w, mediaType, c, status, v)
+}
+
+//WriteError function writes err using WriteResponse. An HTTPError is written with its status and any other error is
+//written as a 500 HTTPError.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) error {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		httpErr = NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	return WriteResponse(w, r, httpErr.Status, httpErr)
+}
+
+func write(w http.ResponseWriter, mediaType string, c Codec, status int, v interface{}) error {
+	b, err := c.EncodeToBytes(v)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	w.WriteHeader(status)
+	_, err = w.Write(b)
+	return err
+}
+
+//mediaRange is a media range of the Accept header with its quality
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+//negotiate returns the registered media type and codec that best match the Accept header. The quality of a media type
+//is the one of the most specific range matching it, so q=0 excludes a media type or a whole range unless a more
+//specific range accepts it. The media type with the highest quality is returned, preferring the one matched by the
+//more specific range and then the one registered first. A nil codec is returned if none is acceptable.
+func negotiate(accept string) (string, Codec) {
+	if strings.TrimSpace(accept) == textutils.EmptyStr {
+		c, _ := GetCodec(MimeJSON)
+		return MimeJSON, c
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, textutils.CommaStr) {
+		params := strings.Split(part, ";")
+		mr := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if q, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					mr.q = q
+				}
+			}
+		}
+		if mr.mediaType != textutils.EmptyStr {
+			ranges = append(ranges, mr)
+		}
+	}
+	//the ranges are ordered by specificity so that the first range matching a media type is the one that applies
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return specificity(ranges[i].mediaType) > specificity(ranges[j].mediaType)
+	})
+	best, bestQ, bestSpecificity := textutils.EmptyStr, 0.0, -1
+	for _, mt := range MediaTypes() {
+		for _, mr := range ranges {
+			if !matchMediaRange(mr.mediaType, mt) {
+				continue
+			}
+			s := specificity(mr.mediaType)
+			if mr.q > bestQ || (mr.q == bestQ && mr.q > 0 && s > bestSpecificity) {
+				best, bestQ, bestSpecificity = mt, mr.q, s
+			}
+			break
+		}
+	}
+	if best == textutils.EmptyStr {
+		return best, nil
+	}
+	c, _ := GetCodec(best)
+	return best, c
+}
+
+func specificity(mediaRange string) int {
+	switch {
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*"):
+		return 1
+	}
+	return 2
+}
+
+func matchMediaRange(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(mediaType, mediaRange[:len(mediaRange)-1])
+	}
+	return false
+}
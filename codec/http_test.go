@@ -0,0 +1,154 @@
+package codec
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type httpCreateServer struct {
+	Name string `json:"name" required:"true" protobuf:"1"`
+	Port int    `json:"port" min:"1" protobuf:"2"`
+}
+
+func TestDecodeRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		limit       int64
+		wantStatus  int
+		want        httpCreateServer
+	}{
+		{name: "JSON", contentType: "application/json; charset=utf-8", body: `{"name":"a","port":80}`,
+			want: httpCreateServer{Name: "a", Port: 80}},
+		{name: "NoContentType", body: `{"name":"a","port":80}`, want: httpCreateServer{Name: "a", Port: 80}},
+		{name: "Protobuf", contentType: MimeProtobuf, body: "\x0a\x01a\x10\x50",
+			want: httpCreateServer{Name: "a", Port: 80}},
+		{name: "Unsupported", contentType: "text/csv", body: "a,80", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "Malformed", contentType: MimeJSON, body: `{"name":`, wantStatus: http.StatusBadRequest},
+		{name: "Invalid", contentType: MimeJSON, body: `{"port":0}`, wantStatus: http.StatusBadRequest},
+		{name: "TooLarge", contentType: MimeJSON, body: `{"name":"abcdef"}`, limit: 8,
+			wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/servers", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			var got httpCreateServer
+			var err error
+			if tt.limit > 0 {
+				err = DecodeRequestWithLimit(r, &got, tt.limit)
+			} else {
+				err = DecodeRequest(r, &got)
+			}
+			if tt.wantStatus != 0 {
+				httpErr, ok := err.(*HTTPError)
+				if !ok || httpErr.Status != tt.wantStatus {
+					t.Fatalf("DecodeRequest() error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRequest_ValidationDetails(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/servers", strings.NewReader(`{"port":0}`))
+	err := DecodeRequest(r, &httpCreateServer{})
+	httpErr, ok := err.(*HTTPError)
+	if !ok {
+		t.Fatalf("DecodeRequest() error = %v, want HTTPError", err)
+	}
+	want := []string{"name: is required", "port: must be greater than or equal to 1"}
+	if strings.Join(httpErr.Details, "|") != strings.Join(want, "|") {
+		t.Errorf("DecodeRequest() details = %q, want %q", httpErr.Details, want)
+	}
+}
+
+//httpPlainCodec is a codec that decodes without validating the value
+type httpPlainCodec struct {
+	Codec
+}
+
+func (c httpPlainCodec) DecodeBytes(b []byte, v interface{}) error {
+	return json.Unmarshal(b, v)
+}
+
+func TestDecodeRequest_NonValidatingCodec(t *testing.T) {
+	RegisterCodec("application/x-plain-json", httpPlainCodec{NewJSONCodec(nil)})
+	r := httptest.NewRequest(http.MethodPost, "/servers", strings.NewReader(`{"port":0}`))
+	r.Header.Set("Content-Type", "application/x-plain-json")
+	err := DecodeRequest(r, &httpCreateServer{})
+	if httpErr, ok := err.(*HTTPError); !ok || httpErr.Status != http.StatusBadRequest || len(httpErr.Details) != 2 {
+		t.Errorf("DecodeRequest() error = %v, want 400 with the validation details", err)
+	}
+}
+
+func TestWriteResponse(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{name: "NoAccept", wantStatus: http.StatusCreated, wantContentType: MimeJSON,
+			wantBody: `{"name":"a","port":80}`},
+		{name: "Wildcard", accept: "*/*", wantStatus: http.StatusCreated, wantContentType: MimeJSON,
+			wantBody: `{"name":"a","port":80}`},
+		{name: "Quality", accept: "application/json;q=0.5, application/x-protobuf", wantStatus: http.StatusCreated,
+			wantContentType: MimeProtobuf, wantBody: "\x0a\x01a\x10\x50"},
+		{name: "Excluded", accept: "application/json;q=0, application/*;q=0.1", wantStatus: http.StatusCreated,
+			wantContentType: MimeProtobuf, wantBody: "\x0a\x01a\x10\x50"},
+		{name: "NotAcceptable", accept: "text/html", wantStatus: http.StatusNotAcceptable,
+			wantContentType: MimeJSON},
+		{name: "ExcludedRange", accept: "application/*;q=0, */*;q=0.5", wantStatus: http.StatusNotAcceptable,
+			wantContentType: MimeJSON},
+		{name: "ExcludedAll", accept: "*/*;q=0", wantStatus: http.StatusNotAcceptable, wantContentType: MimeJSON},
+		{name: "SpecificOverRange", accept: "*/*;q=0, application/x-protobuf;q=0.2", wantStatus: http.StatusCreated,
+			wantContentType: MimeProtobuf, wantBody: "\x0a\x01a\x10\x50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/servers", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			err := WriteResponse(w, r, http.StatusCreated, httpCreateServer{Name: "a", Port: 80})
+			if (err != nil) != (tt.wantStatus == http.StatusNotAcceptable) {
+				t.Errorf("WriteResponse() error = %v", err)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("WriteResponse() status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.wantContentType {
+				t.Errorf("WriteResponse() Content-Type = %s, want %s", ct, tt.wantContentType)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("WriteResponse() body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/servers", nil)
+	w := httptest.NewRecorder()
+	_ = WriteError(w, r, NewHTTPError(http.StatusBadRequest, "invalid request", "name: is required"))
+	want := `{"status":400,"message":"invalid request","details":["name: is required"]}`
+	if w.Code != http.StatusBadRequest || w.Body.String() != want {
+		t.Errorf("WriteError() = %d %s, want 400 %s", w.Code, w.Body.String(), want)
+	}
+}
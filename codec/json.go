@@ -0,0 +1,283 @@
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//JSONTarget is the name used for the json struct tag and the FieldMeta target names
+const JSONTarget = "json"
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+//JSONCodec struct encodes and decodes types using the JSON format. The struct tags and conventions of encoding/json
+//are honoured.
+type JSONCodec struct {
+	decoderOptions *DecoderOptions
+	encoderOptions *EncoderOptions
+}
+
+//NewJSONCodec function creates a JSONCodec with the DecoderOptions specified.
+//If options is nil the defaults are used which accept unknown fields, duplicate keys and do not coerce types
+func NewJSONCodec(options *DecoderOptions) *JSONCodec {
+	if options == nil {
+		options = &DecoderOptions{}
+	}
+	return &JSONCodec{
+		decoderOptions: options,
+		encoderOptions: &EncoderOptions{},
+	}
+}
+
+//WithEncoderOptions function returns a copy of the codec that uses the EncoderOptions specified
+func (c *JSONCodec) WithEncoderOptions(options *EncoderOptions) *JSONCodec {
+	if options == nil {
+		options = &EncoderOptions{}
+	}
+	return &JSONCodec{
+		decoderOptions: c.decoderOptions,
+		encoderOptions: options,
+	}
+}
+
+//EncodeToString function encodes the value v to a JSON string
+func (c *JSONCodec) EncodeToString(v interface{}) (string, error) {
+	b, err := c.EncodeToBytes(v)
+	return string(b), err
+}
+
+//EncodeToBytes function encodes the value v to JSON. Types with a generated encoder are encoded without reflection.
+//Time and duration fields are written using the format declared on them with the format tag and the values of
+//registered unions carry their discriminator. If the codec is Redacted the sensitive fields are masked or omitted.
+//If the codec is Canonical the output is in the JSON Canonicalization Scheme (RFC 8785)
+func (c *JSONCodec) EncodeToBytes(v interface{}) ([]byte, error) {
+	b, err := marshalJSON(v)
+	if err == nil && c.encoderOptions.Redacted {
+		b, err = redactJSON(b, reflect.ValueOf(v), c.encoderOptions.mask())
+	}
+	if err == nil && c.encoderOptions.Canonical {
+		b, err = canonicalizeJSON(b)
+	}
+	return b, err
+}
+
+//marshalJSON encodes v to JSON applying the formats of the time and duration fields and the union discriminators
+func marshalJSON(v interface{}) ([]byte, error) {
+	var b []byte
+	var err error
+	if a, ok := v.(JSONAppender); ok {
+		b, err = a.AppendJSON(nil)
+	} else {
+		b, err = json.Marshal(v)
+	}
+	if err == nil {
+		b, err = formatJSON(b, reflect.TypeOf(v))
+	}
+	if err == nil {
+		b, err = encodeUnionJSON(b, reflect.ValueOf(v), marshalJSON)
+	}
+	return b, err
+}
+
+//Write function encodes the value v as JSON and writes it to w
+func (c *JSONCodec) Write(v interface{}, w io.Writer) error {
+	b, err := c.EncodeToBytes(v)
+	if err == nil {
+		_, err = w.Write(b)
+	}
+	return err
+}
+
+//DecodeString function decodes the JSON string s in to v
+func (c *JSONCodec) DecodeString(s string, v interface{}) error {
+	return c.DecodeBytes([]byte(s), v)
+}
+
+//DecodeBytes function decodes the JSON in b in to v applying the DecoderOptions of the codec.
+//The default values declared on the fields are applied before decoding and the constraints are validated after
+//decoding. Time and duration fields are read using the format declared on them with the format tag and the values of
+//registered unions are decoded in to the implementation selected by their discriminator. Types with a generated
+//decoder are decoded without reflection.
+func (c *JSONCodec) DecodeBytes(b []byte, v interface{}) error {
+	if err := c.decode(b, v, "$"); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+//decode decodes the JSON in b in to v without validating it. path is the location of b in the document being decoded.
+func (c *JSONCodec) decode(b []byte, v interface{}, path string) error {
+	var err error
+	opts := c.decoderOptions
+	if opts.DisallowDuplicateKeys {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		if err = checkDuplicateKeys(dec, "$"); err != nil {
+			return err
+		}
+	}
+	if b, err = unformatJSON(b, reflect.TypeOf(v)); err != nil {
+		return err
+	}
+	if opts.Coercion == LenientTyping {
+		if b, err = coerceJSON(b, reflect.TypeOf(v)); err != nil {
+			return err
+		}
+	}
+	var pending []pendingUnion
+	if b, pending, err = extractUnionJSON(b, reflect.TypeOf(v), path); err != nil {
+		return err
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		if err = ApplyDefaults(v); err != nil {
+			return err
+		}
+	}
+	if d, ok := v.(JSONReaderDecoder); ok {
+		err = d.DecodeJSON(NewJSONReader(b), opts)
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		if opts.DisallowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
+		err = dec.Decode(v)
+	}
+	if err == nil && len(pending) > 0 {
+		err = decodeUnions(reflect.ValueOf(v), pending, c.decode)
+	}
+	return err
+}
+
+//Read function reads all the JSON content from r and decodes it in to v.
+//This function does not close the reader and it is the responsibility of the caller to close the reader
+func (c *JSONCodec) Read(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err == nil {
+		err = c.DecodeBytes(b, v)
+	}
+	return err
+}
+
+//checkDuplicateKeys walks the next JSON value in the token stream and returns an error naming the path of the first
+//object key that is repeated.
+func checkDuplicateKeys(dec *json.Decoder, path string) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := t.(json.Delim)
+	if !ok {
+		return nil
+	}
+	switch delim {
+	case '{':
+		keys := make(map[string]bool)
+		for dec.More() {
+			if t, err = dec.Token(); err != nil {
+				return err
+			}
+			key := t.(string)
+			if keys[key] {
+				return fmt.Errorf("json: duplicate key %q at %s", key, path)
+			}
+			keys[key] = true
+			if err = checkDuplicateKeys(dec, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; dec.More(); i++ {
+			if err = checkDuplicateKeys(dec, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	}
+	//consume the closing delimiter
+	_, err = dec.Token()
+	return err
+}
+
+//coerceJSON converts the scalar values in the JSON b to the types expected by t and returns the updated JSON
+func coerceJSON(b []byte, t reflect.Type) ([]byte, error) {
+	var tree interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return json.Marshal(coerce(tree, t))
+}
+
+//coerce converts the node of a decoded JSON tree to the JSON type matching the kind of t where possible.
+//Nodes that cannot be converted are returned unchanged so that the decoder reports the type mismatch.
+func coerce(node interface{}, t reflect.Type) interface{} {
+	if t == nil {
+		return node
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if pt := reflect.PtrTo(t); pt.Implements(jsonUnmarshalerType) || pt.Implements(textUnmarshalerType) {
+		return node
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if m, ok := node.(map[string]interface{}); ok {
+			for k, v := range m {
+				if f := LookupField(t, JSONTarget, k); f != nil {
+					m[k] = coerce(v, f.Type)
+				}
+			}
+		}
+	case reflect.Map:
+		if m, ok := node.(map[string]interface{}); ok {
+			for k, v := range m {
+				m[k] = coerce(v, t.Elem())
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if a, ok := node.([]interface{}); ok {
+			for i, v := range a {
+				a[i] = coerce(v, t.Elem())
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if s, ok := node.(string); ok {
+			var n json.Number
+			if err := json.Unmarshal([]byte(strings.TrimSpace(s)), &n); err == nil {
+				return n
+			}
+		}
+	case reflect.Bool:
+		var s string
+		switch v := node.(type) {
+		case string:
+			s = strings.TrimSpace(v)
+		case json.Number:
+			s = v.String()
+		default:
+			return node
+		}
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case reflect.String:
+		switch v := node.(type) {
+		case json.Number:
+			return v.String()
+		case bool:
+			return strconv.FormatBool(v)
+		}
+	}
+	return node
+}
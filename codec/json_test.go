@@ -0,0 +1,84 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testServer struct {
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	Secure  bool   `json:"secure"`
+	Retries []int  `json:"retries,omitempty"`
+}
+
+func TestJSONCodec_DecodeString(t *testing.T) {
+	tests := []struct {
+		name    string
+		options *DecoderOptions
+		input   string
+		want    testServer
+		wantErr bool
+	}{
+		{
+			name:  "Default",
+			input: `{"host":"localhost","port":8080,"secure":true}`,
+			want:  testServer{Host: "localhost", Port: 8080, Secure: true},
+		},
+		{
+			name:  "DefaultIgnoresUnknown",
+			input: `{"host":"localhost","prot":8080}`,
+			want:  testServer{Host: "localhost"},
+		},
+		{
+			name:    "StrictUnknownField",
+			options: StrictDecoding(),
+			input:   `{"host":"localhost","prot":8080}`,
+			wantErr: true,
+		},
+		{
+			name:    "StrictDuplicateKey",
+			options: StrictDecoding(),
+			input:   `{"host":"localhost","host":"remote"}`,
+			wantErr: true,
+		},
+		{
+			name:    "StrictTyping",
+			options: StrictDecoding(),
+			input:   `{"port":"8080"}`,
+			wantErr: true,
+		},
+		{
+			name:    "LenientTyping",
+			options: LenientDecoding(),
+			input:   `{"host":42,"port":" 8080","secure":"true","retries":["1",2]}`,
+			want:    testServer{Host: "42", Port: 8080, Secure: true, Retries: []int{1, 2}},
+		},
+		{
+			name:    "LenientInvalidNumber",
+			options: LenientDecoding(),
+			input:   `{"port":"eighty"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testServer
+			err := NewJSONCodec(tt.options).DecodeString(tt.input, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDuplicateKeys_Nested(t *testing.T) {
+	var got map[string]interface{}
+	err := NewJSONCodec(StrictDecoding()).DecodeString(`{"a":[{"b":1},{"b":2,"b":3}]}`, &got)
+	if err == nil || err.Error() != `json: duplicate key "b" at $.a[1]` {
+		t.Errorf("DecodeString() error = %v", err)
+	}
+}
@@ -0,0 +1,529 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+//JSONReader struct reads JSON values from a byte slice without reflection. It is used by the decoders generated by
+//cmd/codecgen. The first error found is kept and all the subsequent reads are no-ops, so the generated code checks
+//the error once per field.
+type JSONReader struct {
+	data []byte
+	pos  int
+	err  error
+	//first holds a flag per open object or array indicating that no element has been read yet
+	first []bool
+}
+
+//NewJSONReader function creates a JSONReader for the JSON in data
+func NewJSONReader(data []byte) *JSONReader {
+	return &JSONReader{data: data, first: make([]bool, 0, 8)}
+}
+
+//Err returns the first error found by the reader
+func (r *JSONReader) Err() error {
+	return r.err
+}
+
+//ObjectStart reads the start of an object. false is returned if the value is null
+func (r *JSONReader) ObjectStart(typeName string) bool {
+	return r.start('{', "Go value of type "+typeName)
+}
+
+//ArrayStart reads the start of an array. false is returned if the value is null
+func (r *JSONReader) ArrayStart(field string) bool {
+	return r.start('[', "field "+field+" of type array")
+}
+
+//More checks if the current object or array has more elements. The separating comma is consumed.
+func (r *JSONReader) More() bool {
+	if r.err != nil {
+		return false
+	}
+	r.skipWhitespace()
+	if r.pos >= len(r.data) {
+		r.err = errors.New("unexpected end of JSON input")
+		return false
+	}
+	c := r.data[r.pos]
+	top := len(r.first) - 1
+	if c == '}' || c == ']' {
+		return false
+	}
+	if top >= 0 && !r.first[top] {
+		if c != ',' {
+			r.syntaxError("invalid character " + quoteChar(c) + " after element")
+			return false
+		}
+		r.pos++
+	}
+	if top >= 0 {
+		r.first[top] = false
+	}
+	return true
+}
+
+//ObjectEnd reads the end of the current object
+func (r *JSONReader) ObjectEnd() error {
+	r.end('}')
+	return r.err
+}
+
+//ArrayEnd reads the end of the current array
+func (r *JSONReader) ArrayEnd() {
+	r.end(']')
+}
+
+//Key reads the next key of the current object and the colon following it. The name matching the key is returned.
+//An exact match is preferred and a case insensitive match is used as fallback, as done by encoding/json. If no name
+//matches, the value is skipped, or an error is set if the DecoderOptions disallow unknown fields, and an empty name is
+//returned.
+func (r *JSONReader) Key(names []string, opts *DecoderOptions) string {
+	key, ok := r.readString()
+	if !ok {
+		return ""
+	}
+	r.skipWhitespace()
+	if r.pos >= len(r.data) || r.data[r.pos] != ':' {
+		r.syntaxError("expected colon after object key")
+		return ""
+	}
+	r.pos++
+	for _, n := range names {
+		if n == key {
+			return n
+		}
+	}
+	for _, n := range names {
+		if strings.EqualFold(n, key) {
+			return n
+		}
+	}
+	if opts != nil && opts.DisallowUnknownFields {
+		r.err = fmt.Errorf("json: unknown field %q", key)
+		return ""
+	}
+	r.Skip()
+	return ""
+}
+
+//String reads a string in to p. A null value leaves p unchanged.
+func (r *JSONReader) String(field string, p *string) {
+	if r.null() {
+		return
+	}
+	if r.peek() != '"' {
+		r.typeError(field, "string")
+		return
+	}
+	if s, ok := r.readString(); ok {
+		*p = s
+	}
+}
+
+//Bool reads a bool in to p. A null value leaves p unchanged.
+func (r *JSONReader) Bool(field string, p *bool) {
+	if r.null() {
+		return
+	}
+	switch {
+	case r.literal("true"):
+		*p = true
+	case r.literal("false"):
+		*p = false
+	default:
+		r.typeError(field, "bool")
+	}
+}
+
+//Int reads an integer of the bit size specified. false is returned for a null value
+func (r *JSONReader) Int(field string, bits int) (int64, bool) {
+	num, ok := r.number(field, "int")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(num, 10, bits)
+	if err != nil {
+		r.err = fmt.Errorf("json: cannot unmarshal number %s into field %s of type int%d", num, field, bits)
+		return 0, false
+	}
+	return n, true
+}
+
+//Uint reads an unsigned integer of the bit size specified. false is returned for a null value
+func (r *JSONReader) Uint(field string, bits int) (uint64, bool) {
+	num, ok := r.number(field, "uint")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(num, 10, bits)
+	if err != nil {
+		r.err = fmt.Errorf("json: cannot unmarshal number %s into field %s of type uint%d", num, field, bits)
+		return 0, false
+	}
+	return n, true
+}
+
+//Float reads a float of the bit size specified. false is returned for a null value
+func (r *JSONReader) Float(field string, bits int) (float64, bool) {
+	num, ok := r.number(field, "float")
+	if !ok {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(num, bits)
+	if err != nil {
+		r.err = fmt.Errorf("json: cannot unmarshal number %s into field %s of type float%d", num, field, bits)
+		return 0, false
+	}
+	return f, true
+}
+
+//Value decodes the next value in to p. If p implements JSONReaderDecoder it is used, otherwise encoding/json is used.
+func (r *JSONReader) Value(p interface{}, opts *DecoderOptions) {
+	if r.err != nil {
+		return
+	}
+	if d, ok := p.(JSONReaderDecoder); ok {
+		if err := d.DecodeJSON(r, opts); err != nil && r.err == nil {
+			r.err = err
+		}
+		return
+	}
+	r.skipWhitespace()
+	start := r.pos
+	r.Skip()
+	if r.err != nil {
+		return
+	}
+	raw := r.data[start:r.pos]
+	if u, ok := p.(json.Unmarshaler); ok {
+		r.err = u.UnmarshalJSON(raw)
+		return
+	}
+	if opts == nil || !opts.DisallowUnknownFields {
+		r.err = json.Unmarshal(raw, p)
+		return
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	r.err = dec.Decode(p)
+}
+
+//Skip reads and discards the next value
+func (r *JSONReader) Skip() {
+	if r.err != nil {
+		return
+	}
+	switch c := r.peek(); {
+	case c == '{' || c == '[':
+		r.pos++
+		r.first = append(r.first, true)
+		for r.More() {
+			if c == '{' {
+				if _, ok := r.readString(); !ok {
+					return
+				}
+				r.skipWhitespace()
+				if r.pos >= len(r.data) || r.data[r.pos] != ':' {
+					r.syntaxError("expected colon after object key")
+					return
+				}
+				r.pos++
+			}
+			r.Skip()
+		}
+		if c == '{' {
+			r.end('}')
+		} else {
+			r.end(']')
+		}
+	case c == '"':
+		r.readString()
+	case c == '-' || c >= '0' && c <= '9':
+		r.scanNumber()
+	case r.literal("true"), r.literal("false"), r.literal("null"):
+	default:
+		r.unexpected()
+	}
+}
+
+func (r *JSONReader) start(delim byte, target string) bool {
+	if r.null() {
+		return false
+	}
+	if r.peek() != delim {
+		r.err = fmt.Errorf("json: cannot unmarshal %s into %s", r.kind(), target)
+		return false
+	}
+	r.pos++
+	r.first = append(r.first, true)
+	return true
+}
+
+func (r *JSONReader) end(delim byte) {
+	if r.err != nil {
+		return
+	}
+	r.skipWhitespace()
+	if r.pos >= len(r.data) || r.data[r.pos] != delim {
+		r.unexpected()
+		return
+	}
+	r.pos++
+	r.first = r.first[:len(r.first)-1]
+}
+
+//null consumes a null literal. true is also returned if an error was found so that the caller skips the value
+func (r *JSONReader) null() bool {
+	if r.err != nil {
+		return true
+	}
+	r.skipWhitespace()
+	return r.literal("null")
+}
+
+func (r *JSONReader) literal(lit string) bool {
+	if r.pos+len(lit) <= len(r.data) && string(r.data[r.pos:r.pos+len(lit)]) == lit {
+		r.pos += len(lit)
+		return true
+	}
+	return false
+}
+
+func (r *JSONReader) peek() byte {
+	r.skipWhitespace()
+	if r.pos < len(r.data) {
+		return r.data[r.pos]
+	}
+	return 0
+}
+
+func (r *JSONReader) skipWhitespace() {
+	for r.pos < len(r.data) {
+		switch r.data[r.pos] {
+		case ' ', '\t', '\n', '\r':
+			r.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (r *JSONReader) number(field, typeName string) (string, bool) {
+	if r.null() {
+		return "", false
+	}
+	c := r.peek()
+	if c != '-' && (c < '0' || c > '9') {
+		r.typeError(field, typeName)
+		return "", false
+	}
+	return r.scanNumber()
+}
+
+//scanNumber reads a number as per the JSON grammar and returns its text
+func (r *JSONReader) scanNumber() (string, bool) {
+	start := r.pos
+	d := r.data
+	i := r.pos
+	if i < len(d) && d[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(d) && d[i] == '0':
+		i++
+	case i < len(d) && d[i] >= '1' && d[i] <= '9':
+		for i < len(d) && d[i] >= '0' && d[i] <= '9' {
+			i++
+		}
+	default:
+		r.pos = i
+		r.unexpected()
+		return "", false
+	}
+	if i < len(d) && d[i] == '.' {
+		i++
+		if i >= len(d) || d[i] < '0' || d[i] > '9' {
+			r.pos = i
+			r.unexpected()
+			return "", false
+		}
+		for i < len(d) && d[i] >= '0' && d[i] <= '9' {
+			i++
+		}
+	}
+	if i < len(d) && (d[i] == 'e' || d[i] == 'E') {
+		i++
+		if i < len(d) && (d[i] == '+' || d[i] == '-') {
+			i++
+		}
+		if i >= len(d) || d[i] < '0' || d[i] > '9' {
+			r.pos = i
+			r.unexpected()
+			return "", false
+		}
+		for i < len(d) && d[i] >= '0' && d[i] <= '9' {
+			i++
+		}
+	}
+	r.pos = i
+	return string(d[start:i]), true
+}
+
+//readString reads a string decoding the escape sequences. Invalid UTF-8 is replaced with U+FFFD as done by
+//encoding/json
+func (r *JSONReader) readString() (string, bool) {
+	if r.err != nil {
+		return "", false
+	}
+	if r.peek() != '"' {
+		r.unexpected()
+		return "", false
+	}
+	r.pos++
+	start := r.pos
+	//fast path for strings without escapes
+	for i := start; i < len(r.data); i++ {
+		c := r.data[i]
+		if c == '"' {
+			if utf8.Valid(r.data[start:i]) {
+				r.pos = i + 1
+				return string(r.data[start:i]), true
+			}
+			break
+		}
+		if c == '\\' || c < 0x20 {
+			break
+		}
+	}
+	buf := make([]byte, 0, 32)
+	for i := start; i < len(r.data); {
+		c := r.data[i]
+		switch {
+		case c == '"':
+			r.pos = i + 1
+			return string(buf), true
+		case c < 0x20:
+			r.pos = i
+			r.syntaxError("invalid character " + quoteChar(c) + " in string literal")
+			return "", false
+		case c == '\\':
+			if i+1 >= len(r.data) {
+				i = len(r.data)
+				continue
+			}
+			i++
+			switch r.data[i] {
+			case '"', '\\', '/':
+				buf = append(buf, r.data[i])
+			case 'b':
+				buf = append(buf, '\b')
+			case 'f':
+				buf = append(buf, '\f')
+			case 'n':
+				buf = append(buf, '\n')
+			case 'r':
+				buf = append(buf, '\r')
+			case 't':
+				buf = append(buf, '\t')
+			case 'u':
+				rr, ok := parseHex4(r.data, i+1)
+				if !ok {
+					r.pos = i
+					r.syntaxError("invalid escape sequence in string literal")
+					return "", false
+				}
+				i += 4
+				if utf16.IsSurrogate(rr) {
+					//a surrogate pair is written as two escapes
+					if lo, ok := parseHex4(r.data, i+3); ok && i+2 < len(r.data) && r.data[i+1] == '\\' &&
+						r.data[i+2] == 'u' {
+						if dec := utf16.DecodeRune(rr, lo); dec != utf8.RuneError {
+							rr = dec
+							i += 6
+						} else {
+							rr = utf8.RuneError
+						}
+					} else {
+						rr = utf8.RuneError
+					}
+				}
+				buf = append(buf, string(rr)...)
+			default:
+				r.pos = i
+				r.syntaxError("invalid escape sequence in string literal")
+				return "", false
+			}
+			i++
+		case c < utf8.RuneSelf:
+			buf = append(buf, c)
+			i++
+		default:
+			rr, size := utf8.DecodeRune(r.data[i:])
+			if rr == utf8.RuneError && size == 1 {
+				buf = append(buf, "�"...)
+			} else {
+				buf = append(buf, r.data[i:i+size]...)
+			}
+			i += size
+		}
+	}
+	r.err = errors.New("unexpected end of JSON input")
+	return "", false
+}
+
+func parseHex4(d []byte, i int) (rune, bool) {
+	if i+4 > len(d) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(d[i:i+4]), 16, 32)
+	return rune(n), err == nil
+}
+
+func (r *JSONReader) kind() string {
+	switch c := r.peek(); {
+	case c == '{':
+		return "object"
+	case c == '[':
+		return "array"
+	case c == '"':
+		return "string"
+	case c == 't' || c == 'f':
+		return "bool"
+	}
+	return "number"
+}
+
+func (r *JSONReader) typeError(field, typeName string) {
+	r.err = fmt.Errorf("json: cannot unmarshal %s into field %s of type %s", r.kind(), field, typeName)
+}
+
+func (r *JSONReader) unexpected() {
+	if r.pos >= len(r.data) {
+		r.err = errors.New("unexpected end of JSON input")
+		return
+	}
+	r.syntaxError("invalid character " + quoteChar(r.data[r.pos]) + " looking for beginning of value")
+}
+
+func (r *JSONReader) syntaxError(msg string) {
+	r.err = fmt.Errorf("json: %s at offset %d", msg, r.pos)
+}
+
+func quoteChar(c byte) string {
+	if c == '\'' {
+		return `'\''`
+	}
+	if c == '"' {
+		return `'"'`
+	}
+	s := strconv.Quote(string(c))
+	return "'" + s[1:len(s)-1] + "'"
+}
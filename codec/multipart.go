@@ -0,0 +1,242 @@
+package codec
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.codemanch.com/commons/fsutils"
+	"go.codemanch.com/commons/textutils"
+)
+
+//MimeMultipart is the media type of multipart form uploads
+const MimeMultipart = "multipart/form-data"
+
+//ErrFileTooLarge is returned while reading a FilePart that exceeds MultipartOptions.MaxFileSize
+var ErrFileTooLarge = errors.New("multipart: file exceeds the size limit")
+
+//DefaultMaxMultipartSize is the size limit in bytes of a multipart request body applied by DecodeMultipart
+const DefaultMaxMultipartSize int64 = 32 << 20
+
+//DefaultMaxFileSize is the size limit in bytes of each file part applied by DecodeMultipart
+const DefaultMaxFileSize int64 = 10 << 20
+
+//MultipartOptions struct holds the limits and the decoding options applied by DecodeMultipart
+type MultipartOptions struct {
+	//MaxBodySize is the size limit in bytes of the whole request body. DefaultMaxMultipartSize is used if it is 0
+	MaxBodySize int64
+	//MaxFileSize is the size limit in bytes of each file part. DefaultMaxFileSize is used if it is 0
+	MaxFileSize int64
+	//MaxTextSize is the total size limit in bytes of the text parts, including the headers and the names of all the
+	//parts. DefaultMaxBodySize is used if it is 0
+	MaxTextSize int64
+	//AllowedTypes are the media types accepted for file parts, e.g. image/png or image/*. The type is detected from
+	//the content of the part using fsutils.DetectReaderContentType. All types are accepted if it is empty.
+	AllowedTypes []string
+	//FormOptions are the DecoderOptions of the FormCodec binding the text parts, e.g. to disallow unknown fields
+	FormOptions *DecoderOptions
+}
+
+//multipartBody limits the bytes read from the body of a multipart request. exceeded is set once more than limit bytes
+//are read so that the errors of the multipart reader, which do not wrap the cause, can be reported as 413.
+type multipartBody struct {
+	reader   io.Reader
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+//errBodyTooLarge is returned by multipartBody once the limit is exceeded
+var errBodyTooLarge = errors.New("multipart: body exceeds the size limit")
+
+func (b *multipartBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errBodyTooLarge
+	}
+	//at most one byte more than the limit is read to detect that the body is too large
+	if room := b.limit - b.read + 1; int64(len(p)) > room {
+		p = p[:room]
+	}
+	n, err := b.reader.Read(p)
+	if b.read += int64(n); b.read > b.limit {
+		b.exceeded = true
+		return n - int(b.read-b.limit), errBodyTooLarge
+	}
+	return n, err
+}
+
+//FilePart struct is a streaming handle to a file part of a multipart request. The content is available only while the
+//FileHandler processing the part runs and reading more than MultipartOptions.MaxFileSize bytes returns
+//ErrFileTooLarge.
+type FilePart struct {
+	//FieldName is the name of the form field
+	FieldName string
+	//FileName is the name of the file sent by the client
+	FileName string
+	//ContentType is the media type detected from the content
+	ContentType string
+	//Header of the part
+	Header   textproto.MIMEHeader
+	reader   io.Reader
+	limit    int64
+	read     int64
+	tooLarge bool
+}
+
+//Read reads the content of the file part
+func (f *FilePart) Read(p []byte) (int, error) {
+	if f.tooLarge {
+		return 0, ErrFileTooLarge
+	}
+	n, err := f.reader.Read(p)
+	f.read += int64(n)
+	if f.read > f.limit {
+		f.tooLarge = true
+		return n - int(f.read-f.limit), ErrFileTooLarge
+	}
+	return n, err
+}
+
+//FileHandler processes a file part of a multipart request. An error returned stops decoding the request
+type FileHandler func(part *FilePart) error
+
+//DecodeMultipart function decodes the multipart/form-data request r. The text parts are bound to the struct pointed by
+//v as done by the FormCodec, including the default values and validation. The file parts are passed to handler as
+//they arrive without buffering them. File parts are skipped if handler is nil. The errors returned are HTTPError with
+//status 415 for a request or file part of an unsupported type, 413 for parts exceeding the limits and 400 for malformed
+//or invalid content.
+func DecodeMultipart(r *http.Request, v interface{}, options *MultipartOptions, handler FileHandler) error {
+	if options == nil {
+		options = &MultipartOptions{}
+	}
+	maxText := options.MaxTextSize
+	if maxText <= 0 {
+		maxText = DefaultMaxBodySize
+	}
+	body := &multipartBody{reader: r.Body, limit: options.MaxBodySize}
+	if body.limit <= 0 {
+		body.limit = DefaultMaxMultipartSize
+	}
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != MimeMultipart {
+		return NewHTTPError(http.StatusUnsupportedMediaType, "a "+MimeMultipart+" request is required")
+	}
+	if params["boundary"] == textutils.EmptyStr {
+		return NewHTTPError(http.StatusBadRequest, "multipart boundary is missing")
+	}
+	mr := multipart.NewReader(body, params["boundary"])
+	values := url.Values{}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return body.error(NewHTTPError(http.StatusBadRequest, "malformed multipart body", err.Error()))
+		}
+		//the headers of every part, holding the names, use the budget of the text parts
+		if maxText -= headerSize(part.Header); maxText < 0 {
+			return NewHTTPError(http.StatusRequestEntityTooLarge, "text parts exceed the size limit")
+		}
+		name := part.FormName()
+		if name == textutils.EmptyStr {
+			continue
+		}
+		if part.FileName() == textutils.EmptyStr {
+			//text part, the remaining budget is shared by all the text parts
+			b, err := ioutil.ReadAll(io.LimitReader(part, maxText+1))
+			if err != nil {
+				return body.error(NewHTTPError(http.StatusBadRequest, "malformed multipart body", err.Error()))
+			}
+			if maxText -= int64(len(b)); maxText < 0 {
+				return NewHTTPError(http.StatusRequestEntityTooLarge, "text parts exceed the size limit")
+			}
+			values.Add(name, string(b))
+			continue
+		}
+		if handler == nil {
+			continue
+		}
+		if err = handleFilePart(part, options, handler); err != nil {
+			return body.error(err)
+		}
+	}
+	if err = NewFormCodec(options.FormOptions).DecodeValues(values, v); err != nil {
+		return decodeError(err)
+	}
+	return nil
+}
+
+//error returns a 413 HTTPError if the body exceeded its limit and err otherwise
+func (b *multipartBody) error(err error) error {
+	if b.exceeded {
+		return NewHTTPError(http.StatusRequestEntityTooLarge,
+			"request body exceeds the limit of "+strconv.FormatInt(b.limit, 10)+" bytes")
+	}
+	return err
+}
+
+//headerSize returns the size of the header h as sent in the request
+func headerSize(h textproto.MIMEHeader) int64 {
+	var size int64
+	for k, values := range h {
+		for _, v := range values {
+			//key, ": " and CRLF
+			size += int64(len(k) + len(v) + 4)
+		}
+	}
+	return size
+}
+
+func handleFilePart(part *multipart.Part, options *MultipartOptions, handler FileHandler) error {
+	contentType, reader, err := fsutils.DetectReaderContentType(part)
+	if err != nil {
+		return NewHTTPError(http.StatusBadRequest, "malformed multipart body", err.Error())
+	}
+	if !isAllowedType(contentType, options.AllowedTypes) {
+		return NewHTTPError(http.StatusUnsupportedMediaType, "unsupported file type "+strconv.Quote(contentType),
+			part.FormName()+": "+part.FileName())
+	}
+	fp := &FilePart{
+		FieldName:   part.FormName(),
+		FileName:    part.FileName(),
+		ContentType: contentType,
+		Header:      part.Header,
+		reader:      reader,
+		limit:       options.MaxFileSize,
+	}
+	if fp.limit <= 0 {
+		fp.limit = DefaultMaxFileSize
+	}
+	//at most one byte more than the limit is read to detect that the part is too large
+	fp.reader = io.LimitReader(reader, fp.limit+1)
+	if err = handler(fp); err != nil {
+		if errors.Is(err, ErrFileTooLarge) {
+			return NewHTTPError(http.StatusRequestEntityTooLarge, "file exceeds the size limit of "+
+				strconv.FormatInt(fp.limit, 10)+" bytes", fp.FieldName+": "+fp.FileName)
+		}
+		return err
+	}
+	return nil
+}
+
+//isAllowedType checks if the media type of contentType matches one of the allowed media ranges
+func isAllowedType(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType := normalizeMediaType(contentType)
+	for _, a := range allowed {
+		if matchMediaRange(strings.ToLower(a), mediaType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package codec
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type uploadForm struct {
+	Title string   `json:"title" required:"true"`
+	Tags  []string `json:"tags"`
+	Album string   `json:"album" default:"default"`
+}
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func newMultipartRequest(t *testing.T, fields map[string][]string, files map[string][]byte) *http.Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for name, values := range fields {
+		for _, v := range values {
+			if err := mw.WriteField(name, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for name, content := range files {
+		fw, err := mw.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = fw.Write(content)
+	}
+	_ = mw.Close()
+	r := httptest.NewRequest(http.MethodPost, "/upload", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestDecodeMultipart(t *testing.T) {
+	tests := []struct {
+		name       string
+		fields     map[string][]string
+		files      map[string][]byte
+		options    *MultipartOptions
+		wantStatus int
+		wantFiles  map[string]string
+	}{
+		{
+			name:      "FieldsAndFiles",
+			fields:    map[string][]string{"title": {"holiday"}, "tags": {"a", "b"}},
+			files:     map[string][]byte{"a.png": pngHeader, "b.txt": []byte("hello")},
+			options:   &MultipartOptions{MaxFileSize: 64},
+			wantFiles: map[string]string{"a.png": "image/png", "b.txt": "text/plain; charset=utf-8"},
+		},
+		{
+			name:       "FileTooLarge",
+			fields:     map[string][]string{"title": {"holiday"}},
+			files:      map[string][]byte{"a.png": pngHeader},
+			options:    &MultipartOptions{MaxFileSize: 8},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "TypeNotAllowed",
+			fields:     map[string][]string{"title": {"holiday"}},
+			files:      map[string][]byte{"b.txt": []byte("hello")},
+			options:    &MultipartOptions{AllowedTypes: []string{"image/*"}},
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "TextTooLarge",
+			fields:     map[string][]string{"title": {"a long title"}},
+			options:    &MultipartOptions{MaxTextSize: 4},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "BodyTooLarge",
+			fields:     map[string][]string{"title": {"holiday"}},
+			files:      map[string][]byte{"a.png": pngHeader},
+			options:    &MultipartOptions{MaxBodySize: 128},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "HeadersTooLarge",
+			fields:     map[string][]string{"title": {"a"}, strings.Repeat("n", 64): {""}},
+			options:    &MultipartOptions{MaxTextSize: 64},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "UnknownField",
+			fields:     map[string][]string{"title": {"holiday"}, "tags": {"a", "b"}, "extra": {"x"}},
+			options:    &MultipartOptions{FormOptions: StrictDecoding()},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid",
+			fields:     map[string][]string{"tags": {"a"}},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			var form uploadForm
+			err := DecodeMultipart(newMultipartRequest(t, tt.fields, tt.files), &form, tt.options,
+				func(part *FilePart) error {
+					content, err := ioutil.ReadAll(part)
+					if err != nil {
+						return err
+					}
+					if !bytes.Equal(content, tt.files[part.FileName]) {
+						t.Errorf("FilePart content = %q, want %q", content, tt.files[part.FileName])
+					}
+					got[part.FileName] = part.ContentType
+					return nil
+				})
+			if tt.wantStatus != 0 {
+				if httpErr, ok := err.(*HTTPError); !ok || httpErr.Status != tt.wantStatus {
+					t.Fatalf("DecodeMultipart() error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if form.Title != "holiday" || len(form.Tags) != 2 || form.Album != "default" {
+				t.Errorf("DecodeMultipart() form = %+v", form)
+			}
+			for name, ct := range tt.wantFiles {
+				if got[name] != ct {
+					t.Errorf("DecodeMultipart() %s content type = %q, want %q", name, got[name], ct)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package codec
+
+//CoercionPolicy specifies how a decoder handles a scalar value whose type does not match the target field type
+type CoercionPolicy int
+
+const (
+	//StrictTyping rejects values whose type does not match the target type. This is the default policy.
+	StrictTyping CoercionPolicy = iota
+	//LenientTyping converts scalar values to the target type where possible. e.g. "42" into an int field or
+	//"true" into a bool field
+	LenientTyping
+)
+
+//DecoderOptions holds the settings that control how strict a decoder is with its input
+type DecoderOptions struct {
+	//DisallowUnknownFields causes decoding to fail if the input contains a key that does not map to a field of the
+	//target struct
+	DisallowUnknownFields bool
+	//DisallowDuplicateKeys causes decoding to fail if an object in the input contains the same key more than once
+	DisallowDuplicateKeys bool
+	//Coercion policy to be applied to scalar values. Default is StrictTyping
+	Coercion CoercionPolicy
+}
+
+//StrictDecoding function returns the DecoderOptions that reject unknown fields, duplicate keys and mismatched types.
+//This is recommended for configuration files where a typo must not be ignored silently.
+func StrictDecoding() *DecoderOptions {
+	return &DecoderOptions{
+		DisallowUnknownFields: true,
+		DisallowDuplicateKeys: true,
+		Coercion:              StrictTyping,
+	}
+}
+
+//LenientDecoding function returns the DecoderOptions that accept unknown fields and coerce scalar values
+func LenientDecoding() *DecoderOptions {
+	return &DecoderOptions{
+		Coercion: LenientTyping,
+	}
+}
+
+//EncoderOptions holds the settings that control the output of an encoder
+type EncoderOptions struct {
+	//Canonical produces a byte stable output suitable for hashing and signatures. For JSON this is the
+	//JSON Canonicalization Scheme (RFC 8785)
+	Canonical bool
+	//Redacted replaces the values of the fields tagged with sensitive:"true" with Mask and drops the fields tagged with
+	//sensitive:"omit", so that the same type can be encoded for the wire and for logs
+	Redacted bool
+	//Mask written in place of a sensitive value. DefaultMask is used if empty
+	Mask string
+}
+
+//mask returns the mask to be used for sensitive values
+func (o *EncoderOptions) mask() string {
+	if o.Mask == "" {
+		return DefaultMask
+	}
+	return o.Mask
+}
@@ -0,0 +1,680 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//ProtobufTarget is the name of the struct tag declaring the protobuf field number and encoding of a field.
+//The tag value is the field number optionally followed by comma separated options
+//	zigzag    signed integers are encoded as sint32/sint64
+//	fixed     integers are encoded as fixed32/fixed64 or sfixed32/sfixed64
+//	unpacked  repeated scalars are encoded one per field instead of packed
+//e.g. `protobuf:"1"`, `protobuf:"2,zigzag"`, `protobuf:"3,fixed,unpacked"`
+const ProtobufTarget = "protobuf"
+
+//protobuf wire types
+const (
+	wireVarint     = 0
+	wireFixed64    = 1
+	wireBytes      = 2
+	wireStartGroup = 3
+	wireEndGroup   = 4
+	wireFixed32    = 5
+)
+
+//MaxProtobufDepth is the maximum nesting level of the messages and groups encoded or decoded by the ProtobufCodec
+const MaxProtobufDepth = 100
+
+//protoFields caches the protoField definitions of the struct types
+var protoFields sync.Map
+
+//protoField holds the protobuf definition of a struct field
+type protoField struct {
+	num      int
+	name     string
+	index    []int
+	zigzag   bool
+	fixed    bool
+	unpacked bool
+}
+
+//protoMessage holds the protobuf fields of a struct type
+type protoMessage struct {
+	fields []*protoField
+	byNum  map[int]*protoField
+}
+
+//ProtobufCodec struct encodes and decodes structs using the protobuf binary wire format without generated code.
+//Only the fields with a protobuf struct tag are encoded. The following Go types are supported
+//	bool, int*, uint*, float32, float64, string and []byte
+//	structs and pointers to structs as nested messages
+//	slices as repeated fields, scalars are packed by default
+//	maps as repeated key/value entries with the key as field 1 and the value as field 2
+//	Decimal as a message with the value as string field 1, compatible with google.type.Decimal
+//Fields with the zero value are not written as per proto3. Pointers to scalars are written if not nil.
+//Unknown fields in the input are skipped, including deprecated groups. time.Time fields are rejected as they have no
+//exported fields to encode, a Unix timestamp or a formatted string has to be used instead.
+//Messages can be nested up to MaxProtobufDepth levels.
+type ProtobufCodec struct {
+}
+
+//NewProtobufCodec function creates a ProtobufCodec
+func NewProtobufCodec() *ProtobufCodec {
+	return &ProtobufCodec{}
+}
+
+//EncodeToString function encodes the struct v to the protobuf wire format
+func (c *ProtobufCodec) EncodeToString(v interface{}) (string, error) {
+	b, err := c.EncodeToBytes(v)
+	return string(b), err
+}
+
+//EncodeToBytes function encodes the struct v to the protobuf wire format
+func (c *ProtobufCodec) EncodeToBytes(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("protobuf: cannot encode %T, a struct is required", v)
+	}
+	return appendProtoMessage(nil, rv, 0)
+}
+
+//Write function encodes the struct v to the protobuf wire format and writes it to w
+func (c *ProtobufCodec) Write(v interface{}, w io.Writer) error {
+	b, err := c.EncodeToBytes(v)
+	if err == nil {
+		_, err = w.Write(b)
+	}
+	return err
+}
+
+//DecodeString function decodes the protobuf message s in to v
+func (c *ProtobufCodec) DecodeString(s string, v interface{}) error {
+	return c.DecodeBytes([]byte(s), v)
+}
+
+//DecodeBytes function decodes the protobuf message b in to the struct pointed by v. The default values declared on
+//the fields are applied before decoding and the constraints are validated after decoding.
+func (c *ProtobufCodec) DecodeBytes(b []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("protobuf: cannot decode in to %T, a non nil pointer to a struct is required", v)
+	}
+	if err := ApplyDefaults(v); err != nil {
+		return err
+	}
+	if err := unmarshalProtoMessage(b, rv.Elem(), 0); err != nil {
+		return err
+	}
+	return Validate(v)
+}
+
+//Read function reads the protobuf message from r and decodes it in to v.
+//This function does not close the reader and it is the responsibility of the caller to close the reader
+func (c *ProtobufCodec) Read(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err == nil {
+		err = c.DecodeBytes(b, v)
+	}
+	return err
+}
+
+//getProtoMessage returns the protobuf definition of the struct type t
+func getProtoMessage(t reflect.Type) (*protoMessage, error) {
+	if cached, ok := protoFields.Load(t); ok {
+		return cached.(*protoMessage), nil
+	}
+	pm := &protoMessage{byNum: make(map[int]*protoField)}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup(ProtobufTarget)
+		if !ok || tag == textutils.HyphenStr {
+			continue
+		}
+		if sf.PkgPath != textutils.EmptyStr {
+			return nil, fmt.Errorf("protobuf: unexported field %s.%s has a protobuf tag", t.Name(), sf.Name)
+		}
+		parts := strings.Split(tag, textutils.CommaStr)
+		num, err := strconv.Atoi(parts[0])
+		if err != nil || num < 1 || num > 1<<29-1 {
+			return nil, fmt.Errorf("protobuf: invalid field number %q on %s.%s", parts[0], t.Name(), sf.Name)
+		}
+		if _, exists := pm.byNum[num]; exists {
+			return nil, fmt.Errorf("protobuf: duplicate field number %d on %s.%s", num, t.Name(), sf.Name)
+		}
+		if usesProtoType(sf.Type, timeType) {
+			return nil, fmt.Errorf("protobuf: unsupported type time.Time of field %s.%s, use an integer or a string",
+				t.Name(), sf.Name)
+		}
+		pf := &protoField{num: num, name: sf.Name, index: sf.Index}
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "zigzag":
+				pf.zigzag = true
+			case "fixed":
+				pf.fixed = true
+			case "unpacked":
+				pf.unpacked = true
+			default:
+				return nil, fmt.Errorf("protobuf: unknown option %q on %s.%s", opt, t.Name(), sf.Name)
+			}
+		}
+		pm.fields = append(pm.fields, pf)
+		pm.byNum[num] = pf
+	}
+	cached, _ := protoFields.LoadOrStore(t, pm)
+	return cached.(*protoMessage), nil
+}
+
+//appendProtoMessage appends the fields of the struct rv. depth is the nesting level of the message
+//usesProtoType checks if the field type t is target or holds target as the element or key of a pointer, slice,
+//array or map
+func usesProtoType(t, target reflect.Type) bool {
+	for {
+		if t == target {
+			return true
+		}
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			t = t.Elem()
+		case reflect.Map:
+			if t.Key() == target {
+				return true
+			}
+			t = t.Elem()
+		default:
+			return false
+		}
+	}
+}
+
+func appendProtoMessage(buf []byte, rv reflect.Value, depth int) ([]byte, error) {
+	if depth > MaxProtobufDepth {
+		return buf, fmt.Errorf("protobuf: messages nested deeper than %d", MaxProtobufDepth)
+	}
+	pm, err := getProtoMessage(rv.Type())
+	if err != nil {
+		return buf, err
+	}
+	for _, pf := range pm.fields {
+		if buf, err = appendProtoField(buf, pf, rv.FieldByIndex(pf.index), depth); err != nil {
+			return buf, err
+		}
+	}
+	return buf, nil
+}
+
+//appendProtoField appends the field fv with the key of pf. Zero values are skipped
+func appendProtoField(buf []byte, pf *protoField, fv reflect.Value, depth int) ([]byte, error) {
+	var err error
+	switch fv.Kind() {
+	case reflect.Ptr:
+		if fv.IsNil() {
+			return buf, nil
+		}
+		return appendProtoValue(buf, pf, fv.Elem(), depth)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		if fv.Len() == 0 {
+			return buf, nil
+		}
+		if isPackable(fv.Type().Elem()) && !pf.unpacked {
+			var packed []byte
+			for i := 0; i < fv.Len(); i++ {
+				if packed, err = appendProtoScalar(packed, pf, fv.Index(i)); err != nil {
+					return buf, err
+				}
+			}
+			buf = appendProtoKey(buf, pf.num, wireBytes)
+			buf = appendVarint(buf, uint64(len(packed)))
+			return append(buf, packed...), nil
+		}
+		for i := 0; i < fv.Len(); i++ {
+			if buf, err = appendProtoValue(buf, pf, fv.Index(i), depth); err != nil {
+				return buf, err
+			}
+		}
+		return buf, nil
+	case reflect.Map:
+		//the entries are sorted by key so that the output is deterministic
+		for _, k := range sortedKeys(fv, fv) {
+			var entry []byte
+			if entry, err = appendProtoValue(entry, &protoField{num: 1, name: pf.name}, k, depth); err != nil {
+				return buf, err
+			}
+			if entry, err = appendProtoValue(entry, &protoField{num: 2, name: pf.name, zigzag: pf.zigzag,
+				fixed: pf.fixed}, fv.MapIndex(k), depth); err != nil {
+				return buf, err
+			}
+			buf = appendProtoKey(buf, pf.num, wireBytes)
+			buf = appendVarint(buf, uint64(len(entry)))
+			buf = append(buf, entry...)
+		}
+		return buf, nil
+	}
+	if fv.IsZero() {
+		return buf, nil
+	}
+	return appendProtoValue(buf, pf, fv, depth)
+}
+
+//appendProtoValue appends the key and the value of fv even if it is the zero value
+func appendProtoValue(buf []byte, pf *protoField, fv reflect.Value, depth int) ([]byte, error) {
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv = reflect.Zero(fv.Type().Elem())
+		} else {
+			fv = fv.Elem()
+		}
+	}
+	switch {
+	case fv.Kind() == reflect.String:
+		buf = appendProtoKey(buf, pf.num, wireBytes)
+		buf = appendVarint(buf, uint64(fv.Len()))
+		return append(buf, fv.String()...), nil
+	case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Uint8:
+		buf = appendProtoKey(buf, pf.num, wireBytes)
+		buf = appendVarint(buf, uint64(fv.Len()))
+		return append(buf, fv.Bytes()...), nil
+	case fv.Type() == decimalType:
+		text := fv.Interface().(Decimal).String()
+		msg := appendVarint(appendProtoKey(nil, 1, wireBytes), uint64(len(text)))
+		buf = appendProtoKey(buf, pf.num, wireBytes)
+		buf = appendVarint(buf, uint64(len(msg)+len(text)))
+		return append(append(buf, msg...), text...), nil
+	case fv.Kind() == reflect.Struct:
+		msg, err := appendProtoMessage(nil, fv, depth+1)
+		if err != nil {
+			return buf, err
+		}
+		buf = appendProtoKey(buf, pf.num, wireBytes)
+		buf = appendVarint(buf, uint64(len(msg)))
+		return append(buf, msg...), nil
+	}
+	wt, err := protoWireType(pf, fv.Type())
+	if err != nil {
+		return buf, err
+	}
+	return appendProtoScalar(appendProtoKey(buf, pf.num, wt), pf, fv)
+}
+
+//appendProtoScalar appends the value of the numeric or bool fv without a key
+func appendProtoScalar(buf []byte, pf *protoField, fv reflect.Value) ([]byte, error) {
+	wt, err := protoWireType(pf, fv.Type())
+	if err != nil {
+		return buf, err
+	}
+	switch fv.Kind() {
+	case reflect.Bool:
+		if fv.Bool() {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := fv.Int()
+		switch {
+		case wt == wireFixed32:
+			return appendFixed32(buf, uint32(i)), nil
+		case wt == wireFixed64:
+			return appendFixed64(buf, uint64(i)), nil
+		case pf.zigzag:
+			return appendVarint(buf, uint64(i<<1)^uint64(i>>63)), nil
+		}
+		return appendVarint(buf, uint64(i)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := fv.Uint()
+		switch wt {
+		case wireFixed32:
+			return appendFixed32(buf, uint32(u)), nil
+		case wireFixed64:
+			return appendFixed64(buf, u), nil
+		}
+		return appendVarint(buf, u), nil
+	case reflect.Float32:
+		return appendFixed32(buf, math.Float32bits(float32(fv.Float()))), nil
+	case reflect.Float64:
+		return appendFixed64(buf, math.Float64bits(fv.Float())), nil
+	}
+	return buf, fmt.Errorf("protobuf: unsupported type %s of field %s", fv.Type(), pf.name)
+}
+
+func appendVarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+func appendFixed32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendFixed64(buf []byte, v uint64) []byte {
+	return appendFixed32(appendFixed32(buf, uint32(v)), uint32(v>>32))
+}
+
+func appendProtoKey(buf []byte, num int, wireType int) []byte {
+	return appendVarint(buf, uint64(num)<<3|uint64(wireType))
+}
+
+//protoWireType returns the wire type used for the scalar type t
+func protoWireType(pf *protoField, t reflect.Type) (int, error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return wireVarint, nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		if pf.fixed {
+			return wireFixed64, nil
+		}
+		return wireVarint, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		if pf.fixed {
+			return wireFixed32, nil
+		}
+		return wireVarint, nil
+	case reflect.Float32:
+		return wireFixed32, nil
+	case reflect.Float64:
+		return wireFixed64, nil
+	case reflect.String, reflect.Slice, reflect.Struct, reflect.Map:
+		return wireBytes, nil
+	}
+	return 0, fmt.Errorf("protobuf: unsupported type %s of field %s", t, pf.name)
+}
+
+//isPackable checks if a repeated field of elements of type t can use the packed encoding
+func isPackable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+//unmarshalProtoMessage decodes the message b in to the struct rv. Fields present in b are merged in to rv.
+//depth is the nesting level of the message
+func unmarshalProtoMessage(b []byte, rv reflect.Value, depth int) error {
+	if depth > MaxProtobufDepth {
+		return fmt.Errorf("protobuf: messages nested deeper than %d", MaxProtobufDepth)
+	}
+	pm, err := getProtoMessage(rv.Type())
+	if err != nil {
+		return err
+	}
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errors.New("protobuf: invalid field key")
+		}
+		b = b[n:]
+		num, wt := int(key>>3), int(key&7)
+		var value []byte
+		if value, b, err = splitProtoValue(b, wt); err != nil {
+			return err
+		}
+		pf, ok := pm.byNum[num]
+		if !ok {
+			//unknown fields are skipped
+			continue
+		}
+		if err = unmarshalProtoField(value, wt, pf, rv.FieldByIndex(pf.index), depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//splitProtoValue returns the value of the wire type wt at the start of b and the remaining bytes.
+//For length delimited values the length prefix is removed
+func splitProtoValue(b []byte, wt int) ([]byte, []byte, error) {
+	switch wt {
+	case wireVarint:
+		_, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, nil, errors.New("protobuf: invalid varint")
+		}
+		return b[:n], b[n:], nil
+	case wireFixed64:
+		if len(b) < 8 {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		return b[:8], b[8:], nil
+	case wireFixed32:
+		if len(b) < 4 {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		return b[:4], b[4:], nil
+	case wireBytes:
+		l, n := binary.Uvarint(b)
+		if n <= 0 || l > uint64(len(b)-n) {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		return b[n : n+int(l)], b[n+int(l):], nil
+	case wireStartGroup:
+		return splitProtoGroup(b)
+	}
+	return nil, nil, fmt.Errorf("protobuf: unsupported wire type %d", wt)
+}
+
+//splitProtoGroup returns the fields of the deprecated group starting at b up to the matching end group key and the
+//bytes remaining after it. Groups are only skipped as no Go type is decoded from them.
+func splitProtoGroup(b []byte) ([]byte, []byte, error) {
+	rest := b
+	for depth := 1; ; {
+		key, n := binary.Uvarint(rest)
+		if n <= 0 {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		switch int(key & 7) {
+		case wireStartGroup:
+			if depth++; depth > MaxProtobufDepth {
+				return nil, nil, fmt.Errorf("protobuf: messages nested deeper than %d", MaxProtobufDepth)
+			}
+			rest = rest[n:]
+			continue
+		case wireEndGroup:
+			if depth--; depth == 0 {
+				return b[:len(b)-len(rest)], rest[n:], nil
+			}
+			rest = rest[n:]
+			continue
+		}
+		var err error
+		if _, rest, err = splitProtoValue(rest[n:], int(key&7)); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+func unmarshalProtoField(value []byte, wt int, pf *protoField, fv reflect.Value, depth int) error {
+	switch fv.Kind() {
+	case reflect.Ptr:
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return unmarshalProtoField(value, wt, pf, fv.Elem(), depth)
+	case reflect.Slice:
+		et := fv.Type().Elem()
+		if et.Kind() == reflect.Uint8 {
+			break
+		}
+		if wt == wireBytes && isPackable(et) {
+			//packed repeated scalars, unpacked values are accepted as well
+			ewt, _ := protoWireType(pf, et)
+			for len(value) > 0 {
+				var elem []byte
+				var err error
+				if elem, value, err = splitProtoValue(value, ewt); err != nil {
+					return err
+				}
+				e := reflect.New(et).Elem()
+				if err = unmarshalProtoScalar(elem, ewt, pf, e); err != nil {
+					return err
+				}
+				fv.Set(reflect.Append(fv, e))
+			}
+			return nil
+		}
+		e := reflect.New(et).Elem()
+		if err := unmarshalProtoField(value, wt, pf, e, depth); err != nil {
+			return err
+		}
+		fv.Set(reflect.Append(fv, e))
+		return nil
+	case reflect.Map:
+		if wt != wireBytes {
+			return protoWireTypeError(pf, wt)
+		}
+		if fv.IsNil() {
+			fv.Set(reflect.MakeMap(fv.Type()))
+		}
+		k := reflect.New(fv.Type().Key()).Elem()
+		v := reflect.New(fv.Type().Elem()).Elem()
+		keyField := &protoField{num: 1, name: pf.name}
+		valField := &protoField{num: 2, name: pf.name, zigzag: pf.zigzag, fixed: pf.fixed}
+		for len(value) > 0 {
+			key, n := binary.Uvarint(value)
+			if n <= 0 {
+				return errors.New("protobuf: invalid field key")
+			}
+			var entry []byte
+			var err error
+			if entry, value, err = splitProtoValue(value[n:], int(key&7)); err != nil {
+				return err
+			}
+			switch key >> 3 {
+			case 1:
+				err = unmarshalProtoField(entry, int(key&7), keyField, k, depth)
+			case 2:
+				err = unmarshalProtoField(entry, int(key&7), valField, v, depth)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		fv.SetMapIndex(k, v)
+		return nil
+	case reflect.Struct:
+		if wt != wireBytes {
+			return protoWireTypeError(pf, wt)
+		}
+		if fv.Type() == decimalType {
+			return unmarshalProtoDecimal(value, pf, fv)
+		}
+		return unmarshalProtoMessage(value, fv, depth+1)
+	}
+	expected, err := protoWireType(pf, fv.Type())
+	if err != nil {
+		return err
+	}
+	if wt != expected {
+		return protoWireTypeError(pf, wt)
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(string(value))
+		return nil
+	case reflect.Slice:
+		fv.SetBytes(append([]byte(nil), value...))
+		return nil
+	}
+	return unmarshalProtoScalar(value, wt, pf, fv)
+}
+
+//unmarshalProtoDecimal decodes the message holding the value of a Decimal as string field 1 in to fv
+func unmarshalProtoDecimal(b []byte, pf *protoField, fv reflect.Value) error {
+	d := Decimal{}
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errors.New("protobuf: invalid field key")
+		}
+		var value []byte
+		var err error
+		if value, b, err = splitProtoValue(b[n:], int(key&7)); err != nil {
+			return err
+		}
+		if key>>3 != 1 {
+			continue
+		}
+		if key&7 != wireBytes {
+			return protoWireTypeError(pf, int(key&7))
+		}
+		if d, err = ParseDecimal(string(value)); err != nil {
+			return fmt.Errorf("protobuf: invalid value of field %s: %v", pf.name, err)
+		}
+	}
+	fv.Set(reflect.ValueOf(d))
+	return nil
+}
+
+//unmarshalProtoScalar decodes the numeric or bool value of wire type wt in to fv
+func unmarshalProtoScalar(value []byte, wt int, pf *protoField, fv reflect.Value) error {
+	var raw uint64
+	switch wt {
+	case wireVarint:
+		raw, _ = binary.Uvarint(value)
+	case wireFixed32:
+		raw = uint64(binary.LittleEndian.Uint32(value))
+	case wireFixed64:
+		raw = binary.LittleEndian.Uint64(value)
+	}
+	switch fv.Kind() {
+	case reflect.Bool:
+		fv.SetBool(raw != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch {
+		case wt == wireFixed32:
+			i = int64(int32(raw))
+		case wt == wireVarint && pf.zigzag:
+			i = int64(raw>>1) ^ -int64(raw&1)
+		default:
+			i = int64(raw)
+		}
+		if fv.Kind() == reflect.Int32 && !pf.zigzag && wt == wireVarint {
+			//int32 values are sign extended to 64 bits on the wire and truncated by the reader
+			i = int64(int32(i))
+		}
+		if fv.OverflowInt(i) {
+			return fmt.Errorf("protobuf: value %d overflows field %s of type %s", i, pf.name, fv.Type())
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if fv.OverflowUint(raw) {
+			return fmt.Errorf("protobuf: value %d overflows field %s of type %s", raw, pf.name, fv.Type())
+		}
+		fv.SetUint(raw)
+	case reflect.Float32:
+		fv.SetFloat(float64(math.Float32frombits(uint32(raw))))
+	case reflect.Float64:
+		fv.SetFloat(math.Float64frombits(raw))
+	default:
+		return fmt.Errorf("protobuf: unsupported type %s of field %s", fv.Type(), pf.name)
+	}
+	return nil
+}
+
+func protoWireTypeError(pf *protoField, wt int) error {
+	return fmt.Errorf("protobuf: unexpected wire type %d for field %s", wt, pf.name)
+}
@@ -0,0 +1,172 @@
+package codec
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type protoInner struct {
+	A int32 `protobuf:"1"`
+}
+
+type protoSample struct {
+	ID       int32             `protobuf:"1"`
+	Name     string            `protobuf:"2"`
+	Inner    *protoInner       `protobuf:"3"`
+	Values   []int32           `protobuf:"4"`
+	Delta    int64             `protobuf:"5,zigzag"`
+	Checksum uint32            `protobuf:"6,fixed"`
+	Ratio    float64           `protobuf:"7"`
+	Tags     []string          `protobuf:"8"`
+	Labels   map[string]int32  `protobuf:"9"`
+	Enabled  *bool             `protobuf:"10"`
+	Raw      []byte            `protobuf:"11"`
+	Children []protoInner      `protobuf:"12"`
+	Flags    []uint32          `protobuf:"13,unpacked"`
+	Ignored  string            `protobuf:"-"`
+	Extra    map[string]string `json:"extra"`
+}
+
+func TestProtobufCodec_Encode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{name: "Varint", input: protoSample{ID: 150}, want: "089601"},
+		{name: "NegativeInt32", input: protoSample{ID: -1}, want: "08ffffffffffffffffff01"},
+		{name: "String", input: protoSample{Name: "testing"}, want: "120774657374696e67"},
+		{name: "Nested", input: protoSample{Inner: &protoInner{A: 150}}, want: "1a03089601"},
+		{name: "Packed", input: protoSample{Values: []int32{3, 270, 86942}}, want: "2206038e029ea705"},
+		{name: "ZigZag", input: protoSample{Delta: -2}, want: "2803"},
+		{name: "Fixed32", input: protoSample{Checksum: 1}, want: "3501000000"},
+		{name: "Double", input: protoSample{Ratio: 1}, want: "39000000000000f03f"},
+		{name: "Map", input: protoSample{Labels: map[string]int32{"b": 2, "a": 1}},
+			want: "4a050a01611001" + "4a050a01621002"},
+		{name: "ExplicitFalse", input: protoSample{Enabled: new(bool)}, want: "5000"},
+		{name: "Unpacked", input: protoSample{Flags: []uint32{1, 2}}, want: "68016802"},
+		{name: "Zero", input: protoSample{Ignored: "x", Extra: map[string]string{"k": "v"}}, want: ""},
+	}
+	c := NewProtobufCodec()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.EncodeToBytes(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("EncodeToBytes() = %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtobufCodec_RoundTrip(t *testing.T) {
+	enabled := true
+	in := protoSample{
+		ID:       -7,
+		Name:     "svc",
+		Inner:    &protoInner{A: 1},
+		Values:   []int32{1, -1, 300},
+		Delta:    -123456789,
+		Checksum: 0xdeadbeef,
+		Ratio:    0.25,
+		Tags:     []string{"a", ""},
+		Labels:   map[string]int32{"x": -5},
+		Enabled:  &enabled,
+		Raw:      []byte{0, 1, 2},
+		Children: []protoInner{{A: 2}, {}},
+		Flags:    []uint32{7},
+	}
+	c := NewProtobufCodec()
+	b, err := c.EncodeToBytes(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out protoSample
+	if err = c.DecodeBytes(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("DecodeBytes()\n got = %+v\nwant = %+v", out, in)
+	}
+}
+
+func TestProtobufCodec_Decode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    protoSample
+		wantErr bool
+	}{
+		//field 20 as varint, fixed64, bytes and fixed32 followed by field 1
+		{name: "UnknownFields", input: "a00101" + "a1010100000000000000" + "a2010161" + "a50101000000" + "0801",
+			want: protoSample{ID: 1}},
+		{name: "UnpackedAccepted", input: "20012002", want: protoSample{Values: []int32{1, 2}}},
+		{name: "PackedAccepted", input: "6a020102", want: protoSample{Flags: []uint32{1, 2}}},
+		{name: "NestedMerged", input: "1a0208011a00", want: protoSample{Inner: &protoInner{A: 1}}},
+		//field 20 as a group holding field 1 and the nested group 2 followed by field 1
+		{name: "GroupSkipped", input: "a301" + "0801" + "1314" + "a401" + "0801", want: protoSample{ID: 1}},
+		{name: "GroupUnterminated", input: "a3010801", wantErr: true},
+		{name: "GroupEndUnexpected", input: "a4010801", wantErr: true},
+		{name: "Truncated", input: "1207746573", wantErr: true},
+		{name: "WrongWireType", input: "0d01000000", wantErr: true},
+	}
+	c := NewProtobufCodec()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(tt.input)
+			var got protoSample
+			err := c.DecodeBytes(b, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeBytes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeBytes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+type protoTree struct {
+	Child *protoTree `protobuf:"1"`
+}
+
+func TestProtobufCodec_Depth(t *testing.T) {
+	c := NewProtobufCodec()
+	root := &protoTree{}
+	for i, node := 0, root; i < MaxProtobufDepth; i++ {
+		node.Child = &protoTree{}
+		node = node.Child
+	}
+	b, err := c.EncodeToBytes(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.DecodeBytes(b, &protoTree{}); err != nil {
+		t.Fatal(err)
+	}
+	root = &protoTree{Child: root}
+	if _, err = c.EncodeToBytes(root); err == nil {
+		t.Error("EncodeToBytes() must fail for messages nested too deep")
+	}
+	b = append(appendVarint([]byte{0x0a}, uint64(len(b))), b...)
+	if err = c.DecodeBytes(b, &protoTree{}); err == nil {
+		t.Error("DecodeBytes() must fail for messages nested too deep")
+	}
+}
+
+func TestProtobufCodec_Time(t *testing.T) {
+	type event struct {
+		At []*time.Time `protobuf:"1"`
+	}
+	c := NewProtobufCodec()
+	if _, err := c.EncodeToBytes(event{}); err == nil {
+		t.Error("EncodeToBytes() must fail for a time.Time field")
+	}
+	if err := c.DecodeBytes(nil, &event{}); err == nil {
+		t.Error("DecodeBytes() must fail for a time.Time field")
+	}
+}
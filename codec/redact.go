@@ -0,0 +1,304 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"unsafe"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//sensitiveTag marks a field holding a secret. sensitive:"true" masks the value and sensitive:"omit" drops the field
+//when encoding with the Redacted option.
+const sensitiveTag = "sensitive"
+
+const sensitiveOmit = "omit"
+
+//DefaultMask is the value written in place of a sensitive field if no mask is specified in the EncoderOptions
+const DefaultMask = "******"
+
+//sensitiveTypes caches if a type holds sensitive fields directly or in nested values
+var sensitiveTypes sync.Map
+
+//parseSensitive returns if the field tag marks the field as sensitive and if it has to be omitted
+func parseSensitive(tag reflect.StructTag) (bool, bool) {
+	switch tag.Get(sensitiveTag) {
+	case textutils.EmptyStr, "false":
+		return false, false
+	case sensitiveOmit:
+		return true, true
+	}
+	return true, false
+}
+
+//HasSensitiveFields function checks if values of the type t may hold fields tagged as sensitive. A type that holds
+//interfaces may hold sensitive fields in their dynamic values, so true is returned for it.
+func HasSensitiveFields(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if cached, ok := sensitiveTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	has := hasSensitiveFields(t, make(map[reflect.Type]bool))
+	sensitiveTypes.Store(t, has)
+	return has
+}
+
+//hasSensitiveFields walks the type t. visiting holds the types being walked to stop the recursion on self
+//referencing types
+func hasSensitiveFields(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if cached, ok := sensitiveTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasSensitiveFields(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sensitive, _ := parseSensitive(sf.Tag); sensitive || hasSensitiveFields(sf.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//Redact function returns a copy of v in which the sensitive string fields hold DefaultMask and the other sensitive
+//fields, as well as the fields tagged with sensitive:"omit", hold their zero value. Unexported fields are redacted
+//too. v is returned as is if it has no sensitive fields, so it is cheap to call for any value before it is logged or
+//formatted. v is never modified.
+func Redact(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !HasSensitiveFields(rv.Type()) {
+		return v
+	}
+	return redactValue(rv, DefaultMask, make(map[pointerKey]reflect.Value)).Interface()
+}
+
+//pointerKey identifies a value reached through a pointer while walking a value that may hold cycles
+type pointerKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+//redactValue returns a deep copy of rv with the sensitive fields masked. Only the values holding sensitive fields are
+//copied, the rest are shared with rv. The dynamic values of interfaces are inspected. copies holds the copies of the
+//pointers already walked so that cyclic values are copied with their cycles.
+func redactValue(rv reflect.Value, mask string, copies map[pointerKey]reflect.Value) reflect.Value {
+	t := rv.Type()
+	if !HasSensitiveFields(t) {
+		return rv
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		if rv.IsNil() {
+			return rv
+		}
+		cp := reflect.New(t).Elem()
+		cp.Set(redactValue(rv.Elem(), mask, copies))
+		return cp
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return rv
+		}
+		key := pointerKey{ptr: rv.Pointer(), typ: t}
+		if cp, ok := copies[key]; ok {
+			return cp
+		}
+		cp := reflect.New(t.Elem())
+		copies[key] = cp
+		cp.Elem().Set(redactValue(rv.Elem(), mask, copies))
+		return cp
+	case reflect.Struct:
+		cp := reflect.New(t).Elem()
+		cp.Set(rv)
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			fv := cp.Field(i)
+			if !fv.CanSet() {
+				if !HasSensitiveFields(sf.Type) {
+					continue
+				}
+				//unexported fields are set through their address in the copy so that secrets they carry are masked
+				fv = reflect.NewAt(sf.Type, unsafe.Pointer(fv.UnsafeAddr())).Elem()
+			}
+			if sensitive, omit := parseSensitive(sf.Tag); omit {
+				fv.Set(reflect.Zero(fv.Type()))
+			} else if sensitive {
+				maskValue(fv, mask)
+			} else {
+				fv.Set(redactValue(fv, mask, copies))
+			}
+		}
+		return cp
+	case reflect.Slice:
+		if rv.IsNil() {
+			return rv
+		}
+		cp := reflect.MakeSlice(t, rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			cp.Index(i).Set(redactValue(rv.Index(i), mask, copies))
+		}
+		return cp
+	case reflect.Array:
+		cp := reflect.New(t).Elem()
+		for i := 0; i < rv.Len(); i++ {
+			cp.Index(i).Set(redactValue(rv.Index(i), mask, copies))
+		}
+		return cp
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv
+		}
+		cp := reflect.MakeMapWithSize(t, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), redactValue(iter.Value(), mask, copies))
+		}
+		return cp
+	}
+	return rv
+}
+
+//maskValue replaces the value of the sensitive field fv with the mask if it is a string and the zero value otherwise
+func maskValue(fv reflect.Value, mask string) {
+	switch {
+	case fv.Kind() == reflect.String:
+		fv.SetString(mask)
+	case fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.String && !fv.IsNil():
+		p := reflect.New(fv.Type().Elem())
+		p.Elem().SetString(mask)
+		fv.Set(p)
+	default:
+		fv.Set(reflect.Zero(fv.Type()))
+	}
+}
+
+//redactJSON rewrites the JSON b encoded from the value rv replacing the values of the sensitive fields with the mask,
+//or removing them if they are tagged with sensitive:"omit". The JSON is walked along rv so that the fields of the
+//dynamic values of interfaces are found. The order of the keys is preserved.
+func redactJSON(b []byte, rv reflect.Value, mask string) ([]byte, error) {
+	if !rv.IsValid() || !HasSensitiveFields(rv.Type()) {
+		return b, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	out := make([]byte, 0, len(b))
+	return redactJSONValue(dec, rv, rv.Type(), mask, out)
+}
+
+//redactJSONValue redacts the next JSON value of dec encoded from rv of the type t. rv is invalid if the value is not
+//known, e.g. for the keys of a map that are not strings, and the type is used alone.
+func redactJSONValue(dec *json.Decoder, rv reflect.Value, t reflect.Type, mask string, out []byte) ([]byte, error) {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface) {
+		if !rv.IsValid() {
+			if t.Kind() == reflect.Interface {
+				t = nil
+			} else {
+				t = t.Elem()
+			}
+			continue
+		}
+		if rv.IsNil() {
+			rv, t = reflect.Value{}, nil
+			continue
+		}
+		rv = rv.Elem()
+		t = rv.Type()
+	}
+	if t == nil || !HasSensitiveFields(t) {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return out, err
+		}
+		return append(out, raw...), nil
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return out, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		out = append(out, '{')
+		first := true
+		for dec.More() {
+			if tok, err = dec.Token(); err != nil {
+				return out, err
+			}
+			key, _ := tok.(string)
+			var vt reflect.Type
+			var vv reflect.Value
+			var fm *FieldMeta
+			switch t.Kind() {
+			case reflect.Map:
+				vt = t.Elem()
+				if rv.IsValid() && t.Key().Kind() == reflect.String {
+					vv = rv.MapIndex(reflect.ValueOf(key).Convert(t.Key()))
+				}
+			case reflect.Struct:
+				if fm = LookupField(t, JSONTarget, key); fm != nil {
+					vt = fm.Type
+					if rv.IsValid() {
+						vv, _ = fieldByIndex(rv, fm.Index)
+					}
+				}
+			}
+			if fm != nil && fm.Sensitive {
+				var skip json.RawMessage
+				if err = dec.Decode(&skip); err != nil {
+					return out, err
+				}
+				if fm.OmitSensitive {
+					continue
+				}
+			}
+			if !first {
+				out = append(out, ',')
+			}
+			first = false
+			out = AppendJSONString(out, key)
+			out = append(out, ':')
+			if fm != nil && fm.Sensitive {
+				out = AppendJSONString(out, mask)
+			} else if out, err = redactJSONValue(dec, vv, vt, mask, out); err != nil {
+				return out, err
+			}
+		}
+		_, err = dec.Token()
+		return append(out, '}'), err
+	case json.Delim('['):
+		out = append(out, '[')
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				out = append(out, ',')
+			}
+			var et reflect.Type
+			var ev reflect.Value
+			if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+				et = t.Elem()
+				if rv.IsValid() && i < rv.Len() {
+					ev = rv.Index(i)
+				}
+			}
+			if out, err = redactJSONValue(dec, ev, et, mask, out); err != nil {
+				return out, err
+			}
+		}
+		_, err = dec.Token()
+		return append(out, ']'), err
+	}
+	//a null pointer or an empty value
+	raw, err := json.Marshal(tok)
+	return append(out, raw...), err
+}
@@ -0,0 +1,178 @@
+package codec
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type redactCredentials struct {
+	User     string  `json:"user"`
+	Password string  `json:"password" sensitive:"true"`
+	Token    *string `json:"token,omitempty" sensitive:"true"`
+	PIN      int     `json:"pin" sensitive:"true"`
+	Secret   string  `json:"secret" sensitive:"omit"`
+}
+
+type redactAccount struct {
+	ID          int                          `json:"id"`
+	Credentials *redactCredentials           `json:"credentials"`
+	History     []redactCredentials          `json:"history"`
+	ByRegion    map[string]redactCredentials `json:"byRegion"`
+}
+
+func sampleAccount() redactAccount {
+	token := "tkn"
+	return redactAccount{
+		ID:          7,
+		Credentials: &redactCredentials{User: "alice", Password: "p@ss", Token: &token, PIN: 1234, Secret: "s"},
+		History:     []redactCredentials{{User: "old", Password: "old-pass"}},
+		ByRegion:    map[string]redactCredentials{"eu": {User: "eu", Password: "eu-pass"}},
+	}
+}
+
+func TestJSONCodec_Redacted(t *testing.T) {
+	account := sampleAccount()
+	tests := []struct {
+		name    string
+		options *EncoderOptions
+		want    string
+	}{
+		{
+			name:    "Wire",
+			options: &EncoderOptions{},
+			want: `{"id":7,"credentials":{"user":"alice","password":"p@ss","token":"tkn","pin":1234,"secret":"s"},` +
+				`"history":[{"user":"old","password":"old-pass","pin":0,"secret":""}],` +
+				`"byRegion":{"eu":{"user":"eu","password":"eu-pass","pin":0,"secret":""}}}`,
+		},
+		{
+			name:    "Redacted",
+			options: &EncoderOptions{Redacted: true},
+			want: `{"id":7,"credentials":{"user":"alice","password":"******","token":"******","pin":"******"},` +
+				`"history":[{"user":"old","password":"******","pin":"******"}],` +
+				`"byRegion":{"eu":{"user":"eu","password":"******","pin":"******"}}}`,
+		},
+		{
+			name:    "CustomMask",
+			options: &EncoderOptions{Redacted: true, Mask: "x", Canonical: true},
+			want: `{"byRegion":{"eu":{"password":"x","pin":"x","user":"eu"}},` +
+				`"credentials":{"password":"x","pin":"x","token":"x","user":"alice"},` +
+				`"history":[{"password":"x","pin":"x","user":"old"}],"id":7}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewJSONCodec(nil).WithEncoderOptions(tt.options).EncodeToString(&account)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("EncodeToString()\n got = %s\nwant = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	account := sampleAccount()
+	redacted := Redact(account).(redactAccount)
+	got := fmt.Sprintf("%+v %+v %+v", *redacted.Credentials, redacted.History, redacted.ByRegion)
+	want := "{User:alice Password:****** Token:" + fmt.Sprint(redacted.Credentials.Token) +
+		" PIN:0 Secret:} [{User:old Password:****** Token:<nil> PIN:0 Secret:}]" +
+		" map[eu:{User:eu Password:****** Token:<nil> PIN:0 Secret:}]"
+	if got != want {
+		t.Errorf("Redact()\n got = %s\nwant = %s", got, want)
+	}
+	if *redacted.Credentials.Token != DefaultMask {
+		t.Errorf("Redact() token = %s, want %s", *redacted.Credentials.Token, DefaultMask)
+	}
+	//the original value must not be modified
+	if account.Credentials.Password != "p@ss" || *account.Credentials.Token != "tkn" ||
+		account.History[0].Password != "old-pass" || account.ByRegion["eu"].Password != "eu-pass" {
+		t.Errorf("Redact() modified the original value %+v", account)
+	}
+	if v := Redact("plain"); v != "plain" {
+		t.Errorf("Redact() = %v, want plain", v)
+	}
+}
+
+type redactEnvelope struct {
+	Payload interface{}            `json:"payload"`
+	Items   []interface{}          `json:"items"`
+	Extra   map[string]interface{} `json:"extra,omitempty"`
+}
+
+type redactNode struct {
+	Value interface{} `json:"value"`
+	Next  *redactNode `json:"-"`
+}
+
+func TestRedact_Interfaces(t *testing.T) {
+	v := redactEnvelope{
+		Payload: redactCredentials{User: "u", Password: "hunter2"},
+		Items:   []interface{}{&redactCredentials{Password: "item-pass"}, 1},
+		Extra:   map[string]interface{}{"nested": map[string]interface{}{"c": redactCredentials{Password: "map-pass"}}},
+	}
+	b, err := NewJSONCodec(nil).WithEncoderOptions(&EncoderOptions{Redacted: true}).EncodeToBytes(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"payload":{"user":"u","password":"******","pin":"******"},"items":[{"user":"","password":"******",` +
+		`"pin":"******"},1],"extra":{"nested":{"c":{"user":"","password":"******","pin":"******"}}}}`
+	if string(b) != want {
+		t.Errorf("EncodeToBytes()\n got = %s\nwant = %s", b, want)
+	}
+	for _, s := range []string{fmt.Sprintf("%+v", Redact(v)), fmt.Sprintf("%+v", Redact(&v)),
+		fmt.Sprint(Redact(interface{}(Changes{{Path: "p", Old: v.Payload}})))} {
+		for _, secret := range []string{"hunter2", "item-pass", "map-pass"} {
+			if strings.Contains(s, secret) {
+				t.Errorf("Redact() = %s, leaks %s", s, secret)
+			}
+		}
+	}
+	if v.Payload.(redactCredentials).Password != "hunter2" {
+		t.Error("Redact() modified the value")
+	}
+	//a cyclic value is copied with its cycle
+	n := &redactNode{Value: redactCredentials{Password: "cycle-pass"}}
+	n.Next = n
+	r := Redact(n).(*redactNode)
+	if r.Next != r || r.Value.(redactCredentials).Password != DefaultMask {
+		t.Errorf("Redact() cyclic = %+v", r)
+	}
+}
+
+type redactSecret struct {
+	Password string `sensitive:"true"`
+}
+
+type redactEmbedded struct {
+	redactSecret
+	User string
+}
+
+type redactNamed struct {
+	c    redactSecret
+	list []redactSecret
+}
+
+func TestRedact_Unexported(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{name: "Embedded", v: redactEmbedded{redactSecret{Password: "hunter2"}, "u"}},
+		{name: "Named", v: redactNamed{c: redactSecret{Password: "hunter2"}, list: []redactSecret{{"hunter2"}}}},
+		{name: "Pointer", v: &redactNamed{c: redactSecret{Password: "hunter2"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s := fmt.Sprintf("%+v", Redact(tt.v)); strings.Contains(s, "hunter2") {
+				t.Errorf("Redact() = %s, leaks the password", s)
+			}
+			if s := fmt.Sprintf("%+v", tt.v); !strings.Contains(s, "hunter2") {
+				t.Errorf("Redact() modified the value %s", s)
+			}
+		})
+	}
+}
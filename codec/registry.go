@@ -0,0 +1,70 @@
+package codec
+
+import (
+	"mime"
+	"strings"
+	"sync"
+)
+
+const (
+	//MimeJSON is the media type of the JSONCodec
+	MimeJSON = "application/json"
+	//MimeProtobuf is the media type of the ProtobufCodec
+	MimeProtobuf = "application/x-protobuf"
+)
+
+//registry holds the codecs registered by media type
+var registry = struct {
+	sync.RWMutex
+	codecs map[string]Codec
+	//order holds the media types in the order of registration used to resolve wildcards
+	order []string
+}{
+	codecs: make(map[string]Codec),
+}
+
+func init() {
+	RegisterCodec(MimeJSON, NewJSONCodec(nil))
+	RegisterCodec(MimeProtobuf, NewProtobufCodec())
+	RegisterCodec("application/protobuf", NewProtobufCodec())
+	RegisterCodec(MimeForm, NewFormCodec(nil))
+}
+
+//RegisterCodec function registers the Codec c for the media type. A codec registered earlier for the same media type
+//is replaced. The media type parameters are ignored and the match is case insensitive.
+func RegisterCodec(mediaType string, c Codec) {
+	mediaType = normalizeMediaType(mediaType)
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.codecs[mediaType]; !ok {
+		registry.order = append(registry.order, mediaType)
+	}
+	registry.codecs[mediaType] = c
+}
+
+//GetCodec function returns the Codec registered for the media type of contentType, e.g. "application/json;
+//charset=utf-8". false is returned if no codec is registered for it.
+func GetCodec(contentType string) (Codec, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	c, ok := registry.codecs[normalizeMediaType(contentType)]
+	return c, ok
+}
+
+//MediaTypes function returns the media types with a registered codec in the order of registration
+func MediaTypes() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	return append([]string(nil), registry.order...)
+}
+
+//normalizeMediaType returns the lower case media type of contentType without parameters
+func normalizeMediaType(contentType string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mt
+	}
+	if idx := strings.IndexByte(contentType, ';'); idx != -1 {
+		contentType = contentType[:idx]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
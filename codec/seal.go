@@ -0,0 +1,210 @@
+package codec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"go.codemanch.com/commons/textutils"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+//AEADAlgo identifies the authenticated encryption algorithm used by a sealed codec. The value is stored in the header
+//of the sealed content. AESGCM and ChaCha20Poly1305 are built in and other algorithms can be added with RegisterAEAD
+//under their own value.
+type AEADAlgo byte
+
+const (
+	//AESGCM is AES in Galois/Counter Mode. The key length selects AES-128, AES-192 or AES-256
+	AESGCM AEADAlgo = 1
+	//ChaCha20Poly1305 is ChaCha20-Poly1305 as defined in RFC 8439. The key must be 32 bytes long
+	ChaCha20Poly1305 AEADAlgo = 2
+)
+
+//sealVersion is the version of the header format of the sealed content
+const sealVersion byte = 1
+
+//AEADFactory creates a cipher.AEAD for a key
+type AEADFactory func(key []byte) (cipher.AEAD, error)
+
+var aeadFactories = map[AEADAlgo]AEADFactory{
+	AESGCM:           newAESGCM,
+	ChaCha20Poly1305: chacha20poly1305.New,
+}
+
+var aeadMutex = &sync.RWMutex{}
+
+//RegisterAEAD function registers the factory for the algorithm. An existing registration is replaced.
+func RegisterAEAD(algo AEADAlgo, f AEADFactory) {
+	aeadMutex.Lock()
+	defer aeadMutex.Unlock()
+	aeadFactories[algo] = f
+}
+
+//newAEAD returns the cipher.AEAD of the algorithm algo for the key
+func newAEAD(algo AEADAlgo, key []byte) (cipher.AEAD, error) {
+	aeadMutex.RLock()
+	f, ok := aeadFactories[algo]
+	aeadMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("seal: no implementation registered for algorithm %d", algo)
+	}
+	return f(key)
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+//KeyProvider interface supplies the keys of a sealed codec. Every key is identified by an id which is stored in the
+//header of the sealed content so that the content can be opened after the current key is rotated.
+type KeyProvider interface {
+	//CurrentKey returns the id and the key to be used for sealing
+	CurrentKey() (string, []byte, error)
+	//Key returns the key identified by id to be used for opening
+	Key(id string) ([]byte, error)
+}
+
+//StaticKeyProvider struct is a KeyProvider backed by a fixed set of keys
+type StaticKeyProvider struct {
+	currentID string
+	keys      map[string][]byte
+}
+
+//NewStaticKeyProvider function creates a StaticKeyProvider that seals with the key identified by currentID.
+//All the keys are available for opening
+func NewStaticKeyProvider(currentID string, keys map[string][]byte) *StaticKeyProvider {
+	return &StaticKeyProvider{
+		currentID: currentID,
+		keys:      keys,
+	}
+}
+
+//CurrentKey returns the id and the key to be used for sealing
+func (s *StaticKeyProvider) CurrentKey() (string, []byte, error) {
+	key, err := s.Key(s.currentID)
+	return s.currentID, key, err
+}
+
+//Key returns the key identified by id
+func (s *StaticKeyProvider) Key(id string) ([]byte, error) {
+	if key, ok := s.keys[id]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("seal: unknown key id %q", id)
+}
+
+//sealedCodec wraps a Codec encrypting and authenticating the encoded output
+type sealedCodec struct {
+	codec Codec
+	algo  AEADAlgo
+	keys  KeyProvider
+}
+
+//Sealed function wraps the Codec c so that the output of Write is sealed using algo with the current key of kp.
+//The sealed content has the header
+//	version(1 byte) | algo(1 byte) | key id length(1 byte) | key id | nonce
+//followed by the ciphertext. The header is authenticated as additional data. Read uses the algorithm and the key
+//identified by the header, so content sealed with an earlier key can be opened as long as kp still provides it.
+func Sealed(c Codec, algo AEADAlgo, kp KeyProvider) Codec {
+	return &sealedCodec{
+		codec: c,
+		algo:  algo,
+		keys:  kp,
+	}
+}
+
+func (sc *sealedCodec) EncodeToString(v interface{}) (string, error) {
+	b, err := sc.EncodeToBytes(v)
+	if err != nil {
+		return textutils.EmptyStr, err
+	}
+	return string(b), nil
+}
+
+func (sc *sealedCodec) EncodeToBytes(v interface{}) ([]byte, error) {
+	plain, err := sc.codec.EncodeToBytes(v)
+	if err != nil {
+		return nil, err
+	}
+	id, key, err := sc.keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if len(id) > 255 {
+		return nil, errors.New("seal: key id must not be longer than 255 bytes")
+	}
+	aead, err := newAEAD(sc.algo, key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, 3+len(id)+aead.NonceSize())
+	header = append(header, sealVersion, byte(sc.algo), byte(len(id)))
+	header = append(header, id...)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	header = append(header, nonce...)
+	return aead.Seal(header, nonce, plain, header), nil
+}
+
+//Write seals the encoded value v and writes it to w
+func (sc *sealedCodec) Write(v interface{}, w io.Writer) error {
+	b, err := sc.EncodeToBytes(v)
+	if err == nil {
+		_, err = w.Write(b)
+	}
+	return err
+}
+
+func (sc *sealedCodec) DecodeString(s string, v interface{}) error {
+	return sc.DecodeBytes([]byte(s), v)
+}
+
+func (sc *sealedCodec) DecodeBytes(b []byte, v interface{}) error {
+	if len(b) < 3 || b[0] != sealVersion {
+		return errors.New("seal: invalid or unsupported header")
+	}
+	algo := AEADAlgo(b[1])
+	idEnd := 3 + int(b[2])
+	if len(b) < idEnd {
+		return errors.New("seal: truncated header")
+	}
+	key, err := sc.keys.Key(string(b[3:idEnd]))
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(algo, key)
+	if err != nil {
+		return err
+	}
+	nonceEnd := idEnd + aead.NonceSize()
+	if len(b) < nonceEnd {
+		return errors.New("seal: truncated header")
+	}
+	plain, err := aead.Open(nil, b[idEnd:nonceEnd], b[nonceEnd:], b[:nonceEnd])
+	if err != nil {
+		return err
+	}
+	return sc.codec.DecodeBytes(plain, v)
+}
+
+//Read reads the sealed content from r, opens it and decodes it using the wrapped codec
+//This function does not close the reader and it is the responsibility of the caller to close the reader
+func (sc *sealedCodec) Read(r io.Reader, v interface{}) error {
+	b, err := ioutil.ReadAll(r)
+	if err == nil {
+		err = sc.DecodeBytes(b, v)
+	}
+	return err
+}
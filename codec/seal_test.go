@@ -0,0 +1,65 @@
+package codec
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSealed(t *testing.T) {
+	keys := map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 16),
+	}
+	in := testServer{Host: "db.internal", Port: 5432}
+	old, err := Sealed(NewJSONCodec(nil), AESGCM, NewStaticKeyProvider("k1", keys)).EncodeToBytes(in)
+	if err != nil {
+		t.Fatalf("EncodeToBytes() error = %v", err)
+	}
+	if bytes.Contains(old, []byte("db.internal")) {
+		t.Errorf("EncodeToBytes() output contains the plain text")
+	}
+	//After rotation content sealed with k1 must still be readable
+	rotated := Sealed(NewJSONCodec(nil), AESGCM, NewStaticKeyProvider("k2", keys))
+	var got testServer
+	if err = rotated.DecodeBytes(old, &got); err != nil || !reflect.DeepEqual(got, in) {
+		t.Errorf("DecodeBytes() = %v, %v", got, err)
+	}
+	//Tampering with the sealed content must be detected
+	tampered := append([]byte{}, old...)
+	tampered[len(tampered)-1] ^= 0xff
+	if err = rotated.DecodeBytes(tampered, &got); err == nil {
+		t.Errorf("DecodeBytes() of tampered content succeeded")
+	}
+	if err = Sealed(NewJSONCodec(nil), AESGCM, NewStaticKeyProvider("k2", nil)).DecodeBytes(old, &got); err == nil {
+		t.Errorf("DecodeBytes() with unknown key id succeeded")
+	}
+	if _, err = Sealed(NewJSONCodec(nil), AEADAlgo(0), NewStaticKeyProvider("k1", keys)).EncodeToBytes(in); err == nil {
+		t.Errorf("EncodeToBytes() with unregistered algorithm succeeded")
+	}
+}
+
+func TestSealed_Algorithms(t *testing.T) {
+	keys := map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}
+	in := testServer{Host: "db.internal", Port: 5432}
+	aesCodec := Sealed(NewJSONCodec(nil), AESGCM, NewStaticKeyProvider("k1", keys))
+	chachaCodec := Sealed(NewJSONCodec(nil), ChaCha20Poly1305, NewStaticKeyProvider("k1", keys))
+	for _, sealer := range []Codec{aesCodec, chachaCodec} {
+		sealed, err := sealer.EncodeToBytes(in)
+		if err != nil {
+			t.Fatalf("EncodeToBytes() error = %v", err)
+		}
+		//The algorithm is read from the header, so either codec opens the content
+		for _, opener := range []Codec{aesCodec, chachaCodec} {
+			var got testServer
+			if err = opener.DecodeBytes(sealed, &got); err != nil || !reflect.DeepEqual(got, in) {
+				t.Errorf("DecodeBytes() = %v, %v", got, err)
+			}
+		}
+	}
+	short := map[string][]byte{"k1": bytes.Repeat([]byte{1}, 16)}
+	shortCodec := Sealed(NewJSONCodec(nil), ChaCha20Poly1305, NewStaticKeyProvider("k1", short))
+	if _, err := shortCodec.EncodeToBytes(in); err == nil {
+		t.Errorf("EncodeToBytes() with a 16 byte ChaCha20-Poly1305 key succeeded")
+	}
+}
@@ -0,0 +1,667 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//Formats of the time.Time and time.Duration fields selected using the format struct tag, e.g. `format:"unix"`.
+//Any other format of a time.Time field is used as a Go layout, e.g. `format:"2006-01-02"`. Fields without a format
+//are encoded as done by encoding/json, i.e. times as RFC 3339 strings and durations as nanoseconds.
+//The format is read from StringFieldMeta.Format by the JSON and form codecs and by config.Bind, which decodes the
+//properties, YAML, environment and argument sources. This package has no YAML, CSV or properties encoder, so values
+//are not written in these encodings.
+const (
+	//FormatRFC3339 encodes a time as an RFC 3339 string without fractional seconds
+	FormatRFC3339 = "rfc3339"
+	//FormatRFC3339Nano encodes a time as an RFC 3339 string with fractional seconds
+	FormatRFC3339Nano = "rfc3339nano"
+	//FormatUnix encodes a time as the number of seconds since the Unix epoch
+	FormatUnix = "unix"
+	//FormatUnixMilli encodes a time as the number of milliseconds since the Unix epoch
+	FormatUnixMilli = "unixmilli"
+	//FormatUnixNano encodes a time as the number of nanoseconds since the Unix epoch
+	FormatUnixNano = "unixnano"
+	//FormatISO8601 encodes a time as done by FormatRFC3339Nano and a duration as an ISO 8601 duration, e.g. PT1H30M
+	FormatISO8601 = "iso8601"
+	//FormatGoDuration encodes a duration as done by time.Duration.String, e.g. 1h30m0s
+	FormatGoDuration = "go"
+	//FormatSeconds encodes a duration as a number of seconds with fractional digits, e.g. 1.5
+	FormatSeconds = "seconds"
+	//FormatMillis encodes a duration as a number of milliseconds
+	FormatMillis = "millis"
+	//FormatNanos encodes a duration as a number of nanoseconds
+	FormatNanos = "nanos"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	//formattedTypes caches if a type holds time or duration fields with a format directly or in nested values
+	formattedTypes sync.Map
+)
+
+//FormatTime function formats t using the format. RFC 3339 with fractional seconds is used if format is empty
+func FormatTime(t time.Time, format string) string {
+	switch format {
+	case textutils.EmptyStr, FormatRFC3339Nano, FormatISO8601:
+		return t.Format(time.RFC3339Nano)
+	case FormatRFC3339:
+		return t.Format(time.RFC3339)
+	case FormatUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case FormatUnixMilli:
+		return strconv.FormatInt(t.Unix()*1e3+int64(t.Nanosecond())/1e6, 10)
+	case FormatUnixNano:
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+	return t.Format(format)
+}
+
+//ParseTime function parses s using the format. Times parsed from Unix formats are in UTC.
+//RFC 3339 with or without fractional seconds is accepted if format is empty
+func ParseTime(s, format string) (time.Time, error) {
+	switch format {
+	case textutils.EmptyStr, FormatRFC3339, FormatRFC3339Nano, FormatISO8601:
+		return time.Parse(time.RFC3339Nano, s)
+	case FormatUnix:
+		return parseUnixSeconds(s)
+	case FormatUnixMilli, FormatUnixNano:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid unix time %q", s)
+		}
+		if format == FormatUnixMilli {
+			return time.Unix(n/1e3, n%1e3*1e6).UTC(), nil
+		}
+		return time.Unix(0, n).UTC(), nil
+	}
+	return time.Parse(format, s)
+}
+
+//parseUnixSeconds parses the number of seconds since the Unix epoch s, e.g. 1622505600.5, without going through a
+//time.Duration so that the whole range of time.Time is supported
+func parseUnixSeconds(s string) (time.Time, error) {
+	digits := strings.TrimPrefix(s, textutils.HyphenStr)
+	intPart, fracPart := digits, textutils.EmptyStr
+	if idx := strings.IndexAny(digits, ".,"); idx != -1 {
+		intPart, fracPart = digits[:idx], digits[idx+1:]
+	}
+	if intPart == textutils.EmptyStr && fracPart == textutils.EmptyStr || !isDigits(intPart) || !isDigits(fracPart) {
+		return time.Time{}, fmt.Errorf("invalid unix time %q", s)
+	}
+	var sec, nsec int64
+	if intPart != textutils.EmptyStr {
+		var err error
+		if sec, err = strconv.ParseInt(intPart, 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("invalid unix time %q", s)
+		}
+	}
+	if fracPart != textutils.EmptyStr {
+		//digits beyond nanoseconds are dropped
+		fracPart = (fracPart + "000000000")[:9]
+		nsec, _ = strconv.ParseInt(fracPart, 10, 64)
+	}
+	if len(digits) < len(s) {
+		sec, nsec = -sec, -nsec
+	}
+	return time.Unix(sec, nsec).UTC(), nil
+}
+
+//FormatDuration function formats d using the format. The number of nanoseconds is returned if format is empty
+func FormatDuration(d time.Duration, format string) (string, error) {
+	switch format {
+	case textutils.EmptyStr, FormatNanos:
+		return strconv.FormatInt(int64(d), 10), nil
+	case FormatISO8601:
+		return formatISO8601Duration(d), nil
+	case FormatGoDuration:
+		return d.String(), nil
+	case FormatSeconds:
+		s := strconv.FormatInt(int64(d/time.Second), 10)
+		if frac := d % time.Second; frac != 0 {
+			if frac < 0 {
+				frac = -frac
+				if d > -time.Second {
+					s = textutils.HyphenStr + s
+				}
+			}
+			s += textutils.PeriodStr + strings.TrimRight(fmt.Sprintf("%09d", int64(frac)), "0")
+		}
+		return s, nil
+	case FormatMillis:
+		return strconv.FormatInt(int64(d/time.Millisecond), 10), nil
+	}
+	return textutils.EmptyStr, fmt.Errorf("unsupported duration format %q", format)
+}
+
+//ParseDuration function parses s using the format. If format is empty a number of nanoseconds, a Go duration, e.g.
+//1h30m, or an ISO 8601 duration, e.g. PT1H30M, is accepted
+func ParseDuration(s, format string) (time.Duration, error) {
+	switch format {
+	case textutils.EmptyStr:
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Duration(n), nil
+		}
+		if d, err := time.ParseDuration(s); err == nil {
+			return d, nil
+		}
+		return parseISO8601Duration(s)
+	case FormatNanos:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return time.Duration(n), err
+	case FormatISO8601:
+		return parseISO8601Duration(s)
+	case FormatGoDuration:
+		return time.ParseDuration(s)
+	case FormatSeconds:
+		return parseScaled(s, time.Second)
+	case FormatMillis:
+		return parseScaled(s, time.Millisecond)
+	}
+	return 0, fmt.Errorf("unsupported duration format %q", format)
+}
+
+//checkTimeFormat checks that the format can be used for values of the type t, which is time.Time or time.Duration
+func checkTimeFormat(t reflect.Type, format string) error {
+	if format == textutils.EmptyStr {
+		return nil
+	}
+	if t == durationType {
+		_, err := FormatDuration(0, format)
+		return err
+	}
+	switch format {
+	case FormatRFC3339, FormatRFC3339Nano, FormatUnix, FormatUnixMilli, FormatUnixNano, FormatISO8601:
+		return nil
+	}
+	//a layout without any of the reference values is most likely a misspelled format name
+	if !strings.ContainsAny(format, "0123456789") {
+		return fmt.Errorf("unsupported time format %q", format)
+	}
+	return nil
+}
+
+//isNumericFormat checks if values of the type t formatted with the format are numbers
+func isNumericFormat(t reflect.Type, format string) bool {
+	switch format {
+	case FormatUnix, FormatUnixMilli, FormatUnixNano, FormatSeconds, FormatMillis, FormatNanos:
+		return true
+	}
+	return t == durationType && format == textutils.EmptyStr
+}
+
+//isTimeType checks if t is time.Time or time.Duration
+func isTimeType(t reflect.Type) bool {
+	return t == timeType || t == durationType
+}
+
+//formatTimeValue formats rv which holds a time.Time or a time.Duration
+func formatTimeValue(rv reflect.Value, format string) (string, error) {
+	if rv.Type() == timeType {
+		return FormatTime(rv.Interface().(time.Time), format), nil
+	}
+	return FormatDuration(time.Duration(rv.Int()), format)
+}
+
+//setTimeValue parses s in to rv which holds a time.Time or a time.Duration
+func setTimeValue(rv reflect.Value, s, format string) error {
+	if rv.Type() == timeType {
+		t, err := ParseTime(s, format)
+		if err == nil {
+			rv.Set(reflect.ValueOf(t))
+		}
+		return err
+	}
+	d, err := ParseDuration(s, format)
+	if err == nil {
+		rv.SetInt(int64(d))
+	}
+	return err
+}
+
+//setFormatted parses s in to rv using the format if rv holds a time.Time or a time.Duration, else SetFromString is
+//used. Nil pointers are allocated.
+func setFormatted(rv reflect.Value, s, format string) error {
+	if format == textutils.EmptyStr {
+		return SetFromString(rv, s)
+	}
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	if isTimeType(rv.Type()) {
+		return setTimeValue(rv, s, format)
+	}
+	return SetFromString(rv, s)
+}
+
+//parseScaled parses the decimal number s, e.g. -1.5, and returns it multiplied by unit
+func parseScaled(s string, unit time.Duration) (time.Duration, error) {
+	neg := strings.HasPrefix(s, textutils.HyphenStr)
+	digits := strings.TrimPrefix(s, textutils.HyphenStr)
+	intPart, fracPart := digits, textutils.EmptyStr
+	if idx := strings.IndexAny(digits, ".,"); idx != -1 {
+		intPart, fracPart = digits[:idx], digits[idx+1:]
+	}
+	if intPart == textutils.EmptyStr && fracPart == textutils.EmptyStr || !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	var n int64
+	if intPart != textutils.EmptyStr {
+		var err error
+		if n, err = strconv.ParseInt(intPart, 10, 64); err != nil || n > math.MaxInt64/int64(unit) {
+			return 0, fmt.Errorf("duration %q out of range", s)
+		}
+	}
+	d := time.Duration(n) * unit
+	if fracPart != textutils.EmptyStr {
+		f, _ := strconv.ParseFloat("0."+fracPart, 64)
+		d += time.Duration(math.Round(f * float64(unit)))
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+//parseISO8601Duration parses an ISO 8601 duration, e.g. P1DT2H30M or -PT0.5S. Years and months are rejected as they do
+//not have a fixed length, days are 24 hours long.
+func parseISO8601Duration(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("invalid ISO 8601 duration %q", s)
+	rest := s
+	neg := strings.HasPrefix(rest, textutils.HyphenStr)
+	if neg || strings.HasPrefix(rest, "+") {
+		rest = rest[1:]
+	}
+	if len(rest) < 2 || rest[0] != 'P' {
+		return 0, invalid
+	}
+	rest = rest[1:]
+	var total time.Duration
+	inTime := false
+	for len(rest) > 0 {
+		if rest[0] == 'T' {
+			if inTime || len(rest) == 1 {
+				return 0, invalid
+			}
+			inTime = true
+			rest = rest[1:]
+			continue
+		}
+		i := 0
+		for i < len(rest) && (rest[i] >= '0' && rest[i] <= '9' || rest[i] == '.' || rest[i] == ',') {
+			i++
+		}
+		if i == 0 || i == len(rest) {
+			return 0, invalid
+		}
+		var unit time.Duration
+		switch u := rest[i]; {
+		case !inTime && u == 'W':
+			unit = 7 * 24 * time.Hour
+		case !inTime && u == 'D':
+			unit = 24 * time.Hour
+		case inTime && u == 'H':
+			unit = time.Hour
+		case inTime && u == 'M':
+			unit = time.Minute
+		case inTime && u == 'S':
+			unit = time.Second
+		default:
+			return 0, invalid
+		}
+		d, err := parseScaled(rest[:i], unit)
+		if err != nil || total > math.MaxInt64-d {
+			return 0, invalid
+		}
+		total += d
+		rest = rest[i+1:]
+	}
+	if neg {
+		total = -total
+	}
+	return total, nil
+}
+
+//formatISO8601Duration formats d as an ISO 8601 duration using hours, minutes and seconds, e.g. PT26H3M0.5S
+func formatISO8601Duration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+	var sb strings.Builder
+	u := uint64(d)
+	if d < 0 {
+		sb.WriteByte('-')
+		u = uint64(-d)
+	}
+	sb.WriteString("PT")
+	h, m := u/uint64(time.Hour), u%uint64(time.Hour)/uint64(time.Minute)
+	sec, ns := u%uint64(time.Minute)/uint64(time.Second), u%uint64(time.Second)
+	if h > 0 {
+		sb.WriteString(strconv.FormatUint(h, 10) + "H")
+	}
+	if m > 0 {
+		sb.WriteString(strconv.FormatUint(m, 10) + "M")
+	}
+	if sec > 0 || ns > 0 {
+		sb.WriteString(strconv.FormatUint(sec, 10))
+		if ns > 0 {
+			sb.WriteString(textutils.PeriodStr + strings.TrimRight(fmt.Sprintf("%09d", ns), "0"))
+		}
+		sb.WriteByte('S')
+	}
+	return sb.String()
+}
+
+//parseTimeConstraints reads the format, min and max tags of a time.Time or time.Duration field. The bounds are parsed
+//using the format of the field. Duration bounds of fields without a format may be Go or ISO 8601 durations.
+func parseTimeConstraints(tag reflect.StructTag, t reflect.Type, format string, c *Constraints) {
+	if err := checkTimeFormat(t, format); err != nil {
+		c.err = err
+		return
+	}
+	for _, name := range []string{"min", "max"} {
+		s, ok := tag.Lookup(name)
+		if !ok {
+			continue
+		}
+		var err error
+		if t == timeType {
+			var bound time.Time
+			if bound, err = ParseTime(s, format); err == nil && name == "min" {
+				c.minTime = &bound
+			} else if err == nil {
+				c.maxTime = &bound
+			}
+		} else {
+			var bound time.Duration
+			if bound, err = ParseDuration(s, format); err == nil && name == "min" {
+				c.minDuration = &bound
+			} else if err == nil {
+				c.maxDuration = &bound
+			}
+		}
+		if err != nil {
+			c.err = fmt.Errorf("invalid %s constraint %q", name, s)
+		}
+	}
+}
+
+//CheckTimeMin function returns a violation for path if t is before min
+func CheckTimeMin(path string, t, min time.Time) error {
+	if t.Before(min) {
+		return validationError{field: path, message: "must not be before " + min.Format(time.RFC3339Nano)}
+	}
+	return nil
+}
+
+//CheckTimeMax function returns a violation for path if t is after max
+func CheckTimeMax(path string, t, max time.Time) error {
+	if t.After(max) {
+		return validationError{field: path, message: "must not be after " + max.Format(time.RFC3339Nano)}
+	}
+	return nil
+}
+
+//CheckDurationMin function returns a violation for path if d is shorter than min
+func CheckDurationMin(path string, d, min time.Duration) error {
+	if d < min {
+		return validationError{field: path, message: "must be greater than or equal to " + min.String()}
+	}
+	return nil
+}
+
+//CheckDurationMax function returns a violation for path if d is longer than max
+func CheckDurationMax(path string, d, max time.Duration) error {
+	if d > max {
+		return validationError{field: path, message: "must be less than or equal to " + max.String()}
+	}
+	return nil
+}
+
+//checkTime checks the min and max constraints on fv which holds a time.Time or a time.Duration
+func checkTime(name string, fv reflect.Value, c *Constraints) ValidationErrors {
+	var errs ValidationErrors
+	if fv.Type() == timeType {
+		t := fv.Interface().(time.Time)
+		if c.minTime != nil {
+			errs = AppendErrors(errs, CheckTimeMin(name, t, *c.minTime))
+		}
+		if c.maxTime != nil {
+			errs = AppendErrors(errs, CheckTimeMax(name, t, *c.maxTime))
+		}
+		return errs
+	}
+	d := time.Duration(fv.Int())
+	if c.minDuration != nil {
+		errs = AppendErrors(errs, CheckDurationMin(name, d, *c.minDuration))
+	}
+	if c.maxDuration != nil {
+		errs = AppendErrors(errs, CheckDurationMax(name, d, *c.maxDuration))
+	}
+	return errs
+}
+
+//hasFormattedFields checks if values of the type t may hold time or duration fields with a format
+func hasFormattedFields(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if cached, ok := formattedTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	has := walkFormattedFields(t, make(map[reflect.Type]bool))
+	formattedTypes.Store(t, has)
+	return has
+}
+
+//walkFormattedFields walks the type t. visiting holds the types being walked to stop the recursion on self
+//referencing types. Types with their own JSON encoding are not walked.
+func walkFormattedFields(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if cached, ok := formattedTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return walkFormattedFields(t.Elem(), visiting)
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+			return false
+		}
+		for _, f := range GetFieldMetas(t) {
+			if f.StringMeta().Format != textutils.EmptyStr && isTimeType(timeElem(f.Type)) ||
+				walkFormattedFields(f.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//timeElem returns the type of the elements of t, stripping pointers, slices, arrays and maps
+func timeElem(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+				return t
+			}
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+//formatJSON rewrites the JSON b encoded by encoding/json from a value of type t, replacing the values of the time and
+//duration fields that have a format with their formatted value. The order of the keys is preserved.
+func formatJSON(b []byte, t reflect.Type) ([]byte, error) {
+	return rewriteTimeJSON(b, t, true)
+}
+
+//unformatJSON rewrites the formatted values of the time and duration fields in the JSON b decoded in to a value of
+//type t to the values expected by encoding/json
+func unformatJSON(b []byte, t reflect.Type) ([]byte, error) {
+	return rewriteTimeJSON(b, t, false)
+}
+
+func rewriteTimeJSON(b []byte, t reflect.Type, encode bool) ([]byte, error) {
+	if !hasFormattedFields(t) {
+		return b, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	out, err := rewriteTimeJSONValue(dec, t, textutils.EmptyStr, encode, "$", make([]byte, 0, len(b)))
+	if err != nil && !encode {
+		//the values that cannot be parsed are reported with their path, the syntax errors by the decoder
+		if _, ok := err.(*formatError); !ok {
+			return b, nil
+		}
+	}
+	return out, err
+}
+
+//formatError reports a value that does not match the format of its field
+type formatError struct {
+	path string
+	err  error
+}
+
+func (e *formatError) Error() string {
+	return "json: invalid value at " + e.path + ": " + e.err.Error()
+}
+
+//rewriteTimeJSONValue copies the next value of dec to out. format is the format of the field holding the value
+func rewriteTimeJSONValue(dec *json.Decoder, t reflect.Type, format string, encode bool, path string,
+	out []byte) ([]byte, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	formatted := format != textutils.EmptyStr && t != nil && isTimeType(timeElem(t))
+	if !formatted && !hasFormattedFields(t) {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return out, err
+		}
+		return append(out, raw...), nil
+	}
+	if formatted && isTimeType(t) {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return out, err
+		}
+		if string(raw) == "null" {
+			return append(out, raw...), nil
+		}
+		converted, err := convertTimeJSON(raw, t, format, encode)
+		if err != nil {
+			return out, &formatError{path: path, err: err}
+		}
+		return append(out, converted...), nil
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return out, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		out = append(out, '{')
+		for first := true; dec.More(); first = false {
+			if tok, err = dec.Token(); err != nil {
+				return out, err
+			}
+			key, _ := tok.(string)
+			vt, vf := fieldFormat(t, key, format)
+			if !first {
+				out = append(out, ',')
+			}
+			out = append(AppendJSONString(out, key), ':')
+			if out, err = rewriteTimeJSONValue(dec, vt, vf, encode, path+textutils.PeriodStr+key, out); err != nil {
+				return out, err
+			}
+		}
+		_, err = dec.Token()
+		return append(out, '}'), err
+	case json.Delim('['):
+		out = append(out, '[')
+		var et reflect.Type
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			et = t.Elem()
+		}
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				out = append(out, ',')
+			}
+			if out, err = rewriteTimeJSONValue(dec, et, format, encode, path+"["+strconv.Itoa(i)+"]",
+				out); err != nil {
+				return out, err
+			}
+		}
+		_, err = dec.Token()
+		return append(out, ']'), err
+	}
+	raw, err := json.Marshal(tok)
+	return append(out, raw...), err
+}
+
+//fieldFormat returns the type and the format of the value of the key in an object of the type t
+func fieldFormat(t reflect.Type, key, format string) (reflect.Type, string) {
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem(), format
+	case reflect.Struct:
+		if fm := LookupField(t, JSONTarget, key); fm != nil {
+			return fm.Type, fm.StringMeta().Format
+		}
+	}
+	return nil, textutils.EmptyStr
+}
+
+//convertTimeJSON converts the JSON value raw of a time.Time or time.Duration between the encoding/json form and the
+//format
+func convertTimeJSON(raw []byte, t reflect.Type, format string, encode bool) ([]byte, error) {
+	s := string(raw)
+	if len(s) >= 2 && s[0] == '"' {
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+	}
+	v := reflect.New(t).Elem()
+	if encode {
+		if err := setTimeValue(v, s, textutils.EmptyStr); err != nil {
+			return nil, err
+		}
+		formatted, err := formatTimeValue(v, format)
+		if err != nil {
+			return nil, err
+		}
+		if isNumericFormat(t, format) {
+			return []byte(formatted), nil
+		}
+		return AppendJSONString(nil, formatted), nil
+	}
+	if err := setTimeValue(v, s, format); err != nil {
+		return nil, err
+	}
+	if t == durationType {
+		return []byte(strconv.FormatInt(v.Int(), 10)), nil
+	}
+	return json.Marshal(v.Interface())
+}
@@ -0,0 +1,234 @@
+package codec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatTime(t *testing.T) {
+	ts := time.Date(2021, 6, 1, 10, 30, 15, 250000000, time.UTC)
+	tests := []struct {
+		format string
+		want   string
+		parsed time.Time
+	}{
+		{format: "", want: "2021-06-01T10:30:15.25Z", parsed: ts},
+		{format: FormatRFC3339, want: "2021-06-01T10:30:15Z", parsed: ts.Truncate(time.Second)},
+		{format: FormatISO8601, want: "2021-06-01T10:30:15.25Z", parsed: ts},
+		{format: FormatUnix, want: "1622543415", parsed: ts.Truncate(time.Second)},
+		{format: FormatUnixMilli, want: "1622543415250", parsed: ts},
+		{format: FormatUnixNano, want: "1622543415250000000", parsed: ts},
+		{format: "2006-01-02", want: "2021-06-01", parsed: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got := FormatTime(ts, tt.format)
+			if got != tt.want {
+				t.Errorf("FormatTime() = %s, want %s", got, tt.want)
+			}
+			parsed, err := ParseTime(got, tt.format)
+			if err != nil || !parsed.Equal(tt.parsed) {
+				t.Errorf("ParseTime() = %v, %v, want %v", parsed, err, tt.parsed)
+			}
+		})
+	}
+	if got, err := ParseTime("1622543415.5", FormatUnix); err != nil || !got.Equal(ts.Add(250*time.Millisecond)) {
+		t.Errorf("ParseTime() = %v, %v", got, err)
+	}
+	epochs := map[string]time.Time{
+		"-1.5":         time.Date(1969, 12, 31, 23, 59, 58, 500000000, time.UTC),
+		"-62135596800": time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC),
+		"253402300799": time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC),
+		".0000000019":  time.Unix(0, 1).UTC(),
+	}
+	for s, want := range epochs {
+		if got, err := ParseTime(s, FormatUnix); err != nil || !got.Equal(want) {
+			t.Errorf("ParseTime(%s) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "-", "1e3", "99999999999999999999"} {
+		if _, err := ParseTime(s, FormatUnix); err == nil {
+			t.Errorf("ParseTime(%s) must fail", s)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	d := 26*time.Hour + 3*time.Minute + 500*time.Millisecond
+	tests := []struct {
+		format string
+		d      time.Duration
+		want   string
+	}{
+		{format: "", d: d, want: "93780500000000"},
+		{format: FormatISO8601, d: d, want: "PT26H3M0.5S"},
+		{format: FormatISO8601, d: -90 * time.Second, want: "-PT1M30S"},
+		{format: FormatISO8601, d: 0, want: "PT0S"},
+		{format: FormatGoDuration, d: d, want: "26h3m0.5s"},
+		{format: FormatSeconds, d: d, want: "93780.5"},
+		{format: FormatSeconds, d: -500 * time.Millisecond, want: "-0.5"},
+		{format: FormatMillis, d: d, want: "93780500"},
+		{format: FormatNanos, d: time.Microsecond, want: "1000"},
+	}
+	for _, tt := range tests {
+		got, err := FormatDuration(tt.d, tt.format)
+		if err != nil || got != tt.want {
+			t.Errorf("FormatDuration(%v, %q) = %s, %v, want %s", tt.d, tt.format, got, err, tt.want)
+		}
+		if parsed, err := ParseDuration(got, tt.format); err != nil || parsed != tt.d {
+			t.Errorf("ParseDuration(%s, %q) = %v, %v, want %v", got, tt.format, parsed, err, tt.d)
+		}
+	}
+	lenient := map[string]time.Duration{"P1DT2H": 26 * time.Hour, "P1W": 168 * time.Hour, "PT0,5S": 500 * time.Millisecond,
+		"1h30m": 90 * time.Minute, "1500": 1500}
+	for s, want := range lenient {
+		if got, err := ParseDuration(s, ""); err != nil || got != want {
+			t.Errorf("ParseDuration(%s) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"P1Y", "P1M", "PT", "P", "PT1H2", "1x"} {
+		if _, err := ParseDuration(s, ""); err == nil {
+			t.Errorf("ParseDuration(%s) must fail", s)
+		}
+	}
+	if _, err := FormatDuration(d, "hours"); err == nil {
+		t.Error("FormatDuration() must fail for an unknown format")
+	}
+}
+
+type schedule struct {
+	Start    time.Time       `json:"start" format:"unix" min:"1577836800"`
+	End      *time.Time      `json:"end,omitempty" format:"2006-01-02"`
+	Created  time.Time       `json:"created"`
+	Interval time.Duration   `json:"interval" format:"iso8601" default:"PT1H" min:"PT1M" max:"P1D"`
+	Timeout  time.Duration   `json:"timeout" format:"seconds"`
+	Retries  []time.Duration `json:"retries,omitempty" format:"go"`
+	Nested   *schedule       `json:"nested,omitempty"`
+}
+
+func TestStringFieldMeta(t *testing.T) {
+	tests := []struct {
+		field string
+		want  StringFieldMeta
+	}{
+		{field: "start", want: StringFieldMeta{Format: FormatUnix}},
+		{field: "end", want: StringFieldMeta{Format: "2006-01-02", OmitEmpty: true}},
+		{field: "interval", want: StringFieldMeta{Format: FormatISO8601, DefaultVal: "PT1H"}},
+		{field: "created"},
+	}
+	for _, tt := range tests {
+		sm := LookupField(reflect.TypeOf(schedule{}), JSONTarget, tt.field).StringMeta()
+		if sm.Name == "" || sm.Format != tt.want.Format || sm.DefaultVal != tt.want.DefaultVal ||
+			sm.OmitEmpty != tt.want.OmitEmpty {
+			t.Errorf("StringMeta(%s) = %+v", tt.field, sm)
+		}
+	}
+}
+
+func TestTimeFormat_Codecs(t *testing.T) {
+	end := time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)
+	in := schedule{
+		Start:    time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		End:      &end,
+		Created:  time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC),
+		Interval: 90 * time.Minute,
+		Timeout:  1500 * time.Millisecond,
+		Retries:  []time.Duration{time.Second, time.Minute},
+		Nested:   &schedule{Start: time.Date(2021, 6, 2, 0, 0, 0, 0, time.UTC), Interval: time.Hour},
+	}
+	tests := []struct {
+		name  string
+		codec Codec
+		want  string
+	}{
+		{
+			name:  "JSON",
+			codec: NewJSONCodec(nil),
+			want: `{"start":1622505600,"end":"2021-07-01","created":"2021-05-01T00:00:00Z","interval":"PT1H30M",` +
+				`"timeout":1.5,"retries":["1s","1m0s"],"nested":{"start":1622592000,` +
+				`"created":"0001-01-01T00:00:00Z","interval":"PT1H","timeout":0}}`,
+		},
+		{
+			name:  "Form",
+			codec: NewFormCodec(nil),
+			want: "created=2021-05-01T00%3A00%3A00Z&end=2021-07-01&interval=PT1H30M&nested.created=0001-01-01T00%3A00%3A00Z" +
+				"&nested.interval=PT1H&nested.start=1622592000&nested.timeout=0&retries=1s&retries=1m0s" +
+				"&start=1622505600&timeout=1.5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.codec.EncodeToString(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("EncodeToString()\n got = %s\nwant = %s", got, tt.want)
+			}
+			var out schedule
+			if err = tt.codec.DecodeString(got, &out); err != nil {
+				t.Fatal(err)
+			}
+			if !Equal(in, out) {
+				t.Errorf("DecodeString() changes = %v", Diff(in, out))
+			}
+		})
+	}
+}
+
+func TestTimeFormat_Decode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *schedule
+		wantErr string
+	}{
+		{
+			name:  "Defaults",
+			input: `{"start":"1622505600","timeout":"2"}`,
+			want: &schedule{Start: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), Interval: time.Hour,
+				Timeout: 2 * time.Second},
+		},
+		{name: "Invalid", input: `{"start":1622505600,"nested":{"interval":"1h"}}`,
+			wantErr: `json: invalid value at $.nested.interval: invalid ISO 8601 duration "1h"`},
+		{name: "Min", input: `{"start":0,"interval":"PT1S"}`,
+			wantErr: "start: must not be before 2020-01-01T00:00:00Z; interval: must be greater than or equal to 1m0s"},
+		{name: "Max", input: `{"start":1622505600,"interval":"P2D"}`,
+			wantErr: "interval: must be less than or equal to 24h0m0s"},
+		{name: "Syntax", input: `{"start":1622505600,}`, wantErr: "invalid character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got schedule
+			err := NewJSONCodec(nil).DecodeString(tt.input, &got)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("DecodeString() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(&got, tt.want) {
+				t.Errorf("DecodeString()\n got = %+v\nwant = %+v", got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeFormat_InvalidTags(t *testing.T) {
+	type invalid struct {
+		At      time.Time     `format:"unixmillis"`
+		Timeout time.Duration `format:"hours"`
+		Ttl     time.Duration `min:"forever"`
+	}
+	err := Validate(invalid{})
+	want := `At: unsupported time format "unixmillis"; Timeout: unsupported duration format "hours"; ` +
+		`Ttl: invalid min constraint "forever"`
+	if err == nil || err.Error() != want {
+		t.Errorf("Validate() error = %v, want %s", err, want)
+	}
+}
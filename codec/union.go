@@ -0,0 +1,533 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//Union struct holds the implementations of an interface type. The implementation of a value is identified by the
+//value of its discriminator field, e.g. {"type":"circle","radius":1}
+type Union struct {
+	iface         reflect.Type
+	discriminator string
+	mu            sync.RWMutex
+	types         map[string]reflect.Type
+	names         map[reflect.Type]string
+}
+
+//unions holds the unions registered by interface type
+var unions = struct {
+	sync.RWMutex
+	byType map[reflect.Type]*Union
+}{
+	byType: make(map[reflect.Type]*Union),
+}
+
+//unionTypes caches if a type holds union values directly or in nested values
+var unionTypes sync.Map
+
+//RegisterUnion function registers the interface type pointed by iface, e.g. (*Shape)(nil), as a union whose
+//implementations are selected by the value of the discriminator field. The implementations are added using
+//Union.Register. The union registered earlier for the same interface is returned if there is one.
+//The JSON codec writes the discriminator as the first key of the union values, decodes the values in to the
+//implementation registered for their discriminator and validates them. Union values held by other interfaces, e.g. an
+//interface{} field, are written with their discriminator too, while decoding such fields is left to encoding/json,
+//which yields a map keeping the discriminator. The other codecs of this package do not support unions.
+func RegisterUnion(iface interface{}, discriminator string) *Union {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic(fmt.Sprintf("codec: RegisterUnion requires a pointer to an interface, found %T", iface))
+	}
+	t = t.Elem()
+	unions.Lock()
+	defer unions.Unlock()
+	if u, ok := unions.byType[t]; ok {
+		return u
+	}
+	u := &Union{
+		iface:         t,
+		discriminator: discriminator,
+		types:         make(map[string]reflect.Type),
+		names:         make(map[reflect.Type]string),
+	}
+	unions.byType[t] = u
+	//the types walked before the registration may hold the new union
+	unionTypes.Range(func(k, _ interface{}) bool {
+		unionTypes.Delete(k)
+		return true
+	})
+	return u
+}
+
+//LookupUnion function returns the Union registered for the interface type t
+func LookupUnion(t reflect.Type) (*Union, bool) {
+	unions.RLock()
+	defer unions.RUnlock()
+	u, ok := unions.byType[t]
+	return u, ok
+}
+
+//Register registers the type of sample as the implementation of the union identified by the discriminator value.
+//sample is a struct or a pointer to a struct implementing the interface, e.g. &Circle{}, and the values decoded have
+//the same type. Register panics if sample does not implement the interface or if value is already registered.
+func (u *Union) Register(value string, sample interface{}) *Union {
+	t := reflect.TypeOf(sample)
+	if t == nil || !t.Implements(u.iface) {
+		panic(fmt.Sprintf("codec: %T does not implement %s", sample, u.iface))
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if existing, ok := u.types[value]; ok {
+		panic(fmt.Sprintf("codec: %q of %s is already registered for %s", value, u.iface, existing))
+	}
+	u.types[value] = t
+	u.names[t] = value
+	return u
+}
+
+//Discriminator returns the name of the discriminator field
+func (u *Union) Discriminator() string {
+	return u.discriminator
+}
+
+//TypeOf returns the implementation registered for the discriminator value
+func (u *Union) TypeOf(value string) (reflect.Type, bool) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	t, ok := u.types[value]
+	return t, ok
+}
+
+//ValueOf returns the discriminator value of the implementation t. A pointer to a registered struct and a struct whose
+//pointer is registered are identified as well.
+func (u *Union) ValueOf(t reflect.Type) (string, bool) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if v, ok := u.names[t]; ok {
+		return v, true
+	}
+	if t.Kind() == reflect.Ptr {
+		v, ok := u.names[t.Elem()]
+		return v, ok
+	}
+	v, ok := u.names[reflect.PtrTo(t)]
+	return v, ok
+}
+
+//values returns the registered discriminator values sorted
+func (u *Union) values() []string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	values := make([]string, 0, len(u.types))
+	for v := range u.types {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
+
+//hasUnionFields checks if values of the type t may hold union values
+func hasUnionFields(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if cached, ok := unionTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	has := walkUnionFields(t, make(map[reflect.Type]bool))
+	unionTypes.Store(t, has)
+	return has
+}
+
+//hasUnions checks if any union is registered
+func hasUnions() bool {
+	unions.RLock()
+	defer unions.RUnlock()
+	return len(unions.byType) > 0
+}
+
+//walkUnionFields walks the type t. visiting holds the types being walked to stop the recursion on self referencing
+//types. Types with their own JSON decoding are not walked.
+func walkUnionFields(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	switch t.Kind() {
+	case reflect.Interface:
+		//the dynamic values of other interfaces may hold union values
+		_, ok := LookupUnion(t)
+		return ok || hasUnions()
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return walkUnionFields(t.Elem(), visiting)
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+			return false
+		}
+		for _, f := range GetFieldMetas(t) {
+			if walkUnionFields(f.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//encodeUnionJSON rewrites the JSON b encoded from rv adding the discriminator to the union values. Union values are
+//encoded again using marshal so that their own formats and nested unions are applied.
+func encodeUnionJSON(b []byte, rv reflect.Value, marshal func(v interface{}) ([]byte, error)) ([]byte, error) {
+	if !rv.IsValid() || !hasUnionFields(rv.Type()) {
+		return b, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return encodeUnionJSONValue(dec, rv, marshal, "$", make([]byte, 0, len(b)))
+}
+
+func encodeUnionJSONValue(dec *json.Decoder, rv reflect.Value, marshal func(v interface{}) ([]byte, error),
+	path string, out []byte) ([]byte, error) {
+	var raw json.RawMessage
+	for rv.IsValid() && (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && !rv.IsNil() {
+		if rv.Kind() != reflect.Interface {
+			rv = rv.Elem()
+			continue
+		}
+		u, ok := LookupUnion(rv.Type())
+		if !ok {
+			rv = rv.Elem()
+			continue
+		}
+		if err := dec.Decode(&raw); err != nil {
+			return out, err
+		}
+		return appendUnionValue(out, u, rv.Elem(), marshal, path)
+	}
+	if !rv.IsValid() || !hasUnionFields(rv.Type()) || rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if err := dec.Decode(&raw); err != nil {
+			return out, err
+		}
+		return append(out, raw...), nil
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return out, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		out = append(out, '{')
+		for first := true; dec.More(); first = false {
+			if tok, err = dec.Token(); err != nil {
+				return out, err
+			}
+			key, _ := tok.(string)
+			var fv reflect.Value
+			if rv.Kind() == reflect.Map {
+				fv = rv.MapIndex(mapKey(rv.Type(), key))
+			} else if fm := LookupField(rv.Type(), JSONTarget, key); fm != nil {
+				fv, _ = fieldByIndex(rv, fm.Index)
+			}
+			if !first {
+				out = append(out, ',')
+			}
+			out = append(AppendJSONString(out, key), ':')
+			if out, err = encodeUnionJSONValue(dec, fv, marshal, path+textutils.PeriodStr+key, out); err != nil {
+				return out, err
+			}
+		}
+		_, err = dec.Token()
+		return append(out, '}'), err
+	case json.Delim('['):
+		out = append(out, '[')
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				out = append(out, ',')
+			}
+			var ev reflect.Value
+			if i < rv.Len() {
+				ev = rv.Index(i)
+			}
+			if out, err = encodeUnionJSONValue(dec, ev, marshal, path+"["+strconv.Itoa(i)+"]", out); err != nil {
+				return out, err
+			}
+		}
+		_, err = dec.Token()
+		return append(out, ']'), err
+	}
+	raw, err = json.Marshal(tok)
+	return append(out, raw...), err
+}
+
+//appendUnionValue appends the JSON object of the implementation value v with the discriminator of the union u as the
+//first key. The discriminator is not added if the implementation has a field with the same name.
+func appendUnionValue(out []byte, u *Union, v reflect.Value, marshal func(v interface{}) ([]byte, error),
+	path string) ([]byte, error) {
+	name, ok := u.ValueOf(v.Type())
+	if !ok {
+		return out, fmt.Errorf("json: %s at %s is not registered for %s", v.Type(), path, u.iface)
+	}
+	b, err := marshal(v.Interface())
+	if err != nil {
+		return out, err
+	}
+	b = bytes.TrimSpace(b)
+	if len(b) < 2 || b[0] != '{' {
+		return out, fmt.Errorf("json: %s at %s must be encoded as an object", v.Type(), path)
+	}
+	if LookupField(v.Type(), JSONTarget, u.discriminator) != nil {
+		return append(out, b...), nil
+	}
+	out = append(AppendJSONString(append(out, '{'), u.discriminator), ':')
+	out = AppendJSONString(out, name)
+	if len(bytes.TrimSpace(b[1:len(b)-1])) > 0 {
+		out = append(out, ',')
+	}
+	return append(out, b[1:]...), nil
+}
+
+//unionStep is a step of the path from a decoded value to a union value
+type unionStep struct {
+	kind  reflect.Kind
+	field []int
+	index int
+	key   string
+}
+
+//pendingUnion is a union value removed from the JSON before decoding, to be decoded in to its implementation
+type pendingUnion struct {
+	steps []unionStep
+	path  string
+	raw   []byte
+	typ   reflect.Type
+}
+
+//extractUnionJSON replaces the union values in the JSON b decoded in to a value of the type t with null so that
+//encoding/json can decode the rest. The implementation of the values removed is selected using the discriminator.
+func extractUnionJSON(b []byte, t reflect.Type, path string) ([]byte, []pendingUnion, error) {
+	if !hasUnionFields(t) {
+		return b, nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var pending []pendingUnion
+	out, err := extractUnionJSONValue(dec, t, nil, path, make([]byte, 0, len(b)), &pending)
+	if err != nil {
+		//the union values that cannot be resolved are reported with their path, the syntax errors by the decoder
+		if _, ok := err.(*unionError); !ok {
+			return b, nil, nil
+		}
+	}
+	return out, pending, err
+}
+
+func extractUnionJSONValue(dec *json.Decoder, t reflect.Type, steps []unionStep, path string, out []byte,
+	pending *[]pendingUnion) ([]byte, error) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var raw json.RawMessage
+	if t == nil || !hasUnionFields(t) {
+		if err := dec.Decode(&raw); err != nil {
+			return out, err
+		}
+		return append(out, raw...), nil
+	}
+	if t.Kind() == reflect.Interface {
+		if err := dec.Decode(&raw); err != nil {
+			return out, err
+		}
+		u, ok := LookupUnion(t)
+		if !ok || string(raw) == "null" {
+			return append(out, raw...), nil
+		}
+		impl, stripped, err := selectUnionType(u, raw, path)
+		if err != nil {
+			return out, err
+		}
+		*pending = append(*pending, pendingUnion{steps: append([]unionStep(nil), steps...), path: path,
+			raw: stripped, typ: impl})
+		return append(out, "null"...), nil
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return out, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		out = append(out, '{')
+		for first := true; dec.More(); first = false {
+			if tok, err = dec.Token(); err != nil {
+				return out, err
+			}
+			key, _ := tok.(string)
+			var vt reflect.Type
+			var step unionStep
+			if t.Kind() == reflect.Map {
+				vt, step = t.Elem(), unionStep{kind: reflect.Map, key: key}
+			} else if fm := LookupField(t, JSONTarget, key); fm != nil && t.Kind() == reflect.Struct {
+				vt, step = fm.Type, unionStep{kind: reflect.Struct, field: fm.Index}
+			}
+			if !first {
+				out = append(out, ',')
+			}
+			out = append(AppendJSONString(out, key), ':')
+			out, err = extractUnionJSONValue(dec, vt, append(steps, step), path+textutils.PeriodStr+key, out, pending)
+			if err != nil {
+				return out, err
+			}
+		}
+		_, err = dec.Token()
+		return append(out, '}'), err
+	case json.Delim('['):
+		out = append(out, '[')
+		var et reflect.Type
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			et = t.Elem()
+		}
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				out = append(out, ',')
+			}
+			step := unionStep{kind: reflect.Slice, index: i}
+			out, err = extractUnionJSONValue(dec, et, append(steps, step), path+"["+strconv.Itoa(i)+"]", out,
+				pending)
+			if err != nil {
+				return out, err
+			}
+		}
+		_, err = dec.Token()
+		return append(out, ']'), err
+	}
+	raw, err = json.Marshal(tok)
+	return append(out, raw...), err
+}
+
+//unionError reports a union value whose implementation cannot be selected
+type unionError struct {
+	error
+}
+
+//selectUnionType returns the implementation selected by the discriminator of the JSON object raw and the object
+//without the discriminator, unless the implementation has a field with the same name
+func selectUnionType(u *Union, raw []byte, path string) (reflect.Type, []byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, &unionError{fmt.Errorf("json: %s at %s must be an object", u.iface, path)}
+	}
+	var keys []string
+	var members []json.RawMessage
+	var value *string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := tok.(string)
+		var v json.RawMessage
+		if err = dec.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		if key == u.discriminator {
+			var s string
+			if err = json.Unmarshal(v, &s); err != nil {
+				return nil, nil, &unionError{fmt.Errorf("json: discriminator %q at %s must be a string", key, path)}
+			}
+			value = &s
+		}
+		keys = append(keys, key)
+		members = append(members, v)
+	}
+	if value == nil {
+		return nil, nil, &unionError{fmt.Errorf("json: missing discriminator %q at %s", u.discriminator, path)}
+	}
+	impl, ok := u.TypeOf(*value)
+	if !ok {
+		expected := strings.Join(u.values(), textutils.CommaStr+textutils.WhiteSpaceStr)
+		return nil, nil, &unionError{fmt.Errorf("json: unknown %s %q at %s, expected one of %s", u.discriminator,
+			*value, path, expected)}
+	}
+	if LookupField(impl, JSONTarget, u.discriminator) != nil {
+		return impl, raw, nil
+	}
+	out := []byte{'{'}
+	for i, key := range keys {
+		if key == u.discriminator {
+			continue
+		}
+		if len(out) > 1 {
+			out = append(out, ',')
+		}
+		out = append(append(AppendJSONString(out, key), ':'), members[i]...)
+	}
+	return impl, append(out, '}'), nil
+}
+
+//decodeUnions decodes the pending union values in to their implementation using decode and sets them on rv
+func decodeUnions(rv reflect.Value, pending []pendingUnion, decode func(b []byte, v interface{}, path string) error) error {
+	for _, p := range pending {
+		var impl reflect.Value
+		if p.typ.Kind() == reflect.Ptr {
+			impl = reflect.New(p.typ.Elem())
+		} else {
+			impl = reflect.New(p.typ)
+		}
+		if err := decode(p.raw, impl.Interface(), p.path); err != nil {
+			return err
+		}
+		if p.typ.Kind() != reflect.Ptr {
+			impl = impl.Elem()
+		}
+		setUnionValue(rv, p.steps, impl)
+	}
+	return nil
+}
+
+//setUnionValue walks rv along the steps and sets val at the end
+func setUnionValue(rv reflect.Value, steps []unionStep, val reflect.Value) {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	if len(steps) == 0 {
+		rv.Set(val)
+		return
+	}
+	step := steps[0]
+	switch step.kind {
+	case reflect.Struct:
+		setUnionValue(fieldByIndexAlloc(rv, step.field), steps[1:], val)
+	case reflect.Slice:
+		setUnionValue(rv.Index(step.index), steps[1:], val)
+	case reflect.Map:
+		k := mapKey(rv.Type(), step.key)
+		//map elements are not addressable, a copy is updated and stored back
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		if existing := rv.MapIndex(k); existing.IsValid() {
+			elem.Set(existing)
+		}
+		setUnionValue(elem, steps[1:], val)
+		rv.SetMapIndex(k, elem)
+	}
+}
+
+//mapKey converts the JSON object key to a key of the map type t
+func mapKey(t reflect.Type, key string) reflect.Value {
+	k := reflect.New(t.Key()).Elem()
+	if t.Key().Kind() == reflect.String {
+		k.SetString(key)
+	} else {
+		_ = SetFromString(k, key)
+	}
+	return k
+}
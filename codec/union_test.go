@@ -0,0 +1,164 @@
+package codec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type shape interface {
+	area() float64
+}
+
+type circle struct {
+	Radius float64 `json:"radius" min:"0"`
+}
+
+func (c circle) area() float64 { return 3 * c.Radius * c.Radius }
+
+type square struct {
+	Side float64       `json:"side" default:"1"`
+	TTL  time.Duration `json:"ttl,omitempty" format:"seconds"`
+}
+
+func (s *square) area() float64 { return s.Side * s.Side }
+
+type group struct {
+	Type   string  `json:"type"`
+	Shapes []shape `json:"shapes"`
+}
+
+func (g *group) area() float64 { return 0 }
+
+type drawing struct {
+	Name   string           `json:"name"`
+	Main   shape            `json:"main"`
+	Shapes []shape          `json:"shapes,omitempty"`
+	Named  map[string]shape `json:"named,omitempty"`
+}
+
+func init() {
+	RegisterUnion((*shape)(nil), "type").
+		Register("circle", circle{}).
+		Register("square", &square{}).
+		Register("group", &group{})
+}
+
+func TestUnion_JSON(t *testing.T) {
+	in := drawing{
+		Name:   "d",
+		Main:   circle{Radius: 2},
+		Shapes: []shape{&square{Side: 3, TTL: 1500 * time.Millisecond}, nil, &group{Type: "group", Shapes: []shape{circle{}}}},
+		Named:  map[string]shape{"a": &square{Side: 1}},
+	}
+	want := `{"name":"d","main":{"type":"circle","radius":2},"shapes":[{"type":"square","side":3,"ttl":1.5},null,` +
+		`{"type":"group","shapes":[{"type":"circle","radius":0}]}],"named":{"a":{"type":"square","side":1}}}`
+	codec := NewJSONCodec(nil)
+	got, err := codec.EncodeToString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("EncodeToString()\n got = %s\nwant = %s", got, want)
+	}
+	var out drawing
+	if err = codec.DecodeString(got, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(in, out) {
+		t.Errorf("DecodeString() changes = %v", Diff(in, out))
+	}
+	var s shape
+	if err = codec.DecodeString(`{"type":"square"}`, &s); err != nil {
+		t.Fatal(err)
+	}
+	if sq, ok := s.(*square); !ok || sq.Side != 1 {
+		t.Errorf("DecodeString() = %#v, want the default side", s)
+	}
+}
+
+func TestUnion_Decode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		options *DecoderOptions
+		wantErr string
+	}{
+		{name: "Null", input: `{"main":null}`},
+		{name: "Strict", input: `{"main":{"type":"circle","radius":1}}`, options: &DecoderOptions{DisallowUnknownFields: true}},
+		{name: "Missing", input: `{"main":{"radius":1}}`, wantErr: `json: missing discriminator "type" at $.main`},
+		{name: "Unknown", input: `{"shapes":[{"type":"hexagon"}]}`,
+			wantErr: `json: unknown type "hexagon" at $.shapes[0], expected one of circle, group, square`},
+		{name: "NotString", input: `{"main":{"type":1}}`, wantErr: `json: discriminator "type" at $.main must be a string`},
+		{name: "NotObject", input: `{"main":[]}`, wantErr: `must be an object`},
+		{name: "Nested", input: `{"main":{"type":"group","shapes":[{}]}}`,
+			wantErr: `json: missing discriminator "type" at $.main.shapes[0]`},
+		{name: "Validation", input: `{"main":{"type":"circle","radius":-1}}`,
+			wantErr: "main.radius: must be greater than or equal to 0"},
+		{name: "Syntax", input: `{"main":{"type":"circle",}}`, wantErr: "invalid character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got drawing
+			err := NewJSONCodec(tt.options).DecodeString(tt.input, &got)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("DecodeString() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type triangle struct{}
+
+func (triangle) area() float64 { return 0 }
+
+func TestUnion_Register(t *testing.T) {
+	u, ok := LookupUnion(reflect.TypeOf((*shape)(nil)).Elem())
+	if !ok || u.Discriminator() != "type" {
+		t.Fatal("LookupUnion() must return the registered union")
+	}
+	if v, ok := u.ValueOf(reflect.TypeOf(&circle{})); !ok || v != "circle" {
+		t.Errorf("ValueOf(*circle) = %s, %v", v, ok)
+	}
+	if _, err := NewJSONCodec(nil).EncodeToString(drawing{Main: triangle{}}); err == nil ||
+		!strings.Contains(err.Error(), "is not registered") {
+		t.Errorf("EncodeToString() error = %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() must panic for a type not implementing the interface")
+		}
+	}()
+	u.Register("string", "")
+}
+
+type envelope struct {
+	Payload interface{} `json:"payload"`
+}
+
+func TestUnion_Scope(t *testing.T) {
+	codec := NewJSONCodec(nil)
+	//union values held by interface{} are written with their discriminator
+	got, err := codec.EncodeToString(envelope{Payload: drawing{Name: "d", Main: &square{Side: 2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"payload":{"name":"d","main":{"type":"square","side":2}}}`
+	if got != want {
+		t.Errorf("EncodeToString()\n got = %s\nwant = %s", got, want)
+	}
+	//and decoded by encoding/json as maps keeping the discriminator
+	var out envelope
+	if err = codec.DecodeString(got, &out); err != nil {
+		t.Fatal(err)
+	}
+	main := out.Payload.(map[string]interface{})["main"].(map[string]interface{})
+	if main["type"] != "square" {
+		t.Errorf("DecodeString() main = %v", main)
+	}
+	//the other codecs do not discriminate unions
+	if err = NewFormCodec(nil).DecodeString("name=d&type=circle&main.radius=2", &drawing{}); err == nil {
+		t.Error("FormCodec.DecodeString() of a union value succeeded")
+	}
+}
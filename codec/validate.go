@@ -0,0 +1,488 @@
+package codec
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//Constraints struct holds the constraints declared on a field using the following struct tags
+//	required:"true"   the field must not have the zero value
+//	default:"value"   value set on the field if it has the zero value before decoding
+//	min:"n" max:"n"   bounds of a number, a time, a duration, or of the length of a string, slice or map
+//	pattern:"regexp"  regular expression that a string must match
+//	scale:"n"         maximum number of fractional digits of a Decimal
+//The bounds of a time.Time or a time.Duration are written using the format of the field, see StringFieldMeta.
+type Constraints struct {
+	DefaultVal string
+	HasDefault bool
+	Min        *float64
+	Max        *float64
+	Pattern    *regexp.Regexp
+	Scale      *int32
+	//minDecimal and maxDecimal hold the exact min and max bounds used for Decimal fields
+	minDecimal *Decimal
+	maxDecimal *Decimal
+	//minTime, maxTime, minDuration and maxDuration hold the bounds of time.Time and time.Duration fields
+	minTime     *time.Time
+	maxTime     *time.Time
+	minDuration *time.Duration
+	maxDuration *time.Duration
+	//err holds the error found while parsing the tags. It is reported when the field is validated
+	err error
+}
+
+//Validator interface is implemented by types that validate their own constraints.
+//Types generated by codecgen implement this interface to avoid reflection
+type Validator interface {
+	//Validate returns ValidationErrors with all the constraint violations or nil if the value is valid
+	Validate() error
+}
+
+//Defaulter interface is implemented by types that apply their own default values.
+type Defaulter interface {
+	//ApplyDefaults sets the default value on the fields that have the zero value
+	ApplyDefaults() error
+}
+
+//ValidationErrors holds all the constraint violations found while validating a value
+type ValidationErrors []error
+
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, len(ve))
+	for i, e := range ve {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+//ErrorOrNil returns nil if there are no violations, else the ValidationErrors
+func (ve ValidationErrors) ErrorOrNil() error {
+	if len(ve) == 0 {
+		return nil
+	}
+	return ve
+}
+
+//parseConstraints reads the constraint tags of a struct field of the type t
+func parseConstraints(tag reflect.StructTag, t reflect.Type) (bool, *Constraints) {
+	c := &Constraints{}
+	required, _ := strconv.ParseBool(tag.Get("required"))
+	c.DefaultVal, c.HasDefault = tag.Lookup("default")
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isTimeType(t) {
+		parseTimeConstraints(tag, t, tag.Get("format"), c)
+	} else {
+		c.Min = parseBound(tag, "min", c)
+		c.Max = parseBound(tag, "max", c)
+		c.minDecimal = parseDecimalBound(tag, "min")
+		c.maxDecimal = parseDecimalBound(tag, "max")
+	}
+	if s, ok := tag.Lookup("scale"); ok {
+		scale, err := strconv.ParseInt(s, 10, 32)
+		if err != nil || scale < 0 {
+			c.err = fmt.Errorf("invalid scale constraint %q", s)
+		} else {
+			sc := int32(scale)
+			c.Scale = &sc
+		}
+	}
+	if p, ok := tag.Lookup("pattern"); ok {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			c.err = err
+		}
+		c.Pattern = re
+	}
+	return required, c
+}
+
+//parseBound reads a numeric bound tag
+func parseBound(tag reflect.StructTag, name string, c *Constraints) *float64 {
+	s, ok := tag.Lookup(name)
+	if !ok {
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		c.err = fmt.Errorf("invalid %s constraint %q", name, s)
+		return nil
+	}
+	return &f
+}
+
+//Validate function checks the constraints declared on the fields of v and all the nested values.
+//All the violations are returned together as ValidationErrors. If v implements Validator its Validate method is used.
+func Validate(v interface{}) error {
+	if val, ok := v.(Validator); ok {
+		return val.Validate()
+	}
+	return validateValue(textutils.EmptyStr, reflect.ValueOf(v)).ErrorOrNil()
+}
+
+//ValidateNested function validates v and prefixes the violations with path.
+//This is used by generated validators for fields that are not scalars.
+func ValidateNested(path string, v interface{}) error {
+	return validateValue(path, reflect.ValueOf(v)).ErrorOrNil()
+}
+
+//AppendErrors function appends the err to errs. If err is a ValidationErrors its violations are appended
+func AppendErrors(errs ValidationErrors, err error) ValidationErrors {
+	if err == nil {
+		return errs
+	}
+	if ve, ok := err.(ValidationErrors); ok {
+		return append(errs, ve...)
+	}
+	return append(errs, err)
+}
+
+//CheckRequired function returns a violation for path if isZero is true
+func CheckRequired(path string, isZero bool) error {
+	if isZero {
+		return validationError{field: path, message: "is required"}
+	}
+	return nil
+}
+
+//CheckMin function returns a violation for path if val is less than min
+func CheckMin(path string, val, min float64) error {
+	if val < min {
+		return validationError{field: path, message: "must be greater than or equal to " + formatBound(min)}
+	}
+	return nil
+}
+
+//CheckMax function returns a violation for path if val is greater than max
+func CheckMax(path string, val, max float64) error {
+	if val > max {
+		return validationError{field: path, message: "must be less than or equal to " + formatBound(max)}
+	}
+	return nil
+}
+
+//CheckMinLength function returns a violation for path if the length l is less than min
+func CheckMinLength(path string, l int, min float64) error {
+	if float64(l) < min {
+		return validationError{field: path, message: "length must be greater than or equal to " + formatBound(min)}
+	}
+	return nil
+}
+
+//CheckMaxLength function returns a violation for path if the length l is greater than max
+func CheckMaxLength(path string, l int, max float64) error {
+	if float64(l) > max {
+		return validationError{field: path, message: "length must be less than or equal to " + formatBound(max)}
+	}
+	return nil
+}
+
+//CheckPattern function returns a violation for path if s does not match re
+func CheckPattern(path, s string, re *regexp.Regexp) error {
+	if !re.MatchString(s) {
+		return validationError{field: path, message: "must match the pattern " + re.String()}
+	}
+	return nil
+}
+
+//CheckField function checks the required flag and the constraints of the field f on its value fv and returns the
+//violations for path. This is used to validate values set from other sources than a codec, e.g. a configuration.
+func CheckField(path string, f *FieldMeta, fv reflect.Value) error {
+	var errs ValidationErrors
+	if f.Required {
+		errs = AppendErrors(errs, CheckRequired(path, fv.IsZero()))
+	}
+	return append(errs, checkConstraints(path, fv, f.Constraints)...).ErrorOrNil()
+}
+
+func formatBound(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+//joinPath appends the name to the path of the parent value
+func joinPath(path, name string) string {
+	if path == textutils.EmptyStr {
+		return name
+	}
+	return path + textutils.PeriodStr + name
+}
+
+//validateValue walks rv and validates all the structs found
+func validateValue(path string, rv reflect.Value) ValidationErrors {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		if !rv.CanAddr() {
+			//make an addressable copy so that Validator implementations with pointer receivers are found
+			cp := reflect.New(rv.Type()).Elem()
+			cp.Set(rv)
+			rv = cp
+		}
+		if val, ok := rv.Addr().Interface().(Validator); ok {
+			return prefixErrors(path, val.Validate())
+		}
+	}
+	var errs ValidationErrors
+	switch rv.Kind() {
+	case reflect.Struct:
+		errs = validateStruct(path, rv)
+	case reflect.Slice, reflect.Array:
+		if isContainer(rv.Type().Elem()) {
+			for i := 0; i < rv.Len(); i++ {
+				errs = append(errs, validateValue(path+"["+strconv.Itoa(i)+"]", rv.Index(i))...)
+			}
+		}
+	case reflect.Map:
+		if isContainer(rv.Type().Elem()) {
+			iter := rv.MapRange()
+			for iter.Next() {
+				errs = append(errs, validateValue(path+"["+fmt.Sprint(iter.Key().Interface())+"]", iter.Value())...)
+			}
+		}
+	}
+	return errs
+}
+
+//validateStruct checks the constraints of all the fields of the struct rv
+func validateStruct(path string, rv reflect.Value) ValidationErrors {
+	var errs ValidationErrors
+	for _, f := range GetFieldMetas(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.Index)
+		if !ok {
+			continue
+		}
+		name := joinPath(path, f.TargetName(JSONTarget))
+		if f.Required {
+			errs = AppendErrors(errs, CheckRequired(name, fv.IsZero()))
+		}
+		errs = append(errs, checkConstraints(name, fv, f.Constraints)...)
+		if isContainer(fv.Type()) {
+			errs = append(errs, validateValue(name, fv)...)
+		}
+	}
+	return errs
+}
+
+//checkConstraints checks the min, max and pattern constraints on the field value fv
+func checkConstraints(name string, fv reflect.Value, c *Constraints) ValidationErrors {
+	var errs ValidationErrors
+	if c.err != nil {
+		return append(errs, validationError{field: name, message: c.err.Error()})
+	}
+	if !c.hasChecks() {
+		return nil
+	}
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	if fv.Type() == decimalType {
+		return checkDecimal(name, fv.Interface().(Decimal), c)
+	}
+	if isTimeType(fv.Type()) {
+		return checkTime(name, fv, c)
+	}
+	var num float64
+	isLength := false
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num = float64(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		num = float64(fv.Uint())
+	case reflect.Float32, reflect.Float64:
+		num = fv.Float()
+	case reflect.String:
+		num = float64(utf8.RuneCountInString(fv.String()))
+		isLength = true
+		if c.Pattern != nil {
+			errs = AppendErrors(errs, CheckPattern(name, fv.String(), c.Pattern))
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		num = float64(fv.Len())
+		isLength = true
+	default:
+		return errs
+	}
+	if isLength {
+		if c.Min != nil {
+			errs = AppendErrors(errs, CheckMinLength(name, int(num), *c.Min))
+		}
+		if c.Max != nil {
+			errs = AppendErrors(errs, CheckMaxLength(name, int(num), *c.Max))
+		}
+	} else {
+		if c.Min != nil {
+			errs = AppendErrors(errs, CheckMin(name, num, *c.Min))
+		}
+		if c.Max != nil {
+			errs = AppendErrors(errs, CheckMax(name, num, *c.Max))
+		}
+	}
+	return errs
+}
+
+//hasChecks checks if any of the constraints verified by checkConstraints is declared
+func (c *Constraints) hasChecks() bool {
+	return c.Min != nil || c.Max != nil || c.Pattern != nil || c.Scale != nil || c.minTime != nil ||
+		c.maxTime != nil || c.minDuration != nil || c.maxDuration != nil
+}
+
+//prefixErrors prefixes the field of the violations in err with path
+func prefixErrors(path string, err error) ValidationErrors {
+	if err == nil {
+		return nil
+	}
+	ve, ok := err.(ValidationErrors)
+	if !ok {
+		ve = ValidationErrors{err}
+	}
+	if path == textutils.EmptyStr {
+		return ve
+	}
+	prefixed := make(ValidationErrors, len(ve))
+	for i, e := range ve {
+		if v, ok := e.(validationError); ok {
+			prefixed[i] = validationError{field: joinPath(path, v.field), message: v.message}
+		} else {
+			prefixed[i] = validationError{field: path, message: e.Error()}
+		}
+	}
+	return prefixed
+}
+
+//isContainer checks if values of the type t may hold structs that need to be validated
+func isContainer(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+//fieldByIndex returns the nested field of the struct rv. If an embedded pointer on the way is nil false is returned
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			if rv.Kind() == reflect.Ptr {
+				if rv.IsNil() {
+					return reflect.Value{}, false
+				}
+				rv = rv.Elem()
+			}
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+//ApplyDefaults function sets the default value declared using the default tag on all the fields of the struct pointed
+//by v which have the zero value. Nested structs are handled recursively. If v implements Defaulter its ApplyDefaults
+//method is used.
+func ApplyDefaults(v interface{}) error {
+	if d, ok := v.(Defaulter); ok {
+		return d.ApplyDefaults()
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("defaults can only be applied to a non nil pointer")
+	}
+	return applyDefaults(rv.Elem())
+}
+
+func applyDefaults(rv reflect.Value) error {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	if rv.CanAddr() {
+		if d, ok := rv.Addr().Interface().(Defaulter); ok {
+			return d.ApplyDefaults()
+		}
+	}
+	for _, f := range GetFieldMetas(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.Index)
+		if !ok || !fv.CanSet() {
+			continue
+		}
+		if f.Constraints.HasDefault && fv.IsZero() {
+			if err := setFormatted(fv, f.Constraints.DefaultVal, f.StringMeta().Format); err != nil {
+				return fmt.Errorf("invalid default for %s: %v", f.FieldName, err)
+			}
+		} else if err := applyDefaults(fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//SetFromString function parses s according to the type of rv and sets the value. Strings, booleans, numbers,
+//pointers to them and types implementing encoding.TextUnmarshaler are supported. A time.Duration may be a number of
+//nanoseconds, a Go duration, e.g. 1h30m, or an ISO 8601 duration, e.g. PT1H30M.
+func SetFromString(rv reflect.Value, s string) error {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return SetFromString(rv.Elem(), s)
+	}
+	if rv.CanAddr() {
+		if tu, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return tu.UnmarshalText([]byte(s))
+		}
+	}
+	if rv.Type() == durationType {
+		return setTimeValue(rv, s, textutils.EmptyStr)
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot set a string value on type %s", rv.Type())
+	}
+	return nil
+}
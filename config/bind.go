@@ -0,0 +1,423 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.codemanch.com/commons/codec"
+	"go.codemanch.com/commons/textutils"
+)
+
+//formatBytes is the format of the integer fields holding a byte size, e.g. `format:"bytes"` accepts 512MiB
+const formatBytes = "bytes"
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	urlType             = reflect.TypeOf(url.URL{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+//keyLister is implemented by the configurations that can list their keys, e.g. Properties and LayeredConfig
+type keyLister interface {
+	Keys() []string
+}
+
+//valueLookup is implemented by the configurations that report if a key has a value, e.g. Properties and LayeredConfig
+type valueLookup interface {
+	Lookup(k string) (string, bool)
+}
+
+//binder holds the state of a Bind
+type binder struct {
+	cfg Configuration
+	//keys are the keys of the configuration, nil if it cannot list them
+	keys []string
+	errs codec.ValidationErrors
+	//found is set when a key with a value is looked up
+	found bool
+	//visiting holds the struct types being bound, it stops the allocation of self referencing types if the keys
+	//cannot be listed
+	visiting map[reflect.Type]bool
+}
+
+//Bind function populates the struct pointed by target from the keys of the configuration starting with prefix.
+//The key of a field is the prefix joined with '.' to the name in its config tag, else its json name, e.g.
+//  type DB struct {
+//  	Host    string        `config:"host" required:"true"`
+//  	Port    int           `config:"port" default:"5432" min:"1" max:"65535"`
+//  	Timeout time.Duration `config:"timeout" default:"5s"`
+//  	Buffer  int64         `config:"buffer" format:"bytes" default:"1MiB"`
+//  	Hosts   []string      `config:"hosts"`
+//  }
+//  err := config.Bind(cfg, "db", &db)
+//Nested structs are bound from the keys below their own name. A slice is read from a comma separated value or from
+//the keys ending with its indexes, e.g. db.hosts.0, and a map from comma separated key=value entries or from the keys
+//below its name. Durations and times accept the format tag of the codec package and integers the bytes format. The
+//default tag is used for the keys without a value. The keys are matched case insensitively if no exact match exists
+//and the configuration can list its keys. A nil pointer to a struct is allocated only if one of the keys below its name
+//has a value, and a pointer to a struct type being bound is not allocated if the keys cannot be listed.
+//The fields keep their value if their key is missing and has no default. The required, min, max and pattern tags are
+//checked after binding and all the malformed values and violations are returned together as codec.ValidationErrors.
+func Bind(cfg Configuration, prefix string, target interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("config: Bind target must be a non nil pointer to a struct")
+	}
+	b := &binder{cfg: cfg, visiting: make(map[reflect.Type]bool)}
+	if kl, ok := cfg.(keyLister); ok {
+		b.keys = kl.Keys()
+		if b.keys == nil {
+			b.keys = []string{}
+		}
+	}
+	b.bindStruct(prefix, rv.Elem())
+	return b.errs.ErrorOrNil()
+}
+
+//lookup returns the value of the key, matching the key case insensitively if there is no exact match
+func (b *binder) lookup(key string) (string, bool) {
+	v, ok := b.get(key)
+	for i := 0; !ok && i < len(b.keys); i++ {
+		if strings.EqualFold(b.keys[i], key) {
+			v, ok = b.get(b.keys[i])
+		}
+	}
+	b.found = b.found || ok
+	return v, ok
+}
+
+//get returns the value of the key and if it is present. Without a Lookup method the presence is detected by calling
+//Get with two different defaults, since a value cannot be equal to both.
+func (b *binder) get(key string) (string, bool) {
+	if vl, ok := b.cfg.(valueLookup); ok {
+		return vl.Lookup(key)
+	}
+	if v := b.cfg.Get(key, textutils.EmptyStr); v != textutils.EmptyStr {
+		return v, true
+	}
+	return textutils.EmptyStr, b.cfg.Get(key, textutils.HyphenStr) != textutils.HyphenStr
+}
+
+//children returns the distinct names of the keys directly below the key, sorted. nil is returned if the configuration
+//cannot list its keys.
+func (b *binder) children(key string) []string {
+	if b.keys == nil {
+		return nil
+	}
+	prefix := strings.ToLower(key) + textutils.PeriodStr
+	seen := make(map[string]bool)
+	var names []string
+	for _, k := range b.keys {
+		if !strings.HasPrefix(strings.ToLower(k), prefix) {
+			continue
+		}
+		name := k[len(prefix):]
+		if idx := strings.IndexByte(name, textutils.PeriodChar); idx != -1 {
+			name = name[:idx]
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (b *binder) fail(key string, err error) {
+	b.errs = append(b.errs, fmt.Errorf("%s: %v", key, err))
+}
+
+func (b *binder) bindStruct(prefix string, rv reflect.Value) {
+	t := rv.Type()
+	b.visiting[t] = true
+	defer delete(b.visiting, t)
+	for _, f := range codec.GetFieldMetas(t) {
+		name, ok := fieldKey(t, f)
+		if !ok {
+			continue
+		}
+		fv, ok := fieldByIndex(rv, f.Index)
+		if !ok {
+			continue
+		}
+		key := joinKey(prefix, name)
+		if b.bindValue(key, fv, f) {
+			b.errs = codec.AppendErrors(b.errs, codec.CheckField(key, f, fv))
+		}
+	}
+}
+
+//bindValue sets the value of the key, or of the keys below it, on rv. f is nil for the items of slices and maps.
+//false is returned if the value is malformed.
+func (b *binder) bindValue(key string, rv reflect.Value, f *codec.FieldMeta) bool {
+	t := rv.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isNested(t) {
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			b.bindNew(key, rv, t)
+			return true
+		}
+		b.bindStruct(key, reflect.Indirect(rv))
+		return true
+	}
+	raw, ok := b.lookup(key)
+	if !ok && f != nil && f.Constraints.HasDefault && rv.IsZero() {
+		raw, ok = f.Constraints.DefaultVal, true
+	}
+	format := textutils.EmptyStr
+	if f != nil {
+		format = f.StringMeta().Format
+	}
+	switch {
+	case ok && t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		return b.bindList(key, rv, parseList(raw, textutils.CommaStr, true), format)
+	case ok && t.Kind() == reflect.Map:
+		m, err := parseMap(raw)
+		if err != nil {
+			b.fail(key, err)
+			return false
+		}
+		return b.bindEntries(key, rv, m, format)
+	case ok:
+		if err := setValue(rv, raw, format); err != nil {
+			b.fail(key, fmt.Errorf("invalid value %q: %v", raw, err))
+			return false
+		}
+	case t.Kind() == reflect.Slice:
+		return b.bindIndexed(key, rv, format)
+	case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+		return b.bindChildren(key, rv, format)
+	}
+	return true
+}
+
+//bindNew binds a new struct of the type t from the keys below the key and sets it on the nil pointer rv if one of the
+//keys has a value. The violations of the struct are dropped if it is not set.
+func (b *binder) bindNew(key string, rv reflect.Value, t reflect.Type) {
+	if b.keys != nil && len(b.children(key)) == 0 || b.keys == nil && b.visiting[t] {
+		return
+	}
+	found, errs := b.found, len(b.errs)
+	b.found = false
+	nv := reflect.New(t)
+	b.bindStruct(key, nv.Elem())
+	if b.found {
+		setIndirect(rv, nv.Elem())
+	} else {
+		b.errs = b.errs[:errs]
+	}
+	b.found = b.found || found
+}
+
+//bindList sets the slice rv from the items of a comma separated value
+func (b *binder) bindList(key string, rv reflect.Value, items []string, format string) bool {
+	st := indirectType(rv.Type())
+	list := reflect.MakeSlice(st, len(items), len(items))
+	valid := true
+	for i, item := range items {
+		if err := setValue(list.Index(i), item, format); err != nil {
+			b.fail(joinKey(key, strconv.Itoa(i)), fmt.Errorf("invalid value %q: %v", item, err))
+			valid = false
+		}
+	}
+	if valid {
+		setIndirect(rv, list)
+	}
+	return valid
+}
+
+//bindIndexed sets the slice rv from the keys ending with the indexes of the items, e.g. hosts.0 and hosts.1
+func (b *binder) bindIndexed(key string, rv reflect.Value, format string) bool {
+	st := indirectType(rv.Type())
+	nested := isNested(indirectType(st.Elem()))
+	list := reflect.MakeSlice(st, 0, 0)
+	valid := true
+	for i := 0; ; i++ {
+		k := joinKey(key, strconv.Itoa(i))
+		item := reflect.New(st.Elem()).Elem()
+		if nested {
+			if b.keys == nil || len(b.children(k)) == 0 {
+				break
+			}
+			b.bindValue(k, item, nil)
+		} else if raw, ok := b.lookup(k); !ok {
+			break
+		} else if err := setValue(item, raw, format); err != nil {
+			b.fail(k, fmt.Errorf("invalid value %q: %v", raw, err))
+			valid = false
+		}
+		list = reflect.Append(list, item)
+	}
+	if valid && list.Len() > 0 {
+		setIndirect(rv, list)
+	}
+	return valid
+}
+
+//bindEntries sets the entries of the map rv from the parsed key=value entries
+func (b *binder) bindEntries(key string, rv reflect.Value, entries map[string]string, format string) bool {
+	mt := indirectType(rv.Type())
+	m := reflect.MakeMapWithSize(mt, len(entries))
+	valid := true
+	for k, v := range entries {
+		mk := reflect.New(mt.Key()).Elem()
+		mv := reflect.New(mt.Elem()).Elem()
+		if err := setValue(mk, k, textutils.EmptyStr); err != nil {
+			b.fail(key, fmt.Errorf("invalid key %q: %v", k, err))
+			valid = false
+			continue
+		}
+		if err := setValue(mv, v, format); err != nil {
+			b.fail(joinKey(key, k), fmt.Errorf("invalid value %q: %v", v, err))
+			valid = false
+			continue
+		}
+		m.SetMapIndex(mk, mv)
+	}
+	if valid {
+		setIndirect(rv, m)
+	}
+	return valid
+}
+
+//bindChildren sets the entries of the map rv from the keys below the key, e.g. labels.env and labels.tier
+func (b *binder) bindChildren(key string, rv reflect.Value, format string) bool {
+	mt := indirectType(rv.Type())
+	nested := isNested(indirectType(mt.Elem()))
+	m := reflect.MakeMap(mt)
+	valid := true
+	for _, name := range b.children(key) {
+		k := joinKey(key, name)
+		item := reflect.New(mt.Elem()).Elem()
+		if nested {
+			b.bindValue(k, item, nil)
+		} else if raw, ok := b.lookup(k); !ok {
+			continue
+		} else if err := setValue(item, raw, format); err != nil {
+			b.fail(k, fmt.Errorf("invalid value %q: %v", raw, err))
+			valid = false
+			continue
+		}
+		m.SetMapIndex(reflect.ValueOf(name).Convert(mt.Key()), item)
+	}
+	if valid && m.Len() > 0 {
+		setIndirect(rv, m)
+	}
+	return valid
+}
+
+//setValue parses s in to rv according to its type and the format. Nil pointers are allocated.
+func setValue(rv reflect.Value, s, format string) error {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	switch {
+	case rv.Type() == timeType:
+		t, err := codec.ParseTime(s, format)
+		if err == nil {
+			rv.Set(reflect.ValueOf(t))
+		}
+		return err
+	case rv.Type() == durationType:
+		d, err := codec.ParseDuration(s, format)
+		if err == nil {
+			rv.SetInt(int64(d))
+		}
+		return err
+	case rv.Type() == urlType:
+		u, err := parseURL(s)
+		if err == nil {
+			rv.Set(reflect.ValueOf(*u))
+		}
+		return err
+	case format == formatBytes:
+		n, err := parseByteSize(s)
+		if err != nil {
+			return err
+		}
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if rv.OverflowInt(n) {
+				return fmt.Errorf("%s overflows %s", s, rv.Type())
+			}
+			rv.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if rv.OverflowUint(uint64(n)) {
+				return fmt.Errorf("%s overflows %s", s, rv.Type())
+			}
+			rv.SetUint(uint64(n))
+		default:
+			return fmt.Errorf("the bytes format cannot be used with type %s", rv.Type())
+		}
+		return nil
+	}
+	return codec.SetFromString(rv, strings.TrimSpace(s))
+}
+
+//fieldKey returns the name of the field in the keys, false if the field is tagged with config:"-"
+func fieldKey(t reflect.Type, f *codec.FieldMeta) (string, bool) {
+	tag := t.FieldByIndex(f.Index).Tag.Get("config")
+	switch tag {
+	case textutils.HyphenStr:
+		return textutils.EmptyStr, false
+	case textutils.EmptyStr:
+		return f.TargetName(codec.JSONTarget), true
+	}
+	return tag, true
+}
+
+//isNested checks if the values of the type t are bound from the keys below their name
+func isNested(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && t != urlType &&
+		!reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+//fieldByIndex returns the nested field of the struct rv allocating the nil embedded pointers on the way. false is
+//returned if an embedded pointer cannot be allocated.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, false
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, rv.CanSet()
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+//setIndirect sets v on rv allocating the pointers to it
+func setIndirect(rv reflect.Value, v reflect.Value) {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	rv.Set(v)
+}
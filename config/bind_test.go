@@ -0,0 +1,229 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.codemanch.com/commons/codec"
+)
+
+type bindServer struct {
+	Name    string `config:"name" required:"true" pattern:"^[a-z]+$"`
+	Address string `json:"address" default:"localhost"`
+}
+
+type bindDB struct {
+	Host    string            `config:"host" required:"true"`
+	Port    int               `config:"port" default:"5432" min:"1" max:"65535"`
+	Timeout time.Duration     `config:"timeout" default:"5s"`
+	Buffer  int64             `config:"buffer" format:"bytes" default:"1MiB"`
+	Hosts   []string          `config:"hosts"`
+	Labels  map[string]string `config:"labels"`
+	Ignored string            `config:"-"`
+}
+
+type bindApp struct {
+	DB      bindDB         `config:"db"`
+	Servers []bindServer   `config:"servers"`
+	Ports   []int          `config:"ports"`
+	Limits  map[string]int `config:"limits"`
+	Cache   *struct {
+		Size int `config:"size"`
+	} `config:"cache"`
+	Metrics *struct {
+		Enabled bool `config:"enabled"`
+	} `config:"metrics"`
+	Started time.Time `config:"started" format:"unix"`
+	Debug   bool      `config:"debug"`
+}
+
+func TestBind(t *testing.T) {
+	p := NewProperties()
+	_, err := p.ReadFrom(strings.NewReader(`app.db.host=db.local
+app.db.timeout=1m30s
+app.db.buffer=512MiB
+app.db.hosts=a, b
+app.db.labels=env=prod, tier=web
+app.db.ignored=x
+app.servers.0.name=web
+app.servers.0.address=10.0.0.1
+app.servers.1.name=api
+app.ports.0=80
+app.ports.1=443
+app.limits.cpu=2
+app.limits.memory=4
+app.cache.size=10
+app.started=1577934245
+APP.DEBUG=true`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := bindApp{Debug: false}
+	app.DB.Ignored = "kept"
+	if err := Bind(p, "app", &app); err != nil {
+		t.Fatal(err)
+	}
+	db := app.DB
+	if db.Host != "db.local" || db.Port != 5432 || db.Timeout != 90*time.Second || db.Buffer != 512<<20 ||
+		strings.Join(db.Hosts, "|") != "a|b" || db.Labels["tier"] != "web" || db.Ignored != "kept" {
+		t.Errorf("Bind() db = %+v", db)
+	}
+	if len(app.Servers) != 2 || app.Servers[0] != (bindServer{Name: "web", Address: "10.0.0.1"}) ||
+		app.Servers[1] != (bindServer{Name: "api", Address: "localhost"}) {
+		t.Errorf("Bind() servers = %+v", app.Servers)
+	}
+	if len(app.Ports) != 2 || app.Ports[1] != 443 || app.Limits["memory"] != 4 || len(app.Limits) != 2 {
+		t.Errorf("Bind() ports = %v, limits = %v", app.Ports, app.Limits)
+	}
+	if app.Cache == nil || app.Cache.Size != 10 || app.Metrics != nil {
+		t.Errorf("Bind() cache = %+v, metrics = %+v", app.Cache, app.Metrics)
+	}
+	if !app.Started.Equal(time.Unix(1577934245, 0)) || !app.Debug {
+		t.Errorf("Bind() started = %v, debug = %v", app.Started, app.Debug)
+	}
+}
+
+func TestBind_Errors(t *testing.T) {
+	p := NewProperties()
+	_, err := p.ReadFrom(strings.NewReader(`db.port=70000
+db.timeout=soon
+db.buffer=lots
+db.labels=env
+servers.0.name=Web
+ports.0=80
+ports.1=x`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var app bindApp
+	err = Bind(p, "", &app)
+	errs, ok := err.(codec.ValidationErrors)
+	if !ok {
+		t.Fatalf("Bind() error = %v, want codec.ValidationErrors", err)
+	}
+	want := []string{
+		"db.host: is required",
+		"db.port: must be less than or equal to 65535",
+		`db.timeout: invalid value "soon"`,
+		`db.buffer: invalid value "lots": config: invalid byte size "lots"`,
+		`db.labels: config: invalid map entry "env", expected key=value`,
+		"servers.0.name: must match the pattern ^[a-z]+$",
+		`ports.1: invalid value "x"`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Bind() errors = %v, want %d errors", errs, len(want))
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(errs[i].Error(), w) {
+			t.Errorf("Bind() error %d = %q, want %q", i, errs[i], w)
+		}
+	}
+	if err := Bind(p, "", app); err == nil {
+		t.Error("Bind() expected an error for a non pointer target")
+	}
+}
+
+func TestBind_LayeredConfig(t *testing.T) {
+	c, err := NewLayeredConfig(NewMapSource("defaults", map[string]string{"db.host": "defaults.local"}),
+		NewArgsSource([]string{"--db.port=6432", "--db.hosts.0=x", "--db.hosts.1=y"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var db bindDB
+	if err := Bind(c, "db", &db); err != nil {
+		t.Fatal(err)
+	}
+	if db.Host != "defaults.local" || db.Port != 6432 || strings.Join(db.Hosts, "|") != "x|y" {
+		t.Errorf("Bind() = %+v", db)
+	}
+}
+
+type bindSchedule struct {
+	Start    time.Time     `config:"start" format:"unix" min:"1577836800"`
+	Day      time.Time     `config:"day" format:"2006-01-02"`
+	Interval time.Duration `config:"interval" format:"iso8601" max:"P1D"`
+}
+
+func TestBind_TimeFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bind")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"schedule.yaml":       "job:\n  start: 1622505600\n  day: 2021-07-01\n  interval: PT1H30M\n",
+		"schedule.properties": "job.start=1622505600\njob.day=2021-07-01\njob.interval=PT1H30M\n",
+		"invalid.yaml":        "job:\n  start: 1000\n  day: 07/01/2021\n  interval: P2D\n",
+	}
+	want := bindSchedule{
+		Start:    time.Unix(1622505600, 0).UTC(),
+		Day:      time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC),
+		Interval: 90 * time.Minute,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err = ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		src, err := NewFileSource(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := NewLayeredConfig(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got bindSchedule
+		err = Bind(c, "job", &got)
+		if strings.HasPrefix(name, "invalid") {
+			if ve, ok := err.(codec.ValidationErrors); !ok || len(ve) != 3 {
+				t.Errorf("%s: Bind() error = %v, want 3 violations", name, err)
+			}
+			continue
+		}
+		if err != nil || !got.Start.Equal(want.Start) || !got.Day.Equal(want.Day) || got.Interval != want.Interval {
+			t.Errorf("%s: Bind() = %+v, %v", name, got, err)
+		}
+	}
+}
+
+type bindNode struct {
+	Name  string    `config:"name" default:"unnamed"`
+	Next  *bindNode `config:"next"`
+	Other *bindNode `config:"other"`
+}
+
+//bindGetOnly hides the Keys and Lookup methods of the configuration
+type bindGetOnly struct {
+	Configuration
+}
+
+func TestBind_Pointers(t *testing.T) {
+	p := NewProperties()
+	p.Put("node.name", "\x00")
+	p.Put("node.next.name", "b")
+	p.Put("app.cache.size", "10")
+	for _, cfg := range []Configuration{p, bindGetOnly{p}} {
+		var n bindNode
+		if err := Bind(cfg, "node", &n); err != nil {
+			t.Fatal(err)
+		}
+		if n.Name != "\x00" || n.Other != nil {
+			t.Errorf("Bind(%T) = %+v", cfg, n)
+		}
+		//the required db.host is missing and the metrics have no key
+		var app bindApp
+		if err := Bind(cfg, "app", &app); err == nil || app.Cache == nil || app.Cache.Size != 10 || app.Metrics != nil {
+			t.Errorf("Bind(%T) cache = %+v, metrics = %+v, error = %v", cfg, app.Cache, app.Metrics, err)
+		}
+	}
+	//the self referencing pointers are allocated only if the keys can be listed
+	var n bindNode
+	if err := Bind(p, "node", &n); err != nil || n.Next == nil || n.Next.Name != "b" || n.Next.Next != nil {
+		t.Errorf("Bind() next = %+v, %v", n.Next, err)
+	}
+}
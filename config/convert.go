@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.codemanch.com/commons/codec"
+	"go.codemanch.com/commons/textutils"
+)
+
+//byteUnits holds the multiplier of the units of a byte size. The SI units are powers of 1000, the IEC units and the
+//single letter units, as used by the JVM, are powers of 1024.
+var byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1e9,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1e12,
+	"tib": 1 << 40,
+	"p":   1 << 50,
+	"pb":  1e15,
+	"pib": 1 << 50,
+}
+
+//parseByteSize parses a size in bytes with an optional unit, e.g. 512MiB, 1.5GB or 64k. The units are case
+//insensitive. KB, MB, GB, TB and PB are powers of 1000 while KiB, MiB, GiB, TiB, PiB and the single letters K, M, G, T
+//and P are powers of 1024.
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == textutils.PeriodChar) {
+		i++
+	}
+	unit, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if i == 0 || !ok {
+		return 0, fmt.Errorf("config: invalid byte size %q", s)
+	}
+	number := s[:i]
+	if strings.IndexByte(number, textutils.PeriodChar) == -1 {
+		n, err := strconv.ParseInt(number, 10, 64)
+		if err != nil || n > math.MaxInt64/unit {
+			return 0, fmt.Errorf("config: byte size %q is out of range", s)
+		}
+		return n * unit, nil
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("config: invalid byte size %q", s)
+	}
+	if f*float64(unit) >= math.MaxInt64 {
+		return 0, fmt.Errorf("config: byte size %q is out of range", s)
+	}
+	return int64(f * float64(unit)), nil
+}
+
+//parseList splits the value at the separator. If trim is true the whitespace around the items is removed and
+//the empty items are dropped. An empty value is an empty list.
+func parseList(s, sep string, trim bool) []string {
+	if s == textutils.EmptyStr {
+		return []string{}
+	}
+	items := strings.Split(s, sep)
+	if !trim {
+		return items
+	}
+	list := items[:0]
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != textutils.EmptyStr {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+//parseMap parses a map written as comma separated key=value entries, e.g. "a=1, b=2". The whitespace around
+//the keys and values is removed.
+func parseMap(s string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, entry := range parseList(s, textutils.CommaStr, true) {
+		idx := strings.IndexByte(entry, textutils.EqualChar)
+		if idx == -1 {
+			return nil, fmt.Errorf("config: invalid map entry %q, expected key=value", entry)
+		}
+		m[strings.TrimSpace(entry[:idx])] = strings.TrimSpace(entry[idx+1:])
+	}
+	return m, nil
+}
+
+//parseURL parses an absolute URL, e.g. https://example.com/api
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if !u.IsAbs() {
+		return nil, fmt.Errorf("config: %q is not an absolute URL", s)
+	}
+	return u, nil
+}
+
+//parseTime parses the time with the layout, which is a Go layout or one of the formats of the codec package, e.g.
+//codec.FormatUnix, as done by Bind for the format tag. RFC 3339 is used if the layout is empty.
+func parseTime(s, layout string) (time.Time, error) {
+	return codec.ParseTime(strings.TrimSpace(s), layout)
+}
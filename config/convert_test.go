@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.codemanch.com/commons/codec"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{input: "1024", want: 1024},
+		{input: "10B", want: 10},
+		{input: "512MiB", want: 512 << 20},
+		{input: "512 mib", want: 512 << 20},
+		{input: "1.5GB", want: 1500000000},
+		{input: "64k", want: 64 << 10},
+		{input: "2KB", want: 2000},
+		{input: "1PiB", want: 1 << 50},
+		{input: "", wantErr: true},
+		{input: "MiB", wantErr: true},
+		{input: "12XB", wantErr: true},
+		{input: "1.2.3MB", wantErr: true},
+		{input: "9000PiB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseByteSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseByteSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseByteSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProperties_TypedGetters(t *testing.T) {
+	p := NewProperties()
+	_, err := p.ReadFrom(strings.NewReader("timeout=1m30s\nbuffer=512MiB\nhosts= a, b ,,c\nlabels=env=prod, tier = web\n" +
+		"endpoint=https://example.com/api\nstart=2020-01-02T03:04:05Z\nday=2020-01-02\nepoch=1577934245\nbad=x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := p.GetAsDuration("timeout", 0); err != nil || got != 90*time.Second {
+		t.Errorf("GetAsDuration() = %v, %v", got, err)
+	}
+	if got, err := p.GetAsByteSize("buffer", 0); err != nil || got != 512<<20 {
+		t.Errorf("GetAsByteSize() = %v, %v", got, err)
+	}
+	if got := p.GetAsList("hosts", ",", true, nil); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("GetAsList() = %q", got)
+	}
+	if got := p.GetAsList("hosts", ",", false, nil); !reflect.DeepEqual(got, []string{"a", " b ", "", "c"}) {
+		t.Errorf("GetAsList() untrimmed = %q", got)
+	}
+	if got, err := p.GetAsMap("labels", nil); err != nil ||
+		!reflect.DeepEqual(got, map[string]string{"env": "prod", "tier": "web"}) {
+		t.Errorf("GetAsMap() = %v, %v", got, err)
+	}
+	if got, err := p.GetAsURL("endpoint", nil); err != nil || got.Host != "example.com" || got.Path != "/api" {
+		t.Errorf("GetAsURL() = %v, %v", got, err)
+	}
+	if got, err := p.GetAsTime("start", "", time.Time{}); err != nil ||
+		!got.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("GetAsTime() = %v, %v", got, err)
+	}
+	if got, err := p.GetAsTime("day", "2006-01-02", time.Time{}); err != nil ||
+		!got.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("GetAsTime() with layout = %v, %v", got, err)
+	}
+	if got, err := p.GetAsTime("epoch", codec.FormatUnix, time.Time{}); err != nil ||
+		!got.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("GetAsTime() with format = %v, %v", got, err)
+	}
+	if got := p.GetAsList("missing", ",", true, []string{"d"}); !reflect.DeepEqual(got, []string{"d"}) {
+		t.Errorf("GetAsList() default = %q", got)
+	}
+	if got, err := p.GetAsDuration("missing", time.Second); err != nil || got != time.Second {
+		t.Errorf("GetAsDuration() default = %v, %v", got, err)
+	}
+	if _, err := p.GetAsDuration("bad", 0); err == nil {
+		t.Error("GetAsDuration() expected an error")
+	}
+	if _, err := p.GetAsMap("bad", nil); err == nil {
+		t.Error("GetAsMap() expected an error")
+	}
+	if _, err := p.GetAsURL("bad", nil); err == nil {
+		t.Error("GetAsURL() expected an error")
+	}
+}
+
+func TestGetEnvAsTyped(t *testing.T) {
+	os.Setenv("CONFIG_TEST_TIMEOUT", "250ms")
+	os.Setenv("CONFIG_TEST_SIZE", "2GiB")
+	os.Setenv("CONFIG_TEST_LIST", "a;b")
+	defer os.Unsetenv("CONFIG_TEST_TIMEOUT")
+	defer os.Unsetenv("CONFIG_TEST_SIZE")
+	defer os.Unsetenv("CONFIG_TEST_LIST")
+	if got, err := GetEnvAsDuration("CONFIG_TEST_TIMEOUT", 0); err != nil || got != 250*time.Millisecond {
+		t.Errorf("GetEnvAsDuration() = %v, %v", got, err)
+	}
+	if got, err := GetEnvAsByteSize("CONFIG_TEST_SIZE", 0); err != nil || got != 2<<30 {
+		t.Errorf("GetEnvAsByteSize() = %v, %v", got, err)
+	}
+	if got := GetEnvAsList("CONFIG_TEST_LIST", ";", true, nil); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("GetEnvAsList() = %q", got)
+	}
+	if got, err := GetEnvAsMap("CONFIG_TEST_MISSING", map[string]string{"a": "1"}); err != nil || got["a"] != "1" {
+		t.Errorf("GetEnvAsMap() default = %v, %v", got, err)
+	}
+}
@@ -2,8 +2,10 @@ package config
 
 //This program contains utility functions related to environment variables
 import (
+	"net/url"
 	"os"
 	"strconv"
+	"time"
 )
 
 //GetEnvAsString function will fetch the value from environment variable.
@@ -52,3 +54,57 @@ func GetEnvAsBool(key string, defaultVal bool) (bool, error) {
 	}
 	return defaultVal, nil
 }
+
+//GetEnvAsDuration function will fetch the value from environment variable and convert that to a time.Duration, e.g.
+//1m30s. If the value is absent then it will return defaultVal supplied.
+func GetEnvAsDuration(key string, defaultVal time.Duration) (time.Duration, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return time.ParseDuration(value)
+	}
+	return defaultVal, nil
+}
+
+//GetEnvAsByteSize function will fetch the value from environment variable and convert that to a number of bytes, e.g.
+//512MiB or 1.5GB. If the value is absent then it will return defaultVal supplied.
+func GetEnvAsByteSize(key string, defaultVal int64) (int64, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return parseByteSize(value)
+	}
+	return defaultVal, nil
+}
+
+//GetEnvAsList function will fetch the value from environment variable and split it at the separator. If trim is true
+//the items are trimmed and the empty items dropped. If the value is absent then it will return defaultVal supplied.
+func GetEnvAsList(key, sep string, trim bool, defaultVal []string) []string {
+	if value, ok := os.LookupEnv(key); ok {
+		return parseList(value, sep, trim)
+	}
+	return defaultVal
+}
+
+//GetEnvAsMap function will fetch the value from environment variable and convert the comma separated key=value
+//entries to a map. If the value is absent then it will return defaultVal supplied.
+func GetEnvAsMap(key string, defaultVal map[string]string) (map[string]string, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return parseMap(value)
+	}
+	return defaultVal, nil
+}
+
+//GetEnvAsURL function will fetch the value from environment variable and convert that to an absolute URL.
+//If the value is absent then it will return defaultVal supplied.
+func GetEnvAsURL(key string, defaultVal *url.URL) (*url.URL, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return parseURL(value)
+	}
+	return defaultVal, nil
+}
+
+//GetEnvAsTime function will fetch the value from environment variable and parse it with the layout, time.RFC3339 if
+//the layout is empty. If the value is absent then it will return defaultVal supplied.
+func GetEnvAsTime(key, layout string, defaultVal time.Time) (time.Time, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return parseTime(value, layout)
+	}
+	return defaultVal, nil
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+const (
+	//defaultOperator separates the name of a variable from the value used when it is absent or empty, ${key:-default}
+	defaultOperator = ":-"
+	//requiredOperator separates the name of a variable from the error message used when it is absent or empty,
+	//${key:?message}
+	requiredOperator = ":?"
+)
+
+//interpolate replaces the variables ${key} in s with the resolved value of the key.
+//The name of a variable may contain variables, e.g. ${db.${env}.url}, ${key:-default} uses the default when the key is
+//absent or empty and ${key:?message} fails with the message in that case. The names with the prefix of a registered
+//Resolver, e.g. ${env:HOME}, are resolved by it instead of the properties. A variable without default whose key is
+//absent is kept as written. \${ is written as ${ without resolving it.
+//chain holds the keys being resolved to detect the cycles and memo the values of the keys already resolved, so that
+//each key is interpolated once. The variables with a Resolver prefix are handled as absent if restricted is true.
+//The caller must hold the lock of the properties.
+func (p *Properties) interpolate(s string, chain []string, memo map[string]string, restricted bool) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == textutils.BackSlashChar && strings.HasPrefix(s[i+1:], "${") {
+			sb.WriteString("${")
+			i += 2
+			continue
+		}
+		if c != textutils.DollarChar || !strings.HasPrefix(s[i:], "${") {
+			sb.WriteByte(c)
+			continue
+		}
+		end := closingBrace(s, i+2)
+		if end == -1 {
+			//an unterminated variable is kept as written
+			sb.WriteString(s[i:])
+			break
+		}
+		resolved, err := p.resolveVariable(s[i+2:end], chain, memo, restricted)
+		if err != nil {
+			return s, err
+		}
+		sb.WriteString(resolved)
+		i = end
+	}
+	return sb.String(), nil
+}
+
+//resolveVariable resolves the expression between ${ and }
+func (p *Properties) resolveVariable(expr string, chain []string, memo map[string]string,
+	restricted bool) (string, error) {
+	name, op, arg := splitVariable(expr)
+	name, err := p.interpolate(name, chain, memo, restricted)
+	if err != nil {
+		return expr, err
+	}
+	for i, k := range chain {
+		if k == name {
+			return expr, fmt.Errorf("properties: cyclic reference %s",
+				strings.Join(append(chain[i:len(chain):len(chain)], name), " -> "))
+		}
+	}
+	var resolved string
+	var ok bool
+	r, n, isResolver := lookupResolver(name)
+	cached, isCached := memo[name]
+	v, exists := p.props[name]
+	switch {
+	case isResolver && restricted:
+		//a value of a restricted source cannot read files or the environment, the variable is handled as absent
+	case isResolver:
+		if resolved, ok, err = r.Resolve(n); err != nil {
+			return expr, fmt.Errorf("properties: %s: %v", name, err)
+		}
+	case isCached:
+		resolved, ok = cached, true
+	case exists:
+		ok = true
+		next := append(chain[:len(chain):len(chain)], name)
+		if resolved, err = p.interpolate(v.raw, next, memo, v.restricted); err != nil {
+			return expr, err
+		}
+		//a value resolved without error does not depend on the chain
+		memo[name] = resolved
+	}
+	switch op {
+	case defaultOperator:
+		if !ok || resolved == textutils.EmptyStr {
+			return p.interpolate(arg, chain, memo, restricted)
+		}
+	case requiredOperator:
+		if !ok || resolved == textutils.EmptyStr {
+			msg, err := p.interpolate(arg, chain, memo, restricted)
+			if err != nil {
+				return expr, err
+			}
+			if msg == textutils.EmptyStr {
+				msg = "is not set"
+			}
+			return expr, fmt.Errorf("properties: %s: %s", name, msg)
+		}
+	default:
+		if !ok {
+			return "${" + name + "}", nil
+		}
+	}
+	return resolved, nil
+}
+
+//splitVariable splits the expression of a variable in to the name, the operator and its argument. Operators inside
+//nested variables of the name are ignored.
+func splitVariable(expr string) (string, string, string) {
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case strings.HasPrefix(expr[i:], "${"):
+			depth++
+			i++
+		case expr[i] == textutils.CloseBraceChar && depth > 0:
+			depth--
+		case depth == 0 && (strings.HasPrefix(expr[i:], defaultOperator) ||
+			strings.HasPrefix(expr[i:], requiredOperator)):
+			return expr[:i], expr[i : i+2], expr[i+2:]
+		}
+	}
+	return expr, textutils.EmptyStr, textutils.EmptyStr
+}
+
+//closingBrace returns the index of the } closing the variable whose name starts at start or -1 if there is none
+func closingBrace(s string, start int) int {
+	depth := 1
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == textutils.BackSlashChar && strings.HasPrefix(s[i+1:], "${"):
+			i += 2
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == textutils.CloseBraceChar:
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
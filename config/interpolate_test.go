@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestProperties_Interpolate(t *testing.T) {
+	doc := `env=prod
+db.prod.url=jdbc:prod
+db.dev.url=jdbc:dev
+url=${db.${env}.url}
+host=${host.name:-localhost}:${port:-8080}
+port=
+name=${missing}
+nested=${missing:-${env}-${db.${env:-dev}.url}}
+escaped=\\${env} and ${env}
+single.escaped=\${env} costs \$5
+unterminated=${env
+empty.default=${missing:-}
+literal=$env {env} $`
+	p := NewProperties()
+	if _, err := p.ReadFrom(strings.NewReader(doc)); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"url":            "jdbc:prod",
+		"host":           "localhost:8080",
+		"name":           "${missing}",
+		"nested":         "prod-jdbc:prod",
+		"escaped":        "${env} and prod",
+		"single.escaped": "${env} costs $5",
+		"unterminated":   "${env",
+		"empty.default":  "",
+		"literal":        "$env {env} $",
+	}
+	for k, v := range want {
+		if got := p.Get(k, "absent"); got != v {
+			t.Errorf("Get(%s) = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestProperties_InterpolateMemo(t *testing.T) {
+	//each key references the next one twice, which takes 2^40 interpolations without memoization
+	var sb strings.Builder
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&sb, "k%d=${k%d}${k%d}\n", i, i+1, i+1)
+	}
+	sb.WriteString("k40=")
+	p := NewProperties()
+	if _, err := p.ReadFrom(strings.NewReader(sb.String())); err != nil {
+		t.Fatal(err)
+	}
+	p.Put("k0", "${k1}")
+	if got, err := p.Resolve("k0", "absent"); err != nil || got != "" {
+		t.Errorf("Resolve(k0) = %q, %v", got, err)
+	}
+}
+
+func TestProperties_InterpolateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		key     string
+		wantErr string
+	}{
+		{name: "Self", doc: "a=${a}", key: "a", wantErr: "properties: cyclic reference a -> a"},
+		{name: "Cycle", doc: "a=x${b}\nb=${c}\nc=${a}", key: "a", wantErr: "properties: cyclic reference a -> b -> c -> a"},
+		{name: "InnerCycle", doc: "a=${b}\nb=${c}\nc=${b}", key: "a", wantErr: "properties: cyclic reference b -> c -> b"},
+		{name: "NestedName", doc: "a=${${a}}", key: "a", wantErr: "properties: cyclic reference a -> a"},
+		{name: "Required", doc: "a=${db.url:?the database url must be set}", key: "a",
+			wantErr: "properties: db.url: the database url must be set"},
+		{name: "RequiredEmpty", doc: "a=${b:?}\nb=", key: "a", wantErr: "properties: b: is not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProperties()
+			_, err := p.ReadFrom(strings.NewReader(tt.doc))
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ReadFrom() error = %v, want %s", err, tt.wantErr)
+			}
+			if _, err = p.Resolve(tt.key, ""); err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Resolve() error = %v, want %s", err, tt.wantErr)
+			}
+			if got := p.Get(tt.key, ""); got != p.props[tt.key].raw {
+				t.Errorf("Get() = %q, want the value as written", got)
+			}
+		})
+	}
+	p := NewProperties()
+	p.Put("a", "${b:?required}")
+	p.Put("b", "set")
+	if got, err := p.Resolve("a", ""); err != nil || got != "set" {
+		t.Errorf("Resolve() = %s, %v", got, err)
+	}
+}
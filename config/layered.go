@@ -0,0 +1,395 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+var _ Configuration = (*LayeredConfig)(nil)
+
+//LayeredConfig struct is a Configuration whose values are merged from a stack of sources. A source added later takes
+//precedence over the sources added before it and the values set with Put or read with ReadFrom take precedence over
+//all the sources. The variables are resolved across the layers, e.g. a file may reference ${db.host} set by an
+//environment variable. The values of a RestrictedSource, such as the environment and the command line, cannot use
+//the resolvers, e.g. ${file:path}.
+type LayeredConfig struct {
+	sources []Source
+	//overrides holds the values set with Put and read with ReadFrom
+	overrides map[string]Origin
+	//origins holds the values of each key by increasing precedence
+	origins   map[string][]Origin
+	props     *Properties
+	listeners listeners
+	//profiles are the active profiles of a config created by NewProfiledConfig
+	profiles []string
+	sync.RWMutex
+}
+
+//Origin struct describes a value supplied by a layer of a LayeredConfig
+type Origin struct {
+	//Source is the name of the source, e.g. the path of a file, or Put and ReadFrom for the values set on the config
+	Source string `json:"source"`
+	//Location is where the value was read in the source if known, e.g. app.yaml:12, APP_DB_HOST or --db.host
+	Location string `json:"location,omitempty"`
+	//Value is the value as written in the source
+	Value string `json:"value"`
+}
+
+//String returns the source and location of the origin
+func (o Origin) String() string {
+	if o.Location == textutils.EmptyStr || o.Location == o.Source {
+		return o.Source
+	}
+	if strings.HasPrefix(o.Location, o.Source) {
+		return o.Location
+	}
+	return o.Source + textutils.WhiteSpaceStr + o.Location
+}
+
+//Provenance struct explains where the value of a key of a LayeredConfig came from
+type Provenance struct {
+	Key string `json:"key"`
+	//Origin is the layer whose value is used
+	Origin Origin `json:"origin"`
+	//Overridden are the values of the layers with a lower precedence, the highest first
+	Overridden []Origin `json:"overridden,omitempty"`
+	//Resolved is the value with its variables resolved
+	Resolved string `json:"resolved"`
+	//Error is the error resolving the variables of the value
+	Error string `json:"error,omitempty"`
+}
+
+//NewLayeredConfig function creates a LayeredConfig of the sources in increasing order of precedence and loads them.
+//The conventional order is the defaults, the configuration files, the .env files, the environment variables and the
+//command line flags, e.g.
+//  NewLayeredConfig(NewMapSource("defaults", defaults), NewYAMLFileSource("app.yaml"), NewEnvSource("APP_"),
+//  NewArgsSource(os.Args[1:]))
+func NewLayeredConfig(sources ...Source) (*LayeredConfig, error) {
+	c := &LayeredConfig{
+		sources:   sources,
+		overrides: make(map[string]Origin),
+		origins:   make(map[string][]Origin),
+		props:     NewProperties(),
+	}
+	return c, c.Reload()
+}
+
+//Reload function loads the values of all the sources again. If a source cannot be loaded or a variable cannot be
+//resolved the error is returned and the values loaded earlier are kept.
+func (c *LayeredConfig) Reload() error {
+	c.Lock()
+	defer c.unlockAndNotify(c.props.resolvedProps)
+	props := NewProperties()
+	origins := make(map[string][]Origin)
+	for _, s := range c.sources {
+		var values, locations map[string]string
+		var err error
+		if ls, ok := s.(LocatedSource); ok {
+			values, locations, err = ls.LoadLocated()
+		} else {
+			values, err = s.Load()
+		}
+		if err != nil {
+			return fmt.Errorf("config: loading %s: %v", s.Name(), err)
+		}
+		rs, ok := s.(RestrictedSource)
+		restricted := ok && rs.Restricted()
+		for k, v := range values {
+			props.props[k] = createValue(k, v)
+			props.props[k].restricted = restricted
+			origins[k] = append(origins[k], Origin{Source: s.Name(), Location: locations[k], Value: v})
+		}
+	}
+	for k, o := range c.overrides {
+		props.props[k] = createValue(k, o.Value)
+		origins[k] = append(origins[k], o)
+	}
+	if err := props.resolveAll(); err != nil {
+		return err
+	}
+	c.props = props
+	c.origins = origins
+	return nil
+}
+
+//current returns the merged properties
+func (c *LayeredConfig) current() *Properties {
+	c.RLock()
+	defer c.RUnlock()
+	return c.props
+}
+
+//override records the value of the key set on the merged properties so that it is kept by Reload
+func (c *LayeredConfig) override(k string) {
+	p := c.props
+	p.RLock()
+	v, ok := p.props[k]
+	p.RUnlock()
+	if ok {
+		c.setOverride(k, Origin{Source: "Put", Value: v.raw})
+	}
+}
+
+//setOverride sets the origin of a value set on the config over the origins of the sources
+func (c *LayeredConfig) setOverride(k string, o Origin) {
+	origins := c.origins[k]
+	if _, ok := c.overrides[k]; ok && len(origins) > 0 {
+		origins = origins[:len(origins)-1]
+	}
+	c.overrides[k] = o
+	c.origins[k] = append(origins, o)
+}
+
+//Explain function returns where the value of the key came from. false is returned if no layer has a value for the key.
+func (c *LayeredConfig) Explain(k string) (Provenance, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.explain(k)
+}
+
+func (c *LayeredConfig) explain(k string) (Provenance, bool) {
+	origins := c.origins[k]
+	if len(origins) == 0 {
+		return Provenance{}, false
+	}
+	pv := Provenance{Key: k, Origin: origins[len(origins)-1]}
+	for i := len(origins) - 2; i >= 0; i-- {
+		pv.Overridden = append(pv.Overridden, origins[i])
+	}
+	resolved, err := c.props.Resolve(k, textutils.EmptyStr)
+	if err != nil {
+		pv.Resolved, pv.Error = c.props.Get(k, textutils.EmptyStr), err.Error()
+	} else {
+		pv.Resolved = resolved
+	}
+	return pv, true
+}
+
+//ExplainAll function returns where the values of all the keys came from sorted by key
+func (c *LayeredConfig) ExplainAll() []Provenance {
+	c.RLock()
+	defer c.RUnlock()
+	keys := make([]string, 0, len(c.origins))
+	for k := range c.origins {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	all := make([]Provenance, 0, len(keys))
+	for _, k := range keys {
+		pv, _ := c.explain(k)
+		all = append(all, pv)
+	}
+	return all
+}
+
+//WriteProvenance function writes where the values of all the keys came from, sorted by key, e.g.
+//  db.url = "jdbc:db.local:5432"
+//    raw "jdbc:${db.host}:${db.port}"
+//    from app.yaml:4 "jdbc:${db.host}:${db.port}"
+//    overrides defaults "jdbc:h2:mem"
+//The raw value is written when it differs from the resolved value and the error when it cannot be resolved.
+//This function does not close the writer and it is the responsibility of the caller to close the writer
+func (c *LayeredConfig) WriteProvenance(w io.Writer) error {
+	bufWriter := bufio.NewWriter(w)
+	for _, pv := range c.ExplainAll() {
+		fmt.Fprintf(bufWriter, "%s = %q\n", pv.Key, pv.Resolved)
+		if pv.Origin.Value != pv.Resolved {
+			fmt.Fprintf(bufWriter, "  raw %q\n", pv.Origin.Value)
+		}
+		if pv.Error != textutils.EmptyStr {
+			fmt.Fprintf(bufWriter, "  error %s\n", pv.Error)
+		}
+		fmt.Fprintf(bufWriter, "  from %s %q\n", pv.Origin, pv.Origin.Value)
+		for _, o := range pv.Overridden {
+			fmt.Fprintf(bufWriter, "  overrides %s %q\n", o, o.Value)
+		}
+	}
+	return bufWriter.Flush()
+}
+
+//ReadFrom function will read the properties from a io.Reader in the .properties format. The values read take
+//precedence over the sources. The values are read even if the variables of some values cannot be resolved and the
+//first such error is returned.
+//The number of bytes read is returned.
+//This function does not close the reader and it is the responsibility of the caller to close the reader
+func (c *LayeredConfig) ReadFrom(r io.Reader) (int64, error) {
+	b, err := ioutil.ReadAll(r)
+	n := int64(len(b))
+	if err != nil {
+		return n, err
+	}
+	values, lines, err := parseProperties(string(b))
+	if err != nil {
+		return n, err
+	}
+	c.Lock()
+	defer c.unlockAndNotify(c.props.resolvedProps)
+	//the merged properties may be in use by readers, so new ones are built and swapped in as done by Reload
+	props := NewProperties()
+	c.props.RLock()
+	for k, v := range c.props.props {
+		cp := *v
+		props.props[k] = &cp
+	}
+	c.props.RUnlock()
+	for k, v := range values {
+		c.setOverride(k, Origin{Source: "ReadFrom", Location: "line " + strconv.Itoa(lines[k]), Value: v})
+		props.props[k] = createValue(k, v)
+	}
+	err = props.resolveAll()
+	c.props = props
+	return n, err
+}
+
+//WriteTo function will write the merged values to a io.Writer in the .properties format sorted by key
+//The number of bytes written is returned.
+//This function does not close the writer and it is the responsibility of the caller to close the writer
+func (c *LayeredConfig) WriteTo(w io.Writer) (int64, error) {
+	return c.current().WriteTo(w)
+}
+
+//Get Function will return the string for the specified key from the source with the highest precedence. If no value
+//is present for the corresponding key then the default value is returned.
+func (c *LayeredConfig) Get(k, d string) string {
+	return c.current().Get(k, d)
+}
+
+//Lookup function will return the string for the specified key from the source with the highest precedence and true,
+//or false if no value is present for the key
+func (c *LayeredConfig) Lookup(k string) (string, bool) {
+	return c.current().Lookup(k)
+}
+
+//Resolve Function will return the string for the specified key with its variables resolved. If no value is present
+//for the corresponding key then the default value is returned.
+func (c *LayeredConfig) Resolve(k, d string) (string, error) {
+	return c.current().Resolve(k, d)
+}
+
+//Keys function will return the keys of the merged values sorted
+func (c *LayeredConfig) Keys() []string {
+	props := c.current()
+	props.RLock()
+	defer props.RUnlock()
+	keys := make([]string, 0, len(props.props))
+	for k := range props.props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//GetAsInt Function will return the value as int for the specified key. If no value is present for the corresponding key
+//then the default value is returned.In case the value is present and it is not a int an error is thrown.
+func (c *LayeredConfig) GetAsInt(k string, defaultVal int) (int, error) {
+	return c.current().GetAsInt(k, defaultVal)
+}
+
+//GetAsInt64 Function will return the value as int64 for the specified key. If no value is present for the corresponding
+//key then the default value is returned.In case the value is present and it is not a int64 an error is thrown.
+func (c *LayeredConfig) GetAsInt64(k string, defaultVal int64) (int64, error) {
+	return c.current().GetAsInt64(k, defaultVal)
+}
+
+//GetAsDecimal Function will return the value as float64 for the specified key.If no value is present for the
+//corresponding key then the default value is returned.In case the key is present and it is not decimal error is thrown.
+func (c *LayeredConfig) GetAsDecimal(k string, defaultVal float64) (float64, error) {
+	return c.current().GetAsDecimal(k, defaultVal)
+}
+
+//GetAsBool Function will return the value as bool for the specified key.If no value is present for the
+//corresponding key then the default value is returned.In case the key is present and it is not a bool is thrown.
+func (c *LayeredConfig) GetAsBool(k string, defaultVal bool) (bool, error) {
+	return c.current().GetAsBool(k, defaultVal)
+}
+
+//GetAsDuration Function will return the value as time.Duration for the specified key. If no value is present for the
+//corresponding key then the default value is returned.In case the value is not a duration an error is thrown.
+func (c *LayeredConfig) GetAsDuration(k string, defaultVal time.Duration) (time.Duration, error) {
+	return c.current().GetAsDuration(k, defaultVal)
+}
+
+//GetAsByteSize Function will return the value as a number of bytes for the specified key. If no value is present for
+//the corresponding key then the default value is returned.In case the value is not a byte size an error is thrown.
+func (c *LayeredConfig) GetAsByteSize(k string, defaultVal int64) (int64, error) {
+	return c.current().GetAsByteSize(k, defaultVal)
+}
+
+//GetAsList Function will return the value split at the separator for the specified key. If no value is present for
+//the corresponding key then the default value is returned.
+func (c *LayeredConfig) GetAsList(k, sep string, trim bool, defaultVal []string) []string {
+	return c.current().GetAsList(k, sep, trim, defaultVal)
+}
+
+//GetAsMap Function will return the value written as comma separated key=value entries as a map for the specified key.
+//If no value is present for the corresponding key then the default value is returned.
+func (c *LayeredConfig) GetAsMap(k string, defaultVal map[string]string) (map[string]string, error) {
+	return c.current().GetAsMap(k, defaultVal)
+}
+
+//GetAsURL Function will return the value as an absolute URL for the specified key. If no value is present for the
+//corresponding key then the default value is returned.
+func (c *LayeredConfig) GetAsURL(k string, defaultVal *url.URL) (*url.URL, error) {
+	return c.current().GetAsURL(k, defaultVal)
+}
+
+//GetAsTime Function will return the value parsed with the layout as time.Time for the specified key. If no value is
+//present for the corresponding key then the default value is returned.
+func (c *LayeredConfig) GetAsTime(k, layout string, defaultVal time.Time) (time.Time, error) {
+	return c.current().GetAsTime(k, layout, defaultVal)
+}
+
+//Put function will set the value of the key over the values of the sources. If the property was already present then
+//the previous values is returned
+func (c *LayeredConfig) Put(k, v string) string {
+	c.Lock()
+	defer c.unlockAndNotify(c.props.resolvedProps)
+	defer c.override(k)
+	return c.props.Put(k, v)
+}
+
+//PutInt function will set the int value of the key over the values of the sources. If the property was already
+//present then the previous values is returned
+func (c *LayeredConfig) PutInt(k string, v int) (int, error) {
+	c.Lock()
+	defer c.unlockAndNotify(c.props.resolvedProps)
+	defer c.override(k)
+	return c.props.PutInt(k, v)
+}
+
+//PutInt64 function will set the int64 value of the key over the values of the sources. If the property was already
+//present then the previous values is returned
+func (c *LayeredConfig) PutInt64(k string, v int64) (int64, error) {
+	c.Lock()
+	defer c.unlockAndNotify(c.props.resolvedProps)
+	defer c.override(k)
+	return c.props.PutInt64(k, v)
+}
+
+//PutDecimal function will set the decimal value of the key over the values of the sources. If the property was
+//already present then the previous values is returned
+func (c *LayeredConfig) PutDecimal(k string, v float64) (float64, error) {
+	c.Lock()
+	defer c.unlockAndNotify(c.props.resolvedProps)
+	defer c.override(k)
+	return c.props.PutDecimal(k, v)
+}
+
+//PutBool function will set the bool value of the key over the values of the sources. If the property was already
+//present then the previous values is returned
+func (c *LayeredConfig) PutBool(k string, v bool) (bool, error) {
+	c.Lock()
+	defer c.unlockAndNotify(c.props.resolvedProps)
+	defer c.override(k)
+	return c.props.PutBool(k, v)
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLayeredConfig(t *testing.T) {
+	if err := os.Setenv("APP_DB_HOST", "env.local"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("APP_DB_HOST")
+	if err := os.Setenv("APP_SECRET", "${file:testdata/layered.env}"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("APP_SECRET")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("log.level", "warn", "")
+	fs.Int("unset", 1, "")
+	if err := fs.Parse([]string{"-log.level=error"}); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewLayeredConfig(
+		NewMapSource("defaults", map[string]string{"db.port": "1", "db.pool.size": "5", "timeout": "30"}),
+		NewYAMLFileSource("testdata/layered.yaml"),
+		NewJSONFileSource("testdata/layered.json"),
+		OptionalSource(NewPropertiesFileSource("testdata/missing.properties")),
+		NewDotEnvFileSource("testdata/layered.env", "APP_"),
+		NewEnvSource("APP_"),
+		NewFlagSource(fs),
+		NewArgsSource([]string{"serve", "--db.pool.size=20", "--verbose", "positional", "-5", "--name=svc",
+			"--leak=${file:testdata/layered.env}", "--fallback=${env:HOME:-none}", "--", "--ignored"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"timeout":      "30",
+		"db.host":      "env.local",
+		"db.port":      "6432",
+		"db.url":       "jdbc:env.local:6432",
+		"db.pool.size": "20",
+		"db.max_idle":  "4",
+		"features.1":   "b",
+		"enabled":      "true",
+		"log.level":    "error",
+		"greeting":     "hello # world",
+		"note":         "plain value",
+		"verbose":      "true",
+		"name":         "svc",
+		"positional":   "absent",
+		"5":            "absent",
+		"secret":       "${file:testdata/layered.env}",
+		"leak":         "${file:testdata/layered.env}",
+		"fallback":     "none",
+		"nothing":      "absent",
+		"unset":        "absent",
+		"ignored":      "absent",
+		"other":        "absent",
+	}
+	for k, v := range want {
+		if got := c.Get(k, "absent"); got != v {
+			t.Errorf("Get(%s) = %q, want %q", k, got, v)
+		}
+	}
+	if n, err := c.GetAsInt("db.pool.size", 0); err != nil || n != 20 {
+		t.Errorf("GetAsInt() = %d, %v", n, err)
+	}
+
+	c.Put("db.host", "put.local")
+	if _, err = c.ReadFrom(strings.NewReader("log.level=trace\ndb.port=${timeout}")); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Get("db.url", ""); got != "jdbc:put.local:30" {
+		t.Errorf("Get(db.url) after Reload = %s", got)
+	}
+	var buf bytes.Buffer
+	if _, err = c.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "log.level=trace\n") {
+		t.Errorf("WriteTo() = %s", buf.String())
+	}
+}
+
+func TestLayeredConfig_Errors(t *testing.T) {
+	if _, err := NewLayeredConfig(NewYAMLFileSource("testdata/missing.yaml")); err == nil {
+		t.Error("NewLayeredConfig() must fail for a missing file")
+	}
+	c, err := NewLayeredConfig(NewMapSource("defaults", map[string]string{"a": "${b:?b is required}"}))
+	if err == nil || err.Error() != "properties: b: b is required" {
+		t.Errorf("NewLayeredConfig() error = %v", err)
+	}
+	c.Put("b", "1")
+	if err = c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Resolve("a", ""); err != nil || got != "1" {
+		t.Errorf("Resolve() = %s, %v", got, err)
+	}
+	if _, err = NewFileSource(filepath.Join("testdata", "app.toml")); err == nil {
+		t.Error("NewFileSource() must fail for an unsupported extension")
+	}
+	for _, name := range []string{"a.properties", "a.json", "a.YML", "a.yaml", ".env", "local.env"} {
+		if _, err = NewFileSource(name); err != nil {
+			t.Errorf("NewFileSource(%s) error = %v", name, err)
+		}
+	}
+}
+
+func TestLayeredConfig_Provenance(t *testing.T) {
+	if err := os.Setenv("PROV_DB_HOST", "env.local"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("PROV_DB_HOST")
+	c, err := NewLayeredConfig(
+		NewMapSource("defaults", map[string]string{"db.port": "1", "log.level": "warn"}),
+		NewYAMLFileSource("testdata/layered.yaml"),
+		NewJSONFileSource("testdata/layered.json"),
+		NewEnvSource("PROV_"),
+		NewArgsSource([]string{"--log.level=debug"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.ReadFrom(strings.NewReader("# overrides\nmissing=${none:?none is required}")); err == nil {
+		t.Error("ReadFrom() must report the unresolved variable")
+	}
+	c.Put("log.level", "info")
+	c.Put("log.level", "error")
+	pv, ok := c.Explain("db.port")
+	want := Provenance{Key: "db.port", Resolved: "6432",
+		Origin: Origin{Source: "testdata/layered.json", Location: "testdata/layered.json:1", Value: "6432"},
+		Overridden: []Origin{{Source: "testdata/layered.yaml", Location: "testdata/layered.yaml:3", Value: "5432"},
+			{Source: "defaults", Value: "1"}}}
+	if !ok || !reflect.DeepEqual(pv, want) {
+		t.Errorf("Explain()\n got = %+v\nwant = %+v", pv, want)
+	}
+	if _, ok = c.Explain("absent"); ok {
+		t.Error("Explain() must return false for a missing key")
+	}
+	var buf bytes.Buffer
+	if err = c.WriteProvenance(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"db.host = \"env.local\"\n  from env:PROV_ PROV_DB_HOST \"env.local\"\n  overrides testdata/layered.yaml:2 \"yaml.local\"\n",
+		"db.url = \"jdbc:env.local:6432\"\n  raw \"jdbc:${db.host}:${db.port}\"\n  from testdata/layered.yaml:4",
+		"log.level = \"error\"\n  from Put \"error\"\n  overrides args --log.level \"debug\"\n  overrides testdata/layered.yaml:6 \"info\"\n" +
+			"  overrides defaults \"warn\"\n",
+		"missing = \"${none:?none is required}\"\n  error properties: none: none is required\n  from ReadFrom line 2 ",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("WriteProvenance() = %s\nmissing %s", buf.String(), s)
+		}
+	}
+	if err = c.Reload(); err == nil {
+		t.Error("Reload() must keep the values read and report the unresolved variable")
+	}
+}
+
+func TestLayeredConfig_ConcurrentReadFrom(t *testing.T) {
+	c, err := NewLayeredConfig(NewMapSource("defaults", map[string]string{"a": "0", "b": "${a}"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			if _, err := c.ReadFrom(strings.NewReader("a=" + strconv.Itoa(i))); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			c.Get("a", "")
+			c.Keys()
+			if _, err := c.Resolve("b", ""); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	if got, err := c.Resolve("b", ""); err != nil || got != "199" {
+		t.Errorf("Resolve() = %s, %v", got, err)
+	}
+}
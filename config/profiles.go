@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//ProfilesEnv is the environment variable conventionally holding the comma separated active profiles, e.g.
+//APP_PROFILES=prod,eu
+const ProfilesEnv = "APP_PROFILES"
+
+//ActiveProfiles function returns the comma separated profiles of the environment variable in their order, e.g. the
+//value "prod, eu" activates prod and eu. The blank and repeated names are dropped.
+func ActiveProfiles(env string) []string {
+	var profiles []string
+	seen := make(map[string]bool)
+	for _, p := range parseList(os.Getenv(env), textutils.CommaStr, true) {
+		if !seen[p] {
+			seen[p] = true
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles
+}
+
+//ProfileFiles function returns the path followed by the path of the overlay of each profile. The name of an overlay
+//is the name of the file with -<profile> before its extension, e.g. config/app.properties and the profile prod give
+//config/app-prod.properties. The overlay of a file without a name, e.g. .env, is .env.prod.
+func ProfileFiles(path string, profiles ...string) ([]string, error) {
+	files := []string{path}
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	for _, p := range profiles {
+		if p == textutils.EmptyStr || p == "." || p == ".." || strings.ContainsAny(p, `/\`) {
+			return nil, fmt.Errorf("config: invalid profile %q", p)
+		}
+		if ext == base {
+			files = append(files, path+textutils.PeriodStr+p)
+		} else {
+			files = append(files, strings.TrimSuffix(path, ext)+textutils.HyphenStr+p+ext)
+		}
+	}
+	return files, nil
+}
+
+//LoadProfiles function reads the .properties file and then the overlays of the profiles, in order, in to new
+//Properties. The values of an overlay take precedence over the values of the file and of the overlays before it and
+//the variables are resolved once all the files are read so that an overlay can change the values referenced by the
+//file. The file must exist while the missing overlays are skipped.
+func LoadProfiles(path string, profiles ...string) (*Properties, error) {
+	files, err := ProfileFiles(path, profiles...)
+	if err != nil {
+		return nil, err
+	}
+	var lines []*propertyLine
+	for i, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if i > 0 && os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		read, err := loadProperties(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("config: %s: %v", f, err)
+		}
+		if n := len(lines); n > 0 && lines[n-1].eol == textutils.EmptyStr {
+			//the files are written back as one document
+			lines[n-1].eol = "\n"
+		}
+		lines = append(lines, read...)
+	}
+	p := NewProperties()
+	return p, p.addLines(lines)
+}
+
+//ProfileSources function returns the sources of the file and of the overlays of the profiles in increasing order of
+//precedence. The format of the files is selected by the extension as done by NewFileSource and the missing overlays
+//load no values.
+func ProfileSources(path string, profiles ...string) ([]Source, error) {
+	files, err := ProfileFiles(path, profiles...)
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]Source, 0, len(files))
+	for i, f := range files {
+		s, err := NewFileSource(f)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			s = OptionalSource(s)
+		}
+		sources = append(sources, s)
+	}
+	return sources, nil
+}
+
+//NewProfiledConfig function creates a LayeredConfig of the file and of the overlays of the profiles activated by the
+//environment variable env, followed by the sources which take precedence over the files, e.g.
+//  NewProfiledConfig("config/app.yaml", ProfilesEnv, NewEnvSource("APP_"), NewArgsSource(os.Args[1:]))
+//loads config/app.yaml and then config/app-prod.yaml if APP_PROFILES=prod.
+func NewProfiledConfig(path, env string, sources ...Source) (*LayeredConfig, error) {
+	profiles := ActiveProfiles(env)
+	files, err := ProfileSources(path, profiles...)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewLayeredConfig(append(files, sources...)...)
+	if err != nil {
+		return nil, err
+	}
+	c.profiles = profiles
+	return c, nil
+}
+
+//Profiles function returns the active profiles of the config in their order of precedence, the lowest first
+func (c *LayeredConfig) Profiles() []string {
+	return append([]string(nil), c.profiles...)
+}
+
+//IsProfileActive function checks if the profile is active
+func (c *LayeredConfig) IsProfileActive(profile string) bool {
+	for _, p := range c.profiles {
+		if p == profile {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestProfileFiles(t *testing.T) {
+	tests := []struct {
+		path     string
+		profiles []string
+		want     []string
+		wantErr  bool
+	}{
+		{path: "app.properties", want: []string{"app.properties"}},
+		{path: "config/app.yaml", profiles: []string{"dev", "local"},
+			want: []string{"config/app.yaml", "config/app-dev.yaml", "config/app-local.yaml"}},
+		{path: "config/.env", profiles: []string{"prod"}, want: []string{"config/.env", "config/.env.prod"}},
+		{path: "app", profiles: []string{"prod"}, want: []string{"app", "app-prod"}},
+		{path: "app.properties", profiles: []string{"../prod"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ProfileFiles(tt.path, tt.profiles...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProfileFiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ProfileFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveProfiles(t *testing.T) {
+	os.Setenv("CONFIG_TEST_PROFILES", " prod, eu,,prod ")
+	defer os.Unsetenv("CONFIG_TEST_PROFILES")
+	if got := ActiveProfiles("CONFIG_TEST_PROFILES"); !reflect.DeepEqual(got, []string{"prod", "eu"}) {
+		t.Errorf("ActiveProfiles() = %q", got)
+	}
+	if got := ActiveProfiles("CONFIG_TEST_UNSET"); len(got) != 0 {
+		t.Errorf("ActiveProfiles() unset = %q", got)
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"app.properties":      "# defaults\nhost=localhost\nurl=http://${host}:${port}\nport=8080",
+		"app-prod.properties": "host=prod.example.com\n",
+		"app-eu.properties":   "port=443\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "app.properties")
+	p, err := LoadProfiles(path, "prod", "missing", "eu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Get("url", ""); got != "http://prod.example.com:443" {
+		t.Errorf("Get(url) = %q", got)
+	}
+	var buf bytes.Buffer
+	if _, err := p.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := files["app.properties"] + "\n" + files["app-prod.properties"] + files["app-eu.properties"]; buf.String() != want {
+		t.Errorf("WriteTo() = %q, want %q", buf.String(), want)
+	}
+	if _, err := LoadProfiles(filepath.Join(dir, "missing.properties"), "prod"); err == nil {
+		t.Error("LoadProfiles() expected an error for a missing file")
+	}
+
+	os.Setenv("CONFIG_TEST_PROFILES", "prod,eu")
+	defer os.Unsetenv("CONFIG_TEST_PROFILES")
+	c, err := NewProfiledConfig(path, "CONFIG_TEST_PROFILES", NewArgsSource([]string{"--port=8443"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Get("url", ""); got != "http://prod.example.com:8443" {
+		t.Errorf("Get(url) = %q", got)
+	}
+	if !reflect.DeepEqual(c.Profiles(), []string{"prod", "eu"}) || !c.IsProfileActive("eu") || c.IsProfileActive("dev") {
+		t.Errorf("Profiles() = %q", c.Profiles())
+	}
+	if pv, _ := c.Explain("host"); pv.Origin.Location != filepath.Join(dir, "app-prod.properties")+":1" {
+		t.Errorf("Explain(host) = %+v", pv)
+	}
+	if c, err = NewProfiledConfig(filepath.Join(dir, "missing.properties"), "CONFIG_TEST_PROFILES"); err == nil ||
+		c != nil {
+		t.Errorf("NewProfiledConfig() = %v, %v, want an error for a missing file", c, err)
+	}
+}
@@ -2,27 +2,36 @@ package config
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
+	"unicode/utf16"
 
 	"go.codemanch.com/commons/textutils"
 )
 
-//value struct to handle variable based values
+//value struct holds the value of a key as written, with its variables unresolved
 type value struct {
-	key     string
-	vars    map[int]string
-	content []string
-	hasVars bool
+	key string
+	raw string
+	//restricted is set for the values of the sources that cannot use the Resolvers, see RestrictedSource
+	restricted bool
 }
 
 //Properties struct to hold the properties values
 type Properties struct {
 	props         map[string]*value
 	resolvedProps map[string]string
+	lines         []*propertyLine
+	listeners     listeners
 	sync.RWMutex
 }
 
@@ -33,92 +42,57 @@ func NewProperties() *Properties {
 	}
 }
 
-//resolve  a value struct. This function will resolve the variables in the struct
-func (p *Properties) resolve(v *value) string {
-	var sb strings.Builder
-	if v.hasVars {
-		//Check if the value starts with a variable
-		if varName, ok := v.vars[0]; ok {
-			sb.WriteString(p.resolveAndGet(varName, createVarStructure(varName)))
-		}
-		for i, c := range v.content {
-			if varName, ok := v.vars[i+1]; ok {
-				sb.WriteString(c)
-				sb.WriteString(p.resolveAndGet(varName, createVarStructure(varName)))
-			}
-		}
-	} else {
+//createValue will create a value struct for given key value pair.
+func createValue(k, v string) *value {
+	return &value{key: k, raw: v}
+}
 
-		sb.WriteString(v.content[0])
+//resolve will resolve the variables of the value v. If they cannot be resolved the value is returned as written.
+//The caller must hold the lock of the properties
+func (p *Properties) resolve(v *value) string {
+	if resolved, err := p.interpolate(v.raw, []string{v.key}, make(map[string]string), v.restricted); err == nil {
+		return resolved
 	}
-	return sb.String()
+	return v.raw
 }
 
 //resolveAll will go through the properties and resolve all variables necessary and add it to the resolvedProperties map
-func (p *Properties) resolveAll() {
+//The values whose variables cannot be resolved are kept as written and the error of the first such key is returned.
+func (p *Properties) resolveAll() error {
 	p.resolvedProps = make(map[string]string)
+	memo := make(map[string]string)
+	keys := make([]string, 0, len(p.props))
 	for k := range p.props {
-		p.resolvedProps[k] = p.resolve(p.props[k])
+		keys = append(keys, k)
 	}
-
-}
-
-//createVarStructure is used to create a variable structure for values that cannot find a variable name.
-func createVarStructure(varName string) string {
-	var sb strings.Builder
-
-	sb.WriteString("${")
-	sb.WriteString(varName)
-	sb.WriteString("}")
-	return sb.String()
-}
-
-//createValue will create a value struct for given key value pair.
-func createValue(k, v string) *value {
-	var varCount, varStart, startIndex int
-	val := &value{}
-	if len(v) <= 3 { // Min Length for variables to be present is 4 including a char for variable name
-		val.content = append(val.content, v)
-	} else {
-		for i, c := range v {
-			//safe to check the i-1 and i-2 as the min length at this point is at-least 4
-			if c == textutils.OpenBraceChar && v[i-1] == textutils.DollarChar && v[i-2] != textutils.BackSlashChar {
-				val.content = append(val.content, v[startIndex:i-1])
-				varStart = i + 1
-			} else if varStart > 0 && c == textutils.CloseBraceChar {
-				startIndex = i + 1
-				//if First variable then make map
-				if varCount == 0 {
-					val.vars = make(map[int]string)
-				}
-				val.vars[len(val.content)] = v[varStart:i]
-				//reset varStart
-				varStart = 0
-				//increment variable counter
-				varCount++
-				val.hasVars = true
+	sort.Strings(keys)
+	var firstErr error
+	for _, k := range keys {
+		v := p.props[k]
+		resolved, err := p.interpolate(v.raw, []string{k}, memo, v.restricted)
+		if err == nil {
+			memo[k] = resolved
+		} else {
+			resolved = v.raw
+			if firstErr == nil {
+				firstErr = err
 			}
-
-		}
-		if varCount == 0 { // No variables were found
-			val.content = append(val.content, v)
-		} else if varStart > 0 { // There may be existing variables but last one was identified without a end
-			val.content = append(val.content, v[startIndex:])
 		}
+		p.resolvedProps[k] = resolved
 	}
-	val.key = k
-	return val
+	return firstErr
 }
 
-//resolveAndGet will dynamically check the variables in the value for the given key. If the key is absent then it will
-//return the default val d passed
-func (p *Properties) resolveAndGet(k, d string) string {
+//Resolve Function will return the string for the specified key with its variables resolved. If no value is present
+//for the corresponding key then the default value is returned. An error is returned if a variable is required and is
+//absent or if the variables reference each other in a cycle.
+func (p *Properties) Resolve(k, d string) (string, error) {
 	p.RLock()
 	defer p.RUnlock()
-	if value, ok := p.props[k]; ok {
-		return p.resolve(value)
+	if v, ok := p.props[k]; ok {
+		return p.interpolate(v.raw, []string{k}, make(map[string]string), v.restricted)
 	}
-	return d
+	return d, nil
 }
 
 //Get Function will return the string for the specified key. If no value is present for the corresponding key
@@ -132,6 +106,14 @@ func (p *Properties) Get(k, d string) string {
 	return d
 }
 
+//Lookup function will return the string for the specified key and true, or false if no value is present for the key
+func (p *Properties) Lookup(k string) (string, bool) {
+	p.RLock()
+	defer p.RUnlock()
+	value, ok := p.resolvedProps[k]
+	return value, ok
+}
+
 //GetAsInt Function will return the value as int for the specified key. If no value is present for the corresponding key
 //then the default value is returned.In case the value is present and it is not a int an error is thrown.
 func (p *Properties) GetAsInt(k string, defaultVal int) (int, error) {
@@ -176,11 +158,83 @@ func (p *Properties) GetAsBool(k string, defaultVal bool) (bool, error) {
 	return defaultVal, nil
 }
 
+//GetAsDuration Function will return the value as time.Duration for the specified key, e.g. 1m30s. If no value is
+//present for the corresponding key then the default value is returned.In case the value is not a duration an error is
+//thrown.
+func (p *Properties) GetAsDuration(k string, defaultVal time.Duration) (time.Duration, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if value, ok := p.resolvedProps[k]; ok {
+		return time.ParseDuration(value)
+	}
+	return defaultVal, nil
+}
+
+//GetAsByteSize Function will return the value as a number of bytes for the specified key, e.g. 512MiB or 1.5GB. If no
+//value is present for the corresponding key then the default value is returned.In case the value is not a byte size
+//an error is thrown.
+func (p *Properties) GetAsByteSize(k string, defaultVal int64) (int64, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if value, ok := p.resolvedProps[k]; ok {
+		return parseByteSize(value)
+	}
+	return defaultVal, nil
+}
+
+//GetAsList Function will return the value split at the separator for the specified key. If trim is true the items are
+//trimmed and the empty items dropped. If no value is present for the corresponding key then the default value is
+//returned.
+func (p *Properties) GetAsList(k, sep string, trim bool, defaultVal []string) []string {
+	p.RLock()
+	defer p.RUnlock()
+	if value, ok := p.resolvedProps[k]; ok {
+		return parseList(value, sep, trim)
+	}
+	return defaultVal
+}
+
+//GetAsMap Function will return the value written as comma separated key=value entries as a map for the specified key.
+//If no value is present for the corresponding key then the default value is returned.In case an entry is not a
+//key=value pair an error is thrown.
+func (p *Properties) GetAsMap(k string, defaultVal map[string]string) (map[string]string, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if value, ok := p.resolvedProps[k]; ok {
+		return parseMap(value)
+	}
+	return defaultVal, nil
+}
+
+//GetAsURL Function will return the value as an absolute URL for the specified key. If no value is present for the
+//corresponding key then the default value is returned.In case the value is not an absolute URL an error is thrown.
+func (p *Properties) GetAsURL(k string, defaultVal *url.URL) (*url.URL, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if value, ok := p.resolvedProps[k]; ok {
+		return parseURL(value)
+	}
+	return defaultVal, nil
+}
+
+//GetAsTime Function will return the value parsed with the layout as time.Time for the specified key. The layout is a
+//Go layout or a format of the codec package, e.g. codec.FormatUnix, and time.RFC3339 is used if it is empty. If no
+//value is present for the corresponding key then the default value is returned.In case the value does not match the
+//layout an error is thrown.
+func (p *Properties) GetAsTime(k, layout string, defaultVal time.Time) (time.Time, error) {
+	p.RLock()
+	defer p.RUnlock()
+	if value, ok := p.resolvedProps[k]; ok {
+		return parseTime(value, layout)
+	}
+	return defaultVal, nil
+}
+
 //Put function will add the key,value to the properties. If the property was already present then the previous values is
 //returned
 func (p *Properties) Put(k, v string) string {
 	p.Lock()
-	defer p.Unlock()
+	defer p.unlockAndNotify(p.resolvedProps)
 	var ret string
 	if oldVal, ok := p.props[k]; ok {
 		ret = p.resolve(oldVal)
@@ -194,7 +248,7 @@ func (p *Properties) Put(k, v string) string {
 //If the property was already present then the previous values is returned
 func (p *Properties) PutInt(k string, v int) (int, error) {
 	p.Lock()
-	defer p.Unlock()
+	defer p.unlockAndNotify(p.resolvedProps)
 	var ret int
 	var err error = nil
 	if oldValue, ok := p.props[k]; ok {
@@ -209,7 +263,7 @@ func (p *Properties) PutInt(k string, v int) (int, error) {
 //string. If the property was already present then the previous values is returned
 func (p *Properties) PutInt64(k string, v int64) (int64, error) {
 	p.Lock()
-	defer p.Unlock()
+	defer p.unlockAndNotify(p.resolvedProps)
 	var ret int64
 	var err error = nil
 	if oldValue, ok := p.props[k]; ok {
@@ -225,7 +279,7 @@ func (p *Properties) PutInt64(k string, v int64) (int64, error) {
 //a string. If the property was already present then the previous values is returned
 func (p *Properties) PutDecimal(k string, v float64) (float64, error) {
 	p.Lock()
-	defer p.Unlock()
+	defer p.unlockAndNotify(p.resolvedProps)
 	var ret float64
 	var err error = nil
 	if oldValue, ok := p.props[k]; ok {
@@ -241,7 +295,7 @@ func (p *Properties) PutDecimal(k string, v float64) (float64, error) {
 //a string. If the property was already present then the previous values is returned
 func (p *Properties) PutBool(k string, v bool) (bool, error) {
 	p.Lock()
-	defer p.Unlock()
+	defer p.unlockAndNotify(p.resolvedProps)
 	var ret bool
 	var err error = nil
 	if oldValue, ok := p.props[k]; ok {
@@ -253,73 +307,420 @@ func (p *Properties) PutBool(k string, v bool) (bool, error) {
 
 }
 
-//ReadFrom function will read the properties from a io.Reader.
+//ReadFrom function will read the properties from a io.Reader in the Java .properties format.
+//Keys are separated from values by '=', ':' or whitespace, lines starting with '#' or '!' are comments, a line ending
+//with an odd number of '\' continues on the next line and the escapes \t, \n, \r, \f and \uXXXX are decoded.
+//The lines read are kept, including comments and blank lines, so that WriteTo preserves the document. Reading in to
+//Properties that already hold a document replaces the lines of the keys redefined and appends the others.
+//The properties are read even if the variables of some values cannot be resolved and the first such error is returned.
+//The number of bytes read is returned.
 //This function does not close the reader and it is the responsibility of the caller to close the reader
-func (p *Properties) ReadFrom(r io.Reader) error {
-	scanner := bufio.NewScanner(r)
-	for scanner.Scan() {
-		line := scanner.Text()
-		l := len(line)
-		//Cases where it is not a valid props entry.
-		if l == 0 || line[0] == textutils.HashChar || line[0] == textutils.EqualChar {
+func (p *Properties) ReadFrom(r io.Reader) (int64, error) {
+	b, err := ioutil.ReadAll(r)
+	n := int64(len(b))
+	if err != nil {
+		return n, err
+	}
+	lines, err := loadProperties(string(b))
+	if err != nil {
+		return n, err
+	}
+	return n, p.addLines(lines)
+}
+
+//addLines sets the entries of the lines read and adds the lines to the document. An entry of a key already in the
+//document replaces the last line of the key in place. The other lines are appended, the comments and blank lines only
+//if a key is added, so that reading the same document again does not duplicate it.
+func (p *Properties) addLines(lines []*propertyLine) error {
+	p.Lock()
+	defer p.unlockAndNotify(p.resolvedProps)
+	last := make(map[string]int)
+	for i, l := range p.lines {
+		if l.isEntry {
+			last[l.key] = i
+		}
+	}
+	var appended []*propertyLine
+	added := false
+	for _, l := range lines {
+		if !l.isEntry {
+			appended = append(appended, l)
 			continue
 		}
-		for i, c := range line {
-			if i > 0 && i < l-1 && line[i] != textutils.BackSlashChar && c == textutils.EqualChar {
-				p.props[line[0:i]] = createValue(line[0:i], line[i+1:l])
-				break
-			}
+		p.props[l.key] = createValue(l.key, l.raw)
+		if i, ok := last[l.key]; ok {
+			//the terminator of the line replaced is kept as it may be followed by other lines
+			replaced := *l
+			replaced.eol = p.lines[i].eol
+			p.lines[i] = &replaced
+			continue
 		}
+		appended = append(appended, l)
+		added = true
 	}
-	p.resolveAll()
-	return nil
+	if added {
+		if n := len(p.lines); n > 0 && p.lines[n-1].eol == textutils.EmptyStr {
+			p.lines[n-1].eol = "\n"
+		}
+		p.lines = append(p.lines, appended...)
+	}
+	return p.resolveAll()
 }
 
-//WriteTo function will read the properties from a io.Writer.
-//If error occurs while writing to the reader, this will immediately return the error.This may cause partial writes.
+//WriteTo function will write the properties to a io.Writer in the Java .properties format.
+//The lines read by ReadFrom are written back in their order with their comments, blank lines and formatting. Only the
+//entries whose value changed are rewritten, keeping their key and separator, and the entries removed are dropped.
+//The keys that were not read are appended one line per key sorted by key. The keys and values are escaped so that
+//ReadFrom and java.util.Properties read the same values back.
+//If error occurs while writing to the writer, this will immediately return the error.This may cause partial writes.
+//The number of bytes written is returned.
 //This function does not close the writer and it is the responsibility of the caller to close the writer
-func (p *Properties) WriteTo(w io.Writer) error {
+func (p *Properties) WriteTo(w io.Writer) (int64, error) {
+	p.RLock()
+	defer p.RUnlock()
+	//the lines added use the terminator of the document
+	eol := textutils.EmptyStr
+	last := make(map[string]int)
+	for i, l := range p.lines {
+		if l.isEntry {
+			last[l.key] = i
+		}
+		if eol == textutils.EmptyStr {
+			eol = l.eol
+		}
+	}
+	if eol == textutils.EmptyStr {
+		eol = "\n"
+	}
+	var keys []string
+	for k := range p.props {
+		if _, ok := last[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
 	bufWriter := bufio.NewWriter(w)
-	var err error = nil
+	var n int64
+	//written returns the number of bytes passed to w, the ones still buffered are excluded
+	written := func() int64 {
+		return n - int64(bufWriter.Buffered())
+	}
+	pendingEOL := textutils.EmptyStr
+	write := func(text, lineEOL string) error {
+		nn, err := bufWriter.WriteString(pendingEOL + text)
+		n += int64(nn)
+		pendingEOL = lineEOL
+		return err
+	}
+	for i, l := range p.lines {
+		text := l.text
+		if l.isEntry {
+			v, ok := p.props[l.key]
+			if !ok {
+				continue
+			}
+			if last[l.key] == i && v.raw != l.raw {
+				text = l.edit(v.raw)
+			}
+		}
+		if err := write(text, l.eol); err != nil {
+			return written(), err
+		}
+	}
+	if pendingEOL == textutils.EmptyStr && len(keys) > 0 && len(p.lines) > 0 {
+		pendingEOL = eol
+	}
+	for _, k := range keys {
+		if err := write(escapeProperty(k, true)+textutils.EqualStr+escapeProperty(p.props[k].raw, false), eol); err != nil {
+			return written(), err
+		}
+	}
+	if err := write(textutils.EmptyStr, textutils.EmptyStr); err != nil {
+		return written(), err
+	}
+	err := bufWriter.Flush()
+	return written(), err
+}
+
+//Remove function will remove the key from the properties and returns the previous value. The lines of the key are
+//dropped by WriteTo
+func (p *Properties) Remove(k string) string {
+	p.Lock()
+	defer p.unlockAndNotify(p.resolvedProps)
+	var ret string
+	if oldVal, ok := p.props[k]; ok {
+		ret = p.resolve(oldVal)
+		delete(p.props, k)
+		p.resolveAll()
+	}
+	return ret
+}
+
+//Keys function will return the keys of the properties in the order they were read followed by the keys added sorted
+func (p *Properties) Keys() []string {
+	p.RLock()
+	defer p.RUnlock()
+	keys := make([]string, 0, len(p.props))
+	seen := make(map[string]bool)
+	for _, l := range p.lines {
+		if _, ok := p.props[l.key]; ok && l.isEntry && !seen[l.key] {
+			seen[l.key] = true
+			keys = append(keys, l.key)
+		}
+	}
+	var added []string
 	for k := range p.props {
-		_, err = bufWriter.WriteString(k)
+		if !seen[k] {
+			added = append(added, k)
+		}
+	}
+	sort.Strings(added)
+	return append(keys, added...)
+}
+
+//propertiesWhitespace are the characters separating keys from values in the .properties format
+const propertiesWhitespace = " \t\f"
+
+//propertyLine is a logical line of a properties document. Comments and blank lines are not entries.
+type propertyLine struct {
+	//text is the line as read, including the continued lines, without the line terminator
+	text string
+	//eol is the line terminator, empty for the last line without one
+	eol string
+	//prefix is the text of the line up to the value, empty if the key is continued on the next lines
+	prefix  string
+	key     string
+	raw     string
+	isEntry bool
+	//line is the number of the first line of the entry
+	line int
+}
+
+//edit returns the text of the entry with the value v, keeping the original key and separator
+func (l *propertyLine) edit(v string) string {
+	if l.prefix == textutils.EmptyStr {
+		return escapeProperty(l.key, true) + textutils.EqualStr + escapeProperty(v, false)
+	}
+	return l.prefix + escapeProperty(v, false)
+}
+
+//loadProperties parses the content in the .properties format and returns its logical lines
+func loadProperties(content string) ([]*propertyLine, error) {
+	natural := splitLines(content)
+	var lines []*propertyLine
+	for i := 0; i < len(natural); i++ {
+		lineNo := i + 1
+		first := natural[i]
+		line := strings.TrimLeft(first.text, propertiesWhitespace)
+		if line == textutils.EmptyStr || line[0] == textutils.HashChar || line[0] == textutils.ExclamationChar {
+			lines = append(lines, &propertyLine{text: first.text, eol: first.eol})
+			continue
+		}
+		indent := len(first.text) - len(line)
+		//the number of characters of the first line in the logical line
+		firstLen := len(line)
+		if isContinued(line) {
+			firstLen--
+		}
+		text := first.text
+		eol := first.eol
+		for isContinued(line) {
+			line = line[:len(line)-1]
+			if i+1 == len(natural) {
+				break
+			}
+			i++
+			text += eol + natural[i].text
+			eol = natural[i].eol
+			line += strings.TrimLeft(natural[i].text, propertiesWhitespace)
+		}
+		k, v := splitProperty(line)
+		pl := &propertyLine{text: text, eol: eol, isEntry: true, line: lineNo}
+		if valueStart := len(line) - len(v); valueStart <= firstLen {
+			pl.prefix = first.text[:indent+valueStart]
+		}
+		var err error
+		if pl.key, err = unescapeProperty(k, true); err == nil {
+			pl.raw, err = unescapeProperty(v, false)
+		}
 		if err != nil {
+			return nil, fmt.Errorf("properties: line %d: %v", lineNo, err)
+		}
+		lines = append(lines, pl)
+	}
+	return lines, nil
+}
+
+//naturalLine is a line of the content and its terminator
+type naturalLine struct {
+	text string
+	eol  string
+}
+
+//splitLines splits the content in to lines terminated by "\n", "\r" or "\r\n"
+func splitLines(content string) []naturalLine {
+	var lines []naturalLine
+	start := 0
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '\r':
+			end := i
+			if i+1 < len(content) && content[i+1] == '\n' {
+				i++
+			}
+			lines = append(lines, naturalLine{text: content[start:end], eol: content[end : i+1]})
+			start = i + 1
+		case '\n':
+			lines = append(lines, naturalLine{text: content[start:i], eol: content[i : i+1]})
+			start = i + 1
+		}
+	}
+	if start < len(content) {
+		lines = append(lines, naturalLine{text: content[start:]})
+	}
+	return lines
+}
+
+//isContinued checks if the line ends with an odd number of backslashes
+func isContinued(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == textutils.BackSlashChar; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+//splitProperty splits a logical line in to the escaped key and value. The key ends at the first unescaped '=', ':'
+//or whitespace and the value starts after the whitespace and at most one '=' or ':' that follow.
+func splitProperty(line string) (string, string) {
+	keyLen, valueStart := 0, len(line)
+	hasSeparator, escaped := false, false
+	for ; keyLen < len(line); keyLen++ {
+		c := line[keyLen]
+		if !escaped && (c == textutils.EqualChar || c == textutils.ColonChar) {
+			valueStart, hasSeparator = keyLen+1, true
 			break
 		}
-		_, err = bufWriter.WriteString(textutils.EqualStr)
-		if err != nil {
+		if !escaped && strings.IndexByte(propertiesWhitespace, c) != -1 {
+			valueStart = keyLen + 1
 			break
 		}
-		v := p.props[k]
-		if v.hasVars {
-			//Check if the value starts with a variable
-			if varName, ok := v.vars[0]; ok {
-				_, err = bufWriter.WriteString(createVarStructure(varName))
-				if err != nil {
-					break
-				}
+		escaped = c == textutils.BackSlashChar && !escaped
+	}
+	for ; valueStart < len(line); valueStart++ {
+		c := line[valueStart]
+		if strings.IndexByte(propertiesWhitespace, c) != -1 {
+			continue
+		}
+		if hasSeparator || c != textutils.EqualChar && c != textutils.ColonChar {
+			break
+		}
+		hasSeparator = true
+	}
+	return line[:keyLen], line[valueStart:]
+}
+
+//unescapeProperty decodes the escapes of a key or value. Surrogate pairs escaped as two \uXXXX sequences are
+//combined in to a single character. A backslash before any other character is dropped, except in a value before ${
+//which is kept so that \${key} is written as ${key} without resolving the variable.
+func unescapeProperty(s string, isKey bool) (string, error) {
+	if strings.IndexByte(s, textutils.BackSlashChar) == -1 {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != textutils.BackSlashChar {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(s) {
+			break
+		}
+		switch c = s[i]; c {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			r, err := parseUnicodeEscape(s[i+1:])
+			if err != nil {
+				return s, err
 			}
-			for i, c := range v.content {
-				if varName, ok := v.vars[i+1]; ok {
-					_, err = bufWriter.WriteString(c)
-					if err != nil {
-						break
-					}
-					_, err = bufWriter.WriteString(createVarStructure(varName))
-					if err != nil {
-						break
+			i += 4
+			if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], "\\u") {
+				if low, err := parseUnicodeEscape(s[i+3:]); err == nil {
+					if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+						r = pair
+						i += 6
 					}
 				}
 			}
-		} else {
-			_, err = bufWriter.WriteString(v.content[0])
-			if err != nil {
-				break
+			sb.WriteRune(r)
+		case textutils.DollarChar:
+			if !isKey && strings.HasPrefix(s[i+1:], "{") {
+				sb.WriteByte(textutils.BackSlashChar)
 			}
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
 		}
 	}
-	if err == nil {
-		err = bufWriter.Flush()
+	return sb.String(), nil
+}
+
+//parseUnicodeEscape parses the 4 hex digits following \u
+func parseUnicodeEscape(s string) (rune, error) {
+	if len(s) < 4 {
+		return 0, errors.New("malformed \\uxxxx encoding")
+	}
+	n, err := strconv.ParseUint(s[:4], 16, 16)
+	if err != nil {
+		return 0, errors.New("malformed \\uxxxx encoding")
 	}
-	return err
+	return rune(n), nil
+}
+
+//escapeProperty escapes a key or value for the .properties format. Spaces are escaped in keys and at the start of
+//values, the separators and comment characters are escaped and control characters are written as \uXXXX. The \${ of
+//a value, which stops the interpolation, is written as is since unescapeProperty keeps it.
+func escapeProperty(s string, isKey bool) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch r {
+		case ' ':
+			if i == 0 || isKey {
+				sb.WriteByte(textutils.BackSlashChar)
+			}
+			sb.WriteByte(' ')
+		case '\t':
+			sb.WriteString("\\t")
+		case '\n':
+			sb.WriteString("\\n")
+		case '\r':
+			sb.WriteString("\\r")
+		case '\f':
+			sb.WriteString("\\f")
+		case textutils.BackSlashChar:
+			if isKey || !strings.HasPrefix(s[i+1:], "${") {
+				sb.WriteByte(textutils.BackSlashChar)
+			}
+			sb.WriteRune(r)
+		case textutils.EqualChar, textutils.ColonChar, textutils.HashChar, textutils.ExclamationChar:
+			sb.WriteByte(textutils.BackSlashChar)
+			sb.WriteRune(r)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, "\\u%04X", r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	return sb.String()
 }
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestProperties_ReadFrom(t *testing.T) {
+	f, err := os.Open("testdata/test.properties")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p := NewProperties()
+	if _, err = p.ReadFrom(f); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"key1": "value1", "intval": "1", "floatval": "1.2", "testkey": "test value1",
+		"testmultiline": "test value"}
+	for k, v := range want {
+		if got := p.Get(k, "missing"); got != v {
+			t.Errorf("Get(%s) = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestProperties_Format(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{name: "Equal", input: "a=b", key: "a", want: "b"},
+		{name: "Colon", input: "a:b", key: "a", want: "b"},
+		{name: "Whitespace", input: "  a \t b c", key: "a", want: "b c"},
+		{name: "SpacedSeparator", input: "a = : b", key: "a", want: ": b"},
+		{name: "EmptyValue", input: "a", key: "a", want: ""},
+		{name: "EscapedKey", input: `a\=b\ c=d`, key: "a=b c", want: "d"},
+		{name: "Escapes", input: `a=\t\n\r\f\\\qAé`, key: "a", want: "\t\n\r\f\\qAé"},
+		{name: "SurrogatePair", input: `a=\uD83D\uDE00`, key: "a", want: "😀"},
+		{name: "Continuation", input: "a=b\\\n   c\\\r\n\td", key: "a", want: "bcd"},
+		{name: "EscapedBackslash", input: "a=b\\\\\nc=d", key: "a", want: `b\`},
+		{name: "ContinuedComment", input: "a=b\\\n# c", key: "a", want: "b# c"},
+		{name: "Comments", input: "# a=1\n! a=2\n  #a=3\r\na=4", key: "a", want: "4"},
+		{name: "ContinuationAtEOF", input: "a=b\\", key: "a", want: "b"},
+		{name: "Malformed", input: `a=\u00g1`, wantErr: true},
+		{name: "Truncated", input: `a=\u00`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProperties()
+			_, err := p.ReadFrom(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadFrom() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := p.Get(tt.key, "missing"); err == nil && got != tt.want {
+				t.Errorf("Get(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProperties_WriteTo(t *testing.T) {
+	p := NewProperties()
+	values := map[string]string{"a b": " lead", "c=d:e": "#!\\", "ctrl": "\t\n\r\f\x01", "text": "héllo ${a b}",
+		"": "empty"}
+	for k, v := range values {
+		p.Put(k, v)
+	}
+	var buf bytes.Buffer
+	n, err := p.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo() = %d, want %d bytes", n, buf.Len())
+	}
+	want := "=empty\na\\ b=\\ lead\nc\\=d\\:e=\\#\\!\\\\\nctrl=\\t\\n\\r\\f\\u0001\ntext=héllo ${a b}\n"
+	if buf.String() != want {
+		t.Errorf("WriteTo()\n got = %q\nwant = %q", buf.String(), want)
+	}
+	read := NewProperties()
+	if n, err = read.ReadFrom(&buf); err != nil || n != int64(len(want)) {
+		t.Fatalf("ReadFrom() = %d, %v", n, err)
+	}
+	for k, v := range values {
+		if got, ok := read.props[k]; !ok || got.raw != v {
+			t.Errorf("ReadFrom() %q = %+v, want %q", k, got, v)
+		}
+	}
+}
+
+func TestProperties_WriteTo_EscapedVariables(t *testing.T) {
+	p := NewProperties()
+	if _, err := p.ReadFrom(strings.NewReader("x=1\nkept=\\${x}\nedited=${x}\n")); err != nil {
+		t.Fatal(err)
+	}
+	p.Put("edited", "a \\${x}")
+	p.Put("added", "\\${x} ${x}")
+	p.Put("backslash", "\\\\${x}")
+	var buf bytes.Buffer
+	if _, err := p.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "x=1\nkept=\\${x}\nedited=a \\${x}\nadded=\\${x} ${x}\nbackslash=\\\\\\${x}\n"
+	if buf.String() != want {
+		t.Errorf("WriteTo()\n got = %q\nwant = %q", buf.String(), want)
+	}
+	read := NewProperties()
+	if _, err := read.ReadFrom(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range p.Keys() {
+		if got, want := read.Get(k, "missing"), p.Get(k, "missing"); got != want {
+			t.Errorf("Get(%q) after the round trip = %q, want %q", k, got, want)
+		}
+	}
+	if got := read.Get("added", ""); got != "${x} 1" {
+		t.Errorf("Get(added) = %q, want ${x} 1", got)
+	}
+}
+
+func TestProperties_Document(t *testing.T) {
+	doc := "# Service settings\r\n\r\nhost = localhost\r\n! ports\r\nport:8080\r\nlist  a,\\\r\n      b\r\n" +
+		"port=9090\r\nname\\\r\n  =x\r\nlast=1"
+	tests := []struct {
+		name string
+		edit func(p *Properties)
+		want string
+	}{
+		{name: "Unchanged", edit: func(p *Properties) {}, want: doc},
+		{name: "Put", edit: func(p *Properties) {
+			p.Put("host", "example.com")
+			p.Put("list", "c")
+			p.Put("name", "y")
+			p.Put("port", "80")
+			p.Put("last", "2")
+		}, want: "# Service settings\r\n\r\nhost = example.com\r\n! ports\r\nport:8080\r\nlist  c\r\n" +
+			"port=80\r\nname=y\r\nlast=2"},
+		{name: "Remove", edit: func(p *Properties) {
+			p.Remove("port")
+			p.Remove("list")
+		}, want: "# Service settings\r\n\r\nhost = localhost\r\n! ports\r\nname\\\r\n  =x\r\nlast=1"},
+		{name: "Add", edit: func(p *Properties) {
+			p.Put("z", "1")
+			p.Put("b", "2")
+		}, want: doc + "\r\nb=2\r\nz=1\r\n"},
+		{name: "ReadAgain", edit: func(p *Properties) {
+			_, _ = p.ReadFrom(strings.NewReader(doc))
+		}, want: doc},
+		{name: "ReadOverlay", edit: func(p *Properties) {
+			_, _ = p.ReadFrom(strings.NewReader("port=1\n# new\nnew=2"))
+		}, want: "# Service settings\r\n\r\nhost = localhost\r\n! ports\r\nport:8080\r\nlist  a,\\\r\n      b\r\n" +
+			"port=1\r\nname\\\r\n  =x\r\nlast=1\n# new\nnew=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProperties()
+			if _, err := p.ReadFrom(strings.NewReader(doc)); err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(p)
+			var buf bytes.Buffer
+			if _, err := p.WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("WriteTo()\n got = %q\nwant = %q", buf.String(), tt.want)
+			}
+		})
+	}
+	p := NewProperties()
+	if _, err := p.ReadFrom(strings.NewReader(doc)); err != nil {
+		t.Fatal(err)
+	}
+	p.Put("added", "1")
+	want := []string{"host", "port", "list", "name", "last", "added"}
+	if got := p.Keys(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//Resolver interface resolves the variables with a prefix, e.g. ${env:DB_HOST}, from a source other than the properties
+type Resolver interface {
+	//Resolve returns the value for the name after the prefix. ok is false if there is no value for the name.
+	Resolve(name string) (value string, ok bool, err error)
+}
+
+//ResolverFunc type is an adapter to use a function as a Resolver
+type ResolverFunc func(name string) (string, bool, error)
+
+//Resolve calls f(name)
+func (f ResolverFunc) Resolve(name string) (string, bool, error) {
+	return f(name)
+}
+
+//resolvers holds the resolvers registered by prefix
+var resolvers = struct {
+	sync.RWMutex
+	byPrefix map[string]Resolver
+}{
+	byPrefix: make(map[string]Resolver),
+}
+
+func init() {
+	RegisterResolver("env", ResolverFunc(resolveEnv))
+	RegisterResolver("file", ResolverFunc(resolveFile))
+	RegisterResolver("sys", ResolverFunc(resolveSys))
+}
+
+//RegisterResolver function registers the Resolver r for the variables starting with prefix followed by a colon, e.g.
+//${prefix:name}. A resolver registered earlier for the same prefix is replaced. The built in prefixes are
+//  env:  the environment variables, ${env:DB_HOST}
+//  file: the content of a file without its trailing line break, ${file:/run/secrets/db_pass}
+//  sys:  hostname, os, arch, pid, user, home, cwd and tmpdir of the running process, ${sys:hostname}
+func RegisterResolver(prefix string, r Resolver) {
+	resolvers.Lock()
+	defer resolvers.Unlock()
+	resolvers.byPrefix[prefix] = r
+}
+
+//lookupResolver returns the Resolver and the name for a variable name with a registered prefix
+func lookupResolver(name string) (Resolver, string, bool) {
+	idx := strings.IndexByte(name, ':')
+	if idx <= 0 {
+		return nil, name, false
+	}
+	resolvers.RLock()
+	defer resolvers.RUnlock()
+	r, ok := resolvers.byPrefix[name[:idx]]
+	return r, name[idx+1:], ok
+}
+
+//resolveEnv resolves the environment variable name
+func resolveEnv(name string) (string, bool, error) {
+	v, ok := os.LookupEnv(name)
+	return v, ok, nil
+}
+
+//resolveFile resolves the content of the file name. A missing file has no value.
+func resolveFile(name string) (string, bool, error) {
+	b, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimRight(string(b), "\r\n"), true, nil
+}
+
+//resolveSys resolves the attributes of the running process
+func resolveSys(name string) (string, bool, error) {
+	var v string
+	var err error
+	switch name {
+	case "hostname":
+		v, err = os.Hostname()
+	case "os":
+		v = runtime.GOOS
+	case "arch":
+		v = runtime.GOARCH
+	case "pid":
+		v = strconv.Itoa(os.Getpid())
+	case "user":
+		var u *user.User
+		if u, err = user.Current(); err == nil {
+			v = u.Username
+		}
+	case "home":
+		v, err = os.UserHomeDir()
+	case "cwd":
+		v, err = os.Getwd()
+	case "tmpdir":
+		v = os.TempDir()
+	default:
+		return "", false, nil
+	}
+	return v, err == nil, err
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestProperties_Resolvers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resolver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	secret := filepath.Join(dir, "db_pass")
+	if err = ioutil.WriteFile(secret, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Setenv("RESOLVER_TEST_HOST", "db.local"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("RESOLVER_TEST_HOST")
+	RegisterResolver("upper", ResolverFunc(func(name string) (string, bool, error) {
+		return strings.ToUpper(name), true, nil
+	}))
+	doc := "host=${env:RESOLVER_TEST_HOST}\n" +
+		"port=${env:RESOLVER_TEST_PORT:-5432}\n" +
+		"pass=${file:" + filepath.ToSlash(secret) + "}\n" +
+		"missing=${file:" + filepath.ToSlash(filepath.Join(dir, "none")) + ":-none}\n" +
+		"os=${sys:os}\n" +
+		"custom=${upper:${sys:os}}\n" +
+		"url=${host}:${port}"
+	p := NewProperties()
+	if _, err = p.ReadFrom(strings.NewReader(doc)); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"host": "db.local", "port": "5432", "pass": "s3cret", "missing": "none",
+		"os": runtime.GOOS, "custom": strings.ToUpper(runtime.GOOS), "url": "db.local:5432"}
+	for k, v := range want {
+		if got := p.Get(k, ""); got != v {
+			t.Errorf("Get(%s) = %q, want %q", k, got, v)
+		}
+	}
+	p.Put("required", "${env:RESOLVER_TEST_PORT:?port is required}")
+	if _, err = p.Resolve("required", ""); err == nil || err.Error() != "properties: env:RESOLVER_TEST_PORT: port is required" {
+		t.Errorf("Resolve() error = %v", err)
+	}
+	p.Put("dir", "${file:"+filepath.ToSlash(dir)+"}")
+	if _, err = p.Resolve("dir", ""); err == nil {
+		t.Error("Resolve() must fail for a directory")
+	}
+}
@@ -0,0 +1,478 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//Source interface provides the values of a layer of a LayeredConfig
+type Source interface {
+	//Name returns the name identifying the source, e.g. the path of a file
+	Name() string
+	//Load returns the values of the source by key
+	Load() (map[string]string, error)
+}
+
+//LocatedSource interface is implemented by the sources that report where each of their values was read from
+type LocatedSource interface {
+	Source
+	//LoadLocated returns the values of the source by key and the location of each value, e.g. the file and line
+	//app.yaml:12, the environment variable APP_DB_HOST or the argument --db.host
+	LoadLocated() (values map[string]string, locations map[string]string, err error)
+}
+
+//RestrictedSource interface is implemented by the sources whose values may be set by a less trusted party than the
+//files of the application, such as the environment and the command line. The variables of their values with the
+//prefix of a Resolver, e.g. ${file:/etc/passwd}, are handled as absent so that the values cannot read files or the
+//environment. The variables referencing other keys are resolved.
+type RestrictedSource interface {
+	Source
+	//Restricted returns true if the values of the source cannot use the resolvers
+	Restricted() bool
+}
+
+//mapSource is a Source of fixed values
+type mapSource struct {
+	name   string
+	values map[string]string
+}
+
+//NewMapSource function creates a Source of the values, typically used for the defaults
+func NewMapSource(name string, values map[string]string) Source {
+	copied := make(map[string]string, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+	return &mapSource{name: name, values: copied}
+}
+
+func (s *mapSource) Name() string {
+	return s.name
+}
+
+func (s *mapSource) Load() (map[string]string, error) {
+	values := make(map[string]string, len(s.values))
+	for k, v := range s.values {
+		values[k] = v
+	}
+	return values, nil
+}
+
+//fileSource is a Source reading a file with a parser for its format. The parser returns the line of each value.
+type fileSource struct {
+	path  string
+	parse func(content string) (map[string]string, map[string]int, error)
+}
+
+func (s *fileSource) Name() string {
+	return s.path
+}
+
+func (s *fileSource) Load() (map[string]string, error) {
+	values, _, err := s.LoadLocated()
+	return values, err
+}
+
+func (s *fileSource) LoadLocated() (map[string]string, map[string]string, error) {
+	b, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	values, lines, err := s.parse(string(b))
+	if err != nil {
+		return nil, nil, fmt.Errorf("config: %s: %v", s.path, err)
+	}
+	locations := make(map[string]string, len(lines))
+	for k, line := range lines {
+		locations[k] = s.path + textutils.ColonStr + strconv.Itoa(line)
+	}
+	return values, locations, nil
+}
+
+//NewPropertiesFileSource function creates a Source reading a file in the .properties format. The variables of the
+//values are resolved by the LayeredConfig so that they may reference the values of the other sources.
+func NewPropertiesFileSource(path string) Source {
+	return &fileSource{path: path, parse: parseProperties}
+}
+
+//NewJSONFileSource function creates a Source reading a JSON file. The nested objects and arrays are flattened in to
+//keys joined by '.', e.g. {"db":{"hosts":["a"]}} is read as db.hosts.0=a
+func NewJSONFileSource(path string) Source {
+	return &fileSource{path: path, parse: parseJSON}
+}
+
+//NewYAMLFileSource function creates a Source reading a YAML file. The nested mappings and sequences are flattened in
+//to keys joined by '.' as done for JSON. Anchors and aliases are not supported.
+func NewYAMLFileSource(path string) Source {
+	return &fileSource{path: path, parse: parseYAML}
+}
+
+//NewDotEnvFileSource function creates a Source reading a .env file of NAME=value lines. The names are mapped to keys
+//as done by NewEnvSource for the prefix and the names without the prefix are ignored.
+func NewDotEnvFileSource(path, prefix string) Source {
+	return &fileSource{path: path, parse: func(content string) (map[string]string, map[string]int, error) {
+		return parseDotEnv(content, prefix)
+	}}
+}
+
+//NewFileSource function creates a Source for the file using its extension to select the format. The extensions
+//.properties, .json, .yaml, .yml and .env are supported.
+func NewFileSource(path string) (Source, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".properties":
+		return NewPropertiesFileSource(path), nil
+	case ".json":
+		return NewJSONFileSource(path), nil
+	case ".yaml", ".yml":
+		return NewYAMLFileSource(path), nil
+	case ".env":
+		return NewDotEnvFileSource(path, textutils.EmptyStr), nil
+	}
+	if filepath.Base(path) == ".env" {
+		return NewDotEnvFileSource(path, textutils.EmptyStr), nil
+	}
+	return nil, fmt.Errorf("config: unsupported configuration file %s", path)
+}
+
+//optionalSource is a Source whose missing file is read as empty
+type optionalSource struct {
+	Source
+}
+
+//OptionalSource function returns a Source that loads no values instead of failing when the file of s does not exist
+func OptionalSource(s Source) Source {
+	return &optionalSource{Source: s}
+}
+
+func (s *optionalSource) Load() (map[string]string, error) {
+	values, _, err := s.LoadLocated()
+	return values, err
+}
+
+func (s *optionalSource) LoadLocated() (map[string]string, map[string]string, error) {
+	var values, locations map[string]string
+	var err error
+	if ls, ok := s.Source.(LocatedSource); ok {
+		values, locations, err = ls.LoadLocated()
+	} else {
+		values, err = s.Source.Load()
+	}
+	if os.IsNotExist(err) {
+		return make(map[string]string), make(map[string]string), nil
+	}
+	return values, locations, err
+}
+
+//envSource is a Source of the environment variables
+type envSource struct {
+	prefix string
+}
+
+//NewEnvSource function creates a Source of the environment variables starting with prefix. The prefix is removed and
+//the names are mapped to keys in lower case with '_' replaced by '.' and "__" by '_', e.g. with the prefix APP_ the
+//variable APP_DB_MAX__IDLE is read as db.max_idle
+func NewEnvSource(prefix string) Source {
+	return &envSource{prefix: prefix}
+}
+
+func (s *envSource) Name() string {
+	return "env:" + s.prefix
+}
+
+func (s *envSource) Restricted() bool {
+	return true
+}
+
+func (s *envSource) Load() (map[string]string, error) {
+	values, _, err := s.LoadLocated()
+	return values, err
+}
+
+func (s *envSource) LoadLocated() (map[string]string, map[string]string, error) {
+	values := make(map[string]string)
+	locations := make(map[string]string)
+	for _, kv := range os.Environ() {
+		idx := strings.IndexByte(kv, textutils.EqualChar)
+		if idx <= 0 {
+			continue
+		}
+		if k, ok := envKey(kv[:idx], s.prefix); ok {
+			values[k], locations[k] = kv[idx+1:], kv[:idx]
+		}
+	}
+	return values, locations, nil
+}
+
+//envKey maps the name of an environment variable to a key
+func envKey(name, prefix string) (string, bool) {
+	if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+		return textutils.EmptyStr, false
+	}
+	parts := strings.Split(strings.ToLower(name[len(prefix):]), "__")
+	for i, part := range parts {
+		parts[i] = strings.Replace(part, "_", textutils.PeriodStr, -1)
+	}
+	return strings.Join(parts, "_"), true
+}
+
+//argsSource is a Source of command line arguments
+type argsSource struct {
+	args []string
+}
+
+//NewArgsSource function creates a Source of the command line arguments, e.g. os.Args[1:]. The arguments --key=value
+//are read as key=value and a --key without value as key=true, the next argument is never read as the value. The
+//arguments not starting with '-' and negative numbers are ignored, the arguments after "--" are not read.
+func NewArgsSource(args []string) Source {
+	return &argsSource{args: append([]string(nil), args...)}
+}
+
+func (s *argsSource) Name() string {
+	return "args"
+}
+
+func (s *argsSource) Restricted() bool {
+	return true
+}
+
+func (s *argsSource) Load() (map[string]string, error) {
+	values, _, err := s.LoadLocated()
+	return values, err
+}
+
+func (s *argsSource) LoadLocated() (map[string]string, map[string]string, error) {
+	values := make(map[string]string)
+	locations := make(map[string]string)
+	for i := 0; i < len(s.args); i++ {
+		arg := s.args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, textutils.HyphenStr) {
+			continue
+		}
+		name := strings.TrimLeft(arg, textutils.HyphenStr)
+		//negative numbers, e.g. -5 or -.5, are positional arguments
+		if name == textutils.EmptyStr || name[0] >= '0' && name[0] <= '9' || name[0] == textutils.PeriodChar {
+			continue
+		}
+		v := strconv.FormatBool(true)
+		if idx := strings.IndexByte(name, textutils.EqualChar); idx != -1 {
+			name, v = name[:idx], name[idx+1:]
+		}
+		values[name], locations[name] = v, "--"+name
+	}
+	return values, locations, nil
+}
+
+//flagSource is a Source of the flags of a flag.FlagSet
+type flagSource struct {
+	fs *flag.FlagSet
+}
+
+//NewFlagSource function creates a Source of the flags of fs that were set on the command line. The defaults of the
+//flags are not read so that they do not override the values of the other sources.
+func NewFlagSource(fs *flag.FlagSet) Source {
+	return &flagSource{fs: fs}
+}
+
+func (s *flagSource) Name() string {
+	return "flags"
+}
+
+func (s *flagSource) Restricted() bool {
+	return true
+}
+
+func (s *flagSource) Load() (map[string]string, error) {
+	values, _, err := s.LoadLocated()
+	return values, err
+}
+
+func (s *flagSource) LoadLocated() (map[string]string, map[string]string, error) {
+	values := make(map[string]string)
+	locations := make(map[string]string)
+	s.fs.Visit(func(f *flag.Flag) {
+		values[f.Name], locations[f.Name] = f.Value.String(), textutils.HyphenStr+f.Name
+	})
+	return values, locations, nil
+}
+
+//parseProperties parses the content in the .properties format. The last value of a key is kept.
+func parseProperties(content string) (map[string]string, map[string]int, error) {
+	lines, err := loadProperties(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make(map[string]string)
+	located := make(map[string]int)
+	for _, l := range lines {
+		if l.isEntry {
+			values[l.key], located[l.key] = l.raw, l.line
+		}
+	}
+	return values, located, nil
+}
+
+//jsonFlattener flattens a JSON document read token by token so that the line of each value is known
+type jsonFlattener struct {
+	content string
+	reader  *bytes.Reader
+	dec     *json.Decoder
+	values  map[string]string
+	located map[string]int
+}
+
+//parseJSON parses the JSON content and flattens it in to keys joined by '.' using the keys of the objects and the
+//indexes of the arrays. The null values are skipped.
+func parseJSON(content string) (map[string]string, map[string]int, error) {
+	f := &jsonFlattener{
+		content: content,
+		reader:  bytes.NewReader([]byte(content)),
+		values:  make(map[string]string),
+		located: make(map[string]int),
+	}
+	f.dec = json.NewDecoder(f.reader)
+	f.dec.UseNumber()
+	if err := f.flatten(textutils.EmptyStr, 0); err != nil {
+		return nil, nil, err
+	}
+	if _, err := f.dec.Token(); err != io.EOF {
+		return nil, nil, errors.New("invalid content after the JSON value")
+	}
+	return f.values, f.located, nil
+}
+
+//line returns the line of the last token read
+func (f *jsonFlattener) line() int {
+	offset := len(f.content) - f.reader.Len()
+	if buffered, ok := f.dec.Buffered().(*bytes.Reader); ok {
+		offset -= buffered.Len()
+	}
+	return strings.Count(f.content[:offset], "\n") + 1
+}
+
+//flatten reads the next value. line is the line of its key, 0 to use the line of the value.
+func (f *jsonFlattener) flatten(prefix string, line int) error {
+	tok, err := f.dec.Token()
+	if err != nil {
+		return err
+	}
+	if line == 0 {
+		line = f.line()
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			for f.dec.More() {
+				if tok, err = f.dec.Token(); err != nil {
+					return err
+				}
+				key, _ := tok.(string)
+				if err = f.flatten(joinKey(prefix, key), f.line()); err != nil {
+					return err
+				}
+			}
+		} else {
+			for i := 0; f.dec.More(); i++ {
+				if err = f.flatten(joinKey(prefix, strconv.Itoa(i)), 0); err != nil {
+					return err
+				}
+			}
+		}
+		_, err = f.dec.Token()
+		return err
+	case string:
+		f.values[prefix] = t
+	case json.Number:
+		f.values[prefix] = t.String()
+	case bool:
+		f.values[prefix] = strconv.FormatBool(t)
+	default:
+		return nil
+	}
+	f.located[prefix] = line
+	return nil
+}
+
+//parseDotEnv parses the NAME=value lines of a .env file. The lines may start with export, the values may be single
+//quoted, taken literally, or double quoted with the escapes \n, \r, \t, \" and \\. A '#' after whitespace starts a
+//comment in unquoted values.
+func parseDotEnv(content, prefix string) (map[string]string, map[string]int, error) {
+	values := make(map[string]string)
+	located := make(map[string]int)
+	for i, nl := range splitLines(content) {
+		line := strings.TrimSpace(nl.text)
+		if line == textutils.EmptyStr || line[0] == textutils.HashChar {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		idx := strings.IndexByte(line, textutils.EqualChar)
+		if idx <= 0 {
+			return nil, nil, fmt.Errorf("line %d: expected NAME=value", i+1)
+		}
+		name := strings.TrimSpace(line[:idx])
+		v, err := dotEnvValue(strings.TrimSpace(line[idx+1:]))
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if k, ok := envKey(name, prefix); ok {
+			values[k], located[k] = v, i+1
+		}
+	}
+	return values, located, nil
+}
+
+//dotEnvValue returns the value of a .env line
+func dotEnvValue(v string) (string, error) {
+	if v == textutils.EmptyStr {
+		return v, nil
+	}
+	switch v[0] {
+	case '\'':
+		end := strings.IndexByte(v[1:], '\'')
+		if end == -1 {
+			return v, fmt.Errorf("unterminated quoted value %s", v)
+		}
+		return v[1 : end+1], nil
+	case '"':
+		var sb strings.Builder
+		for i := 1; i < len(v); i++ {
+			c := v[i]
+			switch {
+			case c == '"':
+				return sb.String(), nil
+			case c == textutils.BackSlashChar && i+1 < len(v):
+				i++
+				switch v[i] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 'r':
+					sb.WriteByte('\r')
+				case 't':
+					sb.WriteByte('\t')
+				default:
+					sb.WriteByte(v[i])
+				}
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		return v, fmt.Errorf("unterminated quoted value %s", v)
+	}
+	if idx := strings.Index(v, " #"); idx != -1 {
+		v = strings.TrimSpace(v[:idx])
+	}
+	return v, nil
+}
@@ -1,15 +1,19 @@
 package config
 
-import "io"
+import (
+	"io"
+	"net/url"
+	"time"
+)
 
 // Configuration is an interface that wraps the  methods for a standard configuration.
 
 type Configuration interface {
 
-	//ReadFrom a reader from Reader
-	ReadFrom(r io.Reader) error
-	//WriteTo a writer
-	WriteTo(w io.Writer) error
+	//ReadFrom a reader from Reader and returns the number of bytes read, as done by io.ReaderFrom
+	ReadFrom(r io.Reader) (int64, error)
+	//WriteTo a writer and returns the number of bytes written, as done by io.WriterTo
+	WriteTo(w io.Writer) (int64, error)
 	//Get returns configuration value as string identified by the key
 	//If the value is absent then it will return defaultVal supplied.
 	Get(k, defaultVal string) string
@@ -29,6 +33,31 @@ type Configuration interface {
 	//If the value is absent then it will return defaultVal supplied.
 	//This may throw an error if a non float64 value is present for the key
 	GetAsDecimal(k string, defaultVal float64) (float64, error)
+	//GetAsDuration returns the config value as time.Duration identified by the key, e.g. 1m30s
+	//If the value is absent then it will return defaultVal supplied.
+	//This may throw an error if a non duration value is present for the key
+	GetAsDuration(k string, defaultVal time.Duration) (time.Duration, error)
+	//GetAsByteSize returns the config value as a number of bytes identified by the key, e.g. 512MiB
+	//If the value is absent then it will return defaultVal supplied.
+	//This may throw an error if a non byte size value is present for the key
+	GetAsByteSize(k string, defaultVal int64) (int64, error)
+	//GetAsList returns the config value split at the separator identified by the key
+	//If trim is true the items are trimmed and the empty items dropped.
+	//If the value is absent then it will return defaultVal supplied.
+	GetAsList(k, sep string, trim bool, defaultVal []string) []string
+	//GetAsMap returns the config value of comma separated key=value entries as a map identified by the key
+	//If the value is absent then it will return defaultVal supplied.
+	//This may throw an error if an entry is not a key=value pair
+	GetAsMap(k string, defaultVal map[string]string) (map[string]string, error)
+	//GetAsURL returns the config value as an absolute URL identified by the key
+	//If the value is absent then it will return defaultVal supplied.
+	//This may throw an error if a non URL value is present for the key
+	GetAsURL(k string, defaultVal *url.URL) (*url.URL, error)
+	//GetAsTime returns the config value parsed with the layout, a Go layout or a codec format such as codec.FormatUnix,
+	//or time.RFC3339 if empty, identified by the key
+	//If the value is absent then it will return defaultVal supplied.
+	//This may throw an error if the value does not match the layout
+	GetAsTime(k, layout string, defaultVal time.Time) (time.Time, error)
 	//Put returns configuration value as string identified by the key
 	//If the value is absent then it will return defaultVal supplied.
 	Put(k, v string) string
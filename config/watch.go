@@ -0,0 +1,257 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Change struct describes the change of the resolved value of a key
+type Change struct {
+	Key string
+	//Old is the value before the change, empty if the key was added
+	Old string
+	//New is the value after the change, empty if the key was removed
+	New     string
+	Added   bool
+	Removed bool
+}
+
+//Listener is notified of the changes of the keys it subscribed to
+type Listener func(c Change)
+
+//subscription is a Listener of a key or of the keys starting with a prefix
+type subscription struct {
+	key      string
+	isPrefix bool
+	listener Listener
+}
+
+//listeners holds the subscriptions of a configuration
+type listeners struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]subscription
+}
+
+//subscribe adds the subscription and returns the function removing it
+func (ls *listeners) subscribe(s subscription) func() {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if ls.subs == nil {
+		ls.subs = make(map[int]subscription)
+	}
+	id := ls.next
+	ls.next++
+	ls.subs[id] = s
+	return func() {
+		ls.mu.Lock()
+		defer ls.mu.Unlock()
+		delete(ls.subs, id)
+	}
+}
+
+//notify calls the listeners with the changes between the old and new values, sorted by key
+func (ls *listeners) notify(old, new map[string]string) {
+	ls.mu.Lock()
+	subs := make([]subscription, 0, len(ls.subs))
+	ids := make([]int, 0, len(ls.subs))
+	for id := range ls.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		subs = append(subs, ls.subs[id])
+	}
+	ls.mu.Unlock()
+	if len(subs) == 0 {
+		return
+	}
+	for _, c := range diffValues(old, new) {
+		for _, s := range subs {
+			if s.key == c.Key || s.isPrefix && strings.HasPrefix(c.Key, s.key) {
+				s.listener(c)
+			}
+		}
+	}
+}
+
+//diffValues returns the changes from the old to the new values sorted by key
+func diffValues(old, new map[string]string) []Change {
+	var changes []Change
+	for k, v := range new {
+		if o, ok := old[k]; !ok {
+			changes = append(changes, Change{Key: k, New: v, Added: true})
+		} else if o != v {
+			changes = append(changes, Change{Key: k, Old: o, New: v})
+		}
+	}
+	for k, o := range old {
+		if _, ok := new[k]; !ok {
+			changes = append(changes, Change{Key: k, Old: o, Removed: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+//unlockAndNotify releases the lock of the properties and notifies the listeners of the changes from the old values.
+//It is deferred by the functions changing the properties with the values before the change.
+func (p *Properties) unlockAndNotify(old map[string]string) {
+	new := p.resolvedProps
+	p.Unlock()
+	p.listeners.notify(old, new)
+}
+
+//Subscribe function registers the listener to be notified when the resolved value of the key changes, is added or is
+//removed. The listeners are called after the change in the goroutine making it. The returned function removes the
+//listener.
+func (p *Properties) Subscribe(k string, l Listener) func() {
+	return p.listeners.subscribe(subscription{key: k, listener: l})
+}
+
+//SubscribePrefix function registers the listener to be notified of the changes of the keys starting with prefix,
+//e.g. "db." for all the database settings or an empty prefix for all the keys
+func (p *Properties) SubscribePrefix(prefix string, l Listener) func() {
+	return p.listeners.subscribe(subscription{key: prefix, isPrefix: true, listener: l})
+}
+
+//WatchFile function reads the properties from the file and then polls it at the interval. When the file changes, the
+//values read from it replace all the values at once, including the values set with Put, and the listeners are
+//notified. If the file cannot be read or its variables cannot be resolved the previous values are kept and the
+//error is passed to onError. The returned function stops the watch.
+func (p *Properties) WatchFile(path string, interval time.Duration, onError func(error)) (func(), error) {
+	stamp := statFile(path)
+	if err := p.reloadFile(path); err != nil {
+		return nil, err
+	}
+	return pollFiles(map[string]fileStamp{path: stamp}, interval, func() {
+		if err := p.reloadFile(path); err != nil && onError != nil {
+			onError(err)
+		}
+	}), nil
+}
+
+//reloadFile reads the file in to new properties and swaps them in if it is read and resolved without error
+func (p *Properties) reloadFile(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	next := NewProperties()
+	if _, err = next.ReadFrom(bytes.NewReader(b)); err != nil {
+		return fmt.Errorf("config: %s: %v", path, err)
+	}
+	p.Lock()
+	defer p.unlockAndNotify(p.resolvedProps)
+	p.props, p.lines, p.resolvedProps = next.props, next.lines, next.resolvedProps
+	return nil
+}
+
+//fileStamp identifies the version of a file by its modification time and size
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+func statFile(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: fi.ModTime(), size: fi.Size(), exists: true}
+}
+
+//pollFiles checks the files at the interval and calls onChange when the modification time or the size of one of
+//them changes from the stamps, including when it is created or deleted. The returned function stops the polling.
+func pollFiles(stamps map[string]fileStamp, interval time.Duration, onChange func()) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				changed := false
+				for path, stamp := range stamps {
+					if current := statFile(path); current != stamp {
+						stamps[path] = current
+						changed = true
+					}
+				}
+				if changed {
+					onChange()
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+		})
+	}
+}
+
+//unlockAndNotify releases the lock of the config and notifies the listeners of the changes from the old merged values
+func (c *LayeredConfig) unlockAndNotify(old map[string]string) {
+	new := c.props.resolvedProps
+	c.Unlock()
+	c.listeners.notify(old, new)
+}
+
+//Subscribe function registers the listener to be notified when the merged value of the key changes, e.g. when a
+//Reload reads a new value from a file. The returned function removes the listener.
+func (c *LayeredConfig) Subscribe(k string, l Listener) func() {
+	return c.listeners.subscribe(subscription{key: k, listener: l})
+}
+
+//SubscribePrefix function registers the listener to be notified of the changes of the merged values of the keys
+//starting with prefix
+func (c *LayeredConfig) SubscribePrefix(prefix string, l Listener) func() {
+	return c.listeners.subscribe(subscription{key: prefix, isPrefix: true, listener: l})
+}
+
+//Watch function polls the files of the sources at the interval and reloads the config when one of them changes. The
+//errors of Reload are passed to onError and the previous values are kept. The returned function stops the watch.
+func (c *LayeredConfig) Watch(interval time.Duration, onError func(error)) func() {
+	stamps := make(map[string]fileStamp)
+	for _, s := range c.sources {
+		if fs, ok := s.(fileWatcher); ok {
+			for _, path := range fs.files() {
+				stamps[path] = statFile(path)
+			}
+		}
+	}
+	return pollFiles(stamps, interval, func() {
+		if err := c.Reload(); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
+
+//fileWatcher is implemented by the sources reading files so that their changes can be watched
+type fileWatcher interface {
+	files() []string
+}
+
+func (s *fileSource) files() []string {
+	return []string{s.path}
+}
+
+func (s *optionalSource) files() []string {
+	if fs, ok := s.Source.(fileWatcher); ok {
+		return fs.files()
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//changes collects the changes notified to a listener
+type changes struct {
+	mu   sync.Mutex
+	list []Change
+	ch   chan struct{}
+}
+
+func newChanges() *changes {
+	return &changes{ch: make(chan struct{}, 100)}
+}
+
+func (c *changes) listener(ch Change) {
+	c.mu.Lock()
+	c.list = append(c.list, ch)
+	c.mu.Unlock()
+	c.ch <- struct{}{}
+}
+
+func (c *changes) get() []Change {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Change(nil), c.list...)
+}
+
+//writeFile writes the content with a distinct modification time so that the change is seen by the polling
+func writeFile(t *testing.T, path, content string, age time.Duration) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mod := time.Now().Add(-age)
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func wait(t *testing.T, ch chan struct{}, what string) {
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func TestProperties_Subscribe(t *testing.T) {
+	p := NewProperties()
+	p.Put("db.host", "localhost")
+	p.Put("db.url", "jdbc:${db.host}")
+	key, prefix, all := newChanges(), newChanges(), newChanges()
+	p.Subscribe("db.url", key.listener)
+	cancel := p.SubscribePrefix("db.", prefix.listener)
+	p.SubscribePrefix("", all.listener)
+	p.Put("db.host", "example.com")
+	p.Put("db.host", "example.com")
+	p.Put("name", "a")
+	cancel()
+	p.Remove("db.host")
+	if want := []Change{{Key: "db.url", Old: "jdbc:localhost", New: "jdbc:example.com"},
+		{Key: "db.url", Old: "jdbc:example.com", New: "jdbc:${db.host}"}}; !reflect.DeepEqual(key.get(), want) {
+		t.Errorf("key changes = %+v, want %+v", key.get(), want)
+	}
+	if want := []Change{{Key: "db.host", Old: "localhost", New: "example.com"},
+		{Key: "db.url", Old: "jdbc:localhost", New: "jdbc:example.com"}}; !reflect.DeepEqual(prefix.get(), want) {
+		t.Errorf("prefix changes = %+v, want %+v", prefix.get(), want)
+	}
+	if want := []Change{{Key: "db.host", Old: "localhost", New: "example.com"},
+		{Key: "db.url", Old: "jdbc:localhost", New: "jdbc:example.com"},
+		{Key: "name", New: "a", Added: true},
+		{Key: "db.host", Old: "example.com", Removed: true},
+		{Key: "db.url", Old: "jdbc:example.com", New: "jdbc:${db.host}"}}; !reflect.DeepEqual(all.get(), want) {
+		t.Errorf("all changes = %+v, want %+v", all.get(), want)
+	}
+}
+
+func TestProperties_WatchFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.properties")
+	writeFile(t, path, "a=1\nb=2\n", 3*time.Hour)
+	p := NewProperties()
+	c := newChanges()
+	p.SubscribePrefix("", c.listener)
+	errs := make(chan error, 10)
+	stop, err := p.WatchFile(path, 10*time.Millisecond, func(err error) {
+		errs <- err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	wait(t, c.ch, "initial load")
+	wait(t, c.ch, "initial load")
+
+	writeFile(t, path, "a=1\nb=3\nc=${missing:?is required}\n", 2*time.Hour)
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "missing: is required") {
+			t.Errorf("onError() = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the error")
+	}
+	if got := p.Get("b", ""); got != "2" {
+		t.Errorf("Get(b) after an invalid reload = %q, want 2", got)
+	}
+
+	writeFile(t, path, "a=1\nb=4\n", time.Hour)
+	wait(t, c.ch, "reload")
+	if got := c.get()[2:]; !reflect.DeepEqual(got, []Change{{Key: "b", Old: "2", New: "4"}}) {
+		t.Errorf("changes = %+v", got)
+	}
+	stop()
+	stop()
+}
+
+func TestLayeredConfig_Watch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.yaml")
+	cfg, err := NewLayeredConfig(NewMapSource("defaults", map[string]string{"db.port": "5432"}),
+		OptionalSource(NewYAMLFileSource(path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newChanges()
+	cfg.SubscribePrefix("db.", c.listener)
+	errs := make(chan error, 10)
+	stop := cfg.Watch(10*time.Millisecond, func(err error) {
+		errs <- err
+	})
+	defer stop()
+
+	writeFile(t, path, "db:\n  port: 6543\n", 2*time.Hour)
+	wait(t, c.ch, "reload")
+	if got := c.get(); !reflect.DeepEqual(got, []Change{{Key: "db.port", Old: "5432", New: "6543"}}) {
+		t.Errorf("changes = %+v", got)
+	}
+
+	writeFile(t, path, "db:\n  port: [1\n", time.Hour)
+	select {
+	case <-errs:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the error")
+	}
+	if got := cfg.Get("db.port", ""); got != "6543" {
+		t.Errorf("Get(db.port) after an invalid reload = %q, want 6543", got)
+	}
+
+	cfg.Put("db.user", "admin")
+	wait(t, c.ch, "put")
+	if got := c.get()[1]; got != (Change{Key: "db.user", New: "admin", Added: true}) {
+		t.Errorf("change = %+v", got)
+	}
+}
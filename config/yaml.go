@@ -0,0 +1,378 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.codemanch.com/commons/textutils"
+)
+
+//yamlParser flattens a YAML document in to keys joined by '.', e.g. servers.0.host. The block and flow mappings and
+//sequences, plain and quoted scalars, block scalars and comments are supported. Anchors, aliases and multi line flow
+//values are not. Null values are skipped.
+type yamlParser struct {
+	lines  []string
+	pos    int
+	values map[string]string
+	//located holds the line number of each value
+	located map[string]int
+}
+
+//parseYAML function parses the YAML content and returns the flattened values and their line numbers
+func parseYAML(content string) (map[string]string, map[string]int, error) {
+	p := &yamlParser{
+		lines:   strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n"),
+		values:  make(map[string]string),
+		located: make(map[string]int),
+	}
+	if !p.skip() {
+		return p.values, p.located, nil
+	}
+	indent, _, err := p.current()
+	if err == nil {
+		err = p.parseBlock(indent, textutils.EmptyStr)
+	}
+	if err == nil && p.skip() {
+		err = p.errorf("unexpected indentation")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.values, p.located, nil
+}
+
+//skip moves past the blank lines, comments and document markers and checks if there is a line left
+func (p *yamlParser) skip() bool {
+	for ; p.pos < len(p.lines); p.pos++ {
+		t := strings.TrimSpace(p.lines[p.pos])
+		if t != textutils.EmptyStr && t[0] != textutils.HashChar && t != "---" && t != "..." && t[0] != '%' {
+			return true
+		}
+	}
+	return false
+}
+
+//current returns the indentation and the text of the current line without its comment
+func (p *yamlParser) current() (int, string, error) {
+	line := p.lines[p.pos]
+	text := strings.TrimLeft(line, textutils.WhiteSpaceStr)
+	if strings.HasPrefix(text, "\t") {
+		return 0, text, p.errorf("tabs are not allowed for indentation")
+	}
+	return len(line) - len(text), stripYAMLComment(text), nil
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("yaml: line %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+//parseBlock parses the mapping or sequence starting at the current line
+func (p *yamlParser) parseBlock(indent int, prefix string) error {
+	_, text, err := p.current()
+	if err != nil {
+		return err
+	}
+	if isYAMLSequenceItem(text) {
+		return p.parseSequence(indent, prefix)
+	}
+	return p.parseMapping(indent, prefix)
+}
+
+func (p *yamlParser) parseMapping(indent int, prefix string) error {
+	for p.skip() {
+		n, text, err := p.current()
+		if err != nil {
+			return err
+		}
+		if n < indent {
+			return nil
+		}
+		if n > indent {
+			return p.errorf("unexpected indentation")
+		}
+		k, rest, ok := splitYAMLKey(text)
+		if !ok {
+			return p.errorf("expected a key in %q", text)
+		}
+		key := joinKey(prefix, k)
+		line := p.pos + 1
+		p.pos++
+		switch {
+		case rest == textutils.EmptyStr:
+			err = p.parseNested(indent, key, true)
+		case rest[0] == '|' || rest[0] == '>':
+			p.parseBlockScalar(indent, key, rest)
+			p.located[key] = line
+		default:
+			err = p.setScalar(key, rest, line)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *yamlParser) parseSequence(indent int, prefix string) error {
+	for i := 0; p.skip(); i++ {
+		n, text, err := p.current()
+		if err != nil {
+			return err
+		}
+		if n < indent || n == indent && !isYAMLSequenceItem(text) {
+			return nil
+		}
+		if n > indent {
+			return p.errorf("unexpected indentation")
+		}
+		key := joinKey(prefix, strconv.Itoa(i))
+		item := strings.TrimLeft(text[1:], textutils.WhiteSpaceStr)
+		if item == textutils.EmptyStr {
+			p.pos++
+			err = p.parseNested(indent, key, false)
+		} else if _, _, isKey := splitYAMLKey(item); isKey || isYAMLSequenceItem(item) {
+			//a block starting on the line of the item, e.g. "- name: a", continues at the indentation of its text
+			itemIndent := indent + len(text) - len(item)
+			p.lines[p.pos] = strings.Repeat(textutils.WhiteSpaceStr, itemIndent) +
+				strings.TrimLeft(p.lines[p.pos], textutils.WhiteSpaceStr)[len(text)-len(item):]
+			err = p.parseBlock(itemIndent, key)
+		} else {
+			p.pos++
+			err = p.setScalar(key, item, p.pos)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//parseNested parses the value of a key or an item written on the next lines. A sequence may have the indentation of
+//the key owning it.
+func (p *yamlParser) parseNested(indent int, key string, sequenceAtIndent bool) error {
+	if !p.skip() {
+		return nil
+	}
+	n, text, err := p.current()
+	if err != nil {
+		return err
+	}
+	if n > indent {
+		return p.parseBlock(n, key)
+	}
+	if n == indent && sequenceAtIndent && isYAMLSequenceItem(text) {
+		return p.parseSequence(n, key)
+	}
+	return nil
+}
+
+//parseBlockScalar reads the literal (|) or folded (>) scalar following a key. The chomping indicators - and + are
+//honoured and the indentation is detected from the first line.
+func (p *yamlParser) parseBlockScalar(indent int, key, header string) {
+	literal := header[0] == '|'
+	var lines []string
+	blockIndent := -1
+	for ; p.pos < len(p.lines); p.pos++ {
+		line := p.lines[p.pos]
+		text := strings.TrimLeft(line, textutils.WhiteSpaceStr)
+		if text == textutils.EmptyStr {
+			lines = append(lines, textutils.EmptyStr)
+			continue
+		}
+		n := len(line) - len(text)
+		if n <= indent || blockIndent != -1 && n < blockIndent {
+			break
+		}
+		if blockIndent == -1 {
+			blockIndent = n
+		}
+		lines = append(lines, line[blockIndent:])
+	}
+	trailing := 0
+	for i := len(lines) - 1; i >= 0 && lines[i] == textutils.EmptyStr; i-- {
+		trailing++
+	}
+	lines = lines[:len(lines)-trailing]
+	var sb strings.Builder
+	for i, l := range lines {
+		switch {
+		case literal && i > 0:
+			sb.WriteByte('\n')
+		case literal:
+		case l == textutils.EmptyStr:
+			//a blank line of a folded scalar is a line break
+			sb.WriteByte('\n')
+			continue
+		case i > 0 && lines[i-1] != textutils.EmptyStr:
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(l)
+	}
+	v := sb.String()
+	switch {
+	case strings.Contains(header, "-") || len(lines) == 0:
+	case strings.Contains(header, "+"):
+		v += strings.Repeat("\n", trailing+1)
+	default:
+		v += "\n"
+	}
+	p.values[key] = v
+}
+
+//setScalar sets the value of the key from a scalar or a flow collection
+func (p *yamlParser) setScalar(key, v string, line int) error {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(v, "!") {
+		//the tags are ignored
+		idx := strings.IndexByte(v, ' ')
+		if idx == -1 {
+			return nil
+		}
+		v = strings.TrimSpace(v[idx:])
+	}
+	if v == textutils.EmptyStr {
+		return nil
+	}
+	switch v[0] {
+	case '&', '*':
+		return p.errorf("anchors and aliases are not supported")
+	case '[', '{':
+		closing := "]"
+		if v[0] == '{' {
+			closing = "}"
+		}
+		if !strings.HasSuffix(v, closing) {
+			return p.errorf("unterminated flow collection %q", v)
+		}
+		for i, item := range splitFlowItems(v[1 : len(v)-1]) {
+			item = strings.TrimSpace(item)
+			var err error
+			if v[0] == '[' {
+				err = p.setScalar(joinKey(key, strconv.Itoa(i)), item, line)
+			} else if k, rest, ok := splitYAMLKey(item); ok {
+				err = p.setScalar(joinKey(key, k), rest, line)
+			} else {
+				err = p.errorf("expected a key in %q", item)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case '"':
+		s, err := strconv.Unquote(v)
+		if err != nil {
+			return p.errorf("invalid double quoted scalar %s", v)
+		}
+		p.values[key], p.located[key] = s, line
+		return nil
+	case '\'':
+		if len(v) < 2 || !strings.HasSuffix(v, "'") {
+			return p.errorf("unterminated single quoted scalar %s", v)
+		}
+		p.values[key], p.located[key] = strings.Replace(v[1:len(v)-1], "''", "'", -1), line
+		return nil
+	}
+	switch v {
+	case "~", "null", "Null", "NULL":
+		return nil
+	}
+	p.values[key], p.located[key] = v, line
+	return nil
+}
+
+//isYAMLSequenceItem checks if the text is an item of a block sequence
+func isYAMLSequenceItem(text string) bool {
+	return text == textutils.HyphenStr || strings.HasPrefix(text, "- ")
+}
+
+//splitYAMLKey splits "key: value" in to the unquoted key and the value. ok is false if the text is not a mapping entry
+func splitYAMLKey(text string) (string, string, bool) {
+	if text == textutils.EmptyStr {
+		return textutils.EmptyStr, textutils.EmptyStr, false
+	}
+	start, quoted := 0, false
+	if q := text[0]; q == '"' || q == '\'' {
+		idx := strings.IndexByte(text[1:], q)
+		if idx == -1 {
+			return textutils.EmptyStr, textutils.EmptyStr, false
+		}
+		start, quoted = idx+2, true
+	}
+	for i := start; i < len(text); i++ {
+		if text[i] != textutils.ColonChar {
+			if quoted && text[i] != ' ' {
+				break
+			}
+			continue
+		}
+		if i+1 < len(text) && text[i+1] != ' ' {
+			continue
+		}
+		key := strings.TrimSpace(text[:i])
+		if quoted {
+			key = key[1 : len(key)-1]
+		}
+		return key, strings.TrimSpace(text[i+1:]), key != textutils.EmptyStr || quoted
+	}
+	return textutils.EmptyStr, textutils.EmptyStr, false
+}
+
+//splitFlowItems splits the content of a flow collection at the commas outside of quotes and nested collections
+func splitFlowItems(s string) []string {
+	var items []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != textutils.EmptyStr || len(items) > 0 {
+		items = append(items, s[start:])
+	}
+	return items
+}
+
+//stripYAMLComment removes a comment starting with '#' at the start of the text or after whitespace outside of quotes
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == textutils.BackSlashChar && quote == '"' {
+				i++
+			}
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" [{:,-", text[i-1]) != -1):
+			quote = c
+		case c == textutils.HashChar && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return strings.TrimRight(text, " \t")
+}
+
+//joinKey joins the key to its parent prefix with '.'
+func joinKey(prefix, key string) string {
+	if prefix == textutils.EmptyStr {
+		return key
+	}
+	return prefix + textutils.PeriodStr + key
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	doc := `# application settings
+---
+server:
+  host: "0.0.0.0"   # all interfaces
+  port: 8080
+  tags: [web, 'api', "x,y"]
+  limits: {rate: 10, burst: 20}
+db:
+  hosts:
+  - primary.local
+  -   replica.local
+  users:
+    - name: admin
+      roles:
+        - read
+        - write
+    - name: 'it''s'
+  empty:
+  nothing: ~
+motd: |
+  Hello
+    world
+
+banner: >-
+  folded
+  text
+
+  next
+keep: |+
+  kept
+
+url: http://example.com/#anchor
+'quoted key': "tab\tnewline\n"
+`
+	want := map[string]string{
+		"server.host": "0.0.0.0", "server.port": "8080",
+		"server.tags.0": "web", "server.tags.1": "api", "server.tags.2": "x,y",
+		"server.limits.rate": "10", "server.limits.burst": "20",
+		"db.hosts.0": "primary.local", "db.hosts.1": "replica.local",
+		"db.users.0.name": "admin", "db.users.0.roles.0": "read", "db.users.0.roles.1": "write",
+		"db.users.1.name": "it's",
+		"motd":            "Hello\n  world\n",
+		"banner":          "folded text\nnext",
+		"keep":            "kept\n\n",
+		"url":             "http://example.com/#anchor",
+		"quoted key":      "tab\tnewline\n",
+	}
+	got, lines, err := parseYAML(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines["server.port"] != 5 || lines["db.users.1.name"] != 17 || lines["motd"] != 20 || lines["server.tags.2"] != 6 {
+		t.Errorf("parseYAML() lines = %v", lines)
+	}
+	if !reflect.DeepEqual(got, want) {
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("%s = %q, want %q", k, got[k], v)
+			}
+		}
+		for k, v := range got {
+			if _, ok := want[k]; !ok {
+				t.Errorf("unexpected %s = %q", k, v)
+			}
+		}
+	}
+}
+
+func TestParseYAML_Errors(t *testing.T) {
+	tests := map[string]string{
+		"Indentation": "a: 1\n  b: 2",
+		"Tabs":        "a:\n\tb: 1",
+		"NoKey":       "a: 1\njust text",
+		"Alias":       "a: &x 1\nb: *x",
+		"Quote":       "a: 'open",
+		"Flow":        "a: [1, 2",
+	}
+	for name, doc := range tests {
+		if _, _, err := parseYAML(doc); err == nil {
+			t.Errorf("%s: parseYAML() must fail", name)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package fsutils
 
 import (
+	"bytes"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -68,3 +70,14 @@ func DetectContentType(path string) (string, error) {
 	return "", err
 
 }
+
+//DetectReaderContentType will detect the content type of the content of r using the first 512 bytes.
+//As the bytes used for the detection are consumed from r, the reader returned must be used to read the whole content.
+func DetectReaderContentType(r io.Reader) (string, io.Reader, error) {
+	buffer := make([]byte, 512)
+	n, err := io.ReadFull(r, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", r, err
+	}
+	return http.DetectContentType(buffer[:n]), io.MultiReader(bytes.NewReader(buffer[:n]), r), nil
+}
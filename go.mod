@@ -1,3 +1,5 @@
-module "go.codemanch.com/commons"
+module go.codemanch.com/commons
 
-go 1.12
\ No newline at end of file
+go 1.13
+
+require golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad
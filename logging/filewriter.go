@@ -4,7 +4,7 @@ import (
 	"io"
 	"os"
 
-	"go.appmanch.org/commons/textutils"
+	"go.codemanch.com/commons/textutils"
 )
 
 // FileWriter struct
@@ -14,10 +14,11 @@ import (
 	"sync"
 	"time"
 
-	"github.com/appmanch/go-commons/config"
-	"github.com/appmanch/go-commons/textutils"
+	"go.codemanch.com/commons/codec"
+	"go.codemanch.com/commons/config"
+	"go.codemanch.com/commons/textutils"
 
-	"github.com/appmanch/go-commons/fsutils"
+	"go.codemanch.com/commons/fsutils"
 )
 
 //Severity of the logging levels
@@ -201,7 +202,8 @@ func loadConfig() *LogConfig {
 			} else {
 				defer logConfigFile.Close()
 				bytes, _ := ioutil.ReadAll(logConfigFile)
-				err = json.Unmarshal(bytes, &logConfig)
+				//unknown keys are rejected so that a misspelled setting is not silently ignored
+				err = codec.NewJSONCodec(&codec.DecoderOptions{DisallowUnknownFields: true}).DecodeBytes(bytes, logConfig)
 				if err != nil {
 					writeLog(os.Stderr, "Unable to open the log config file using default log config", err)
 					logConfig = loadDefaultConfig()
@@ -1,7 +1,11 @@
 package logging
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -165,4 +169,49 @@ func TestLogger_IsEnabled(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestRedactArgs(t *testing.T) {
+	type credentials struct {
+		User     string
+		Password string `sensitive:"true"`
+	}
+	args := []interface{}{"login", credentials{User: "u", Password: "hunter2"},
+		struct{ Payload interface{} }{Payload: credentials{Password: "nested-pass"}}}
+	msg := getLogMessage(InfoLvl, args...)
+	defer putLogMessage(msg)
+	if s := msg.Content.String(); strings.Contains(s, "hunter2") || strings.Contains(s, "nested-pass") {
+		t.Errorf("getLogMessage() = %s, leaks a sensitive value", s)
+	}
+	if args[1].(credentials).Password != "hunter2" {
+		t.Error("redactArgs() modified the arguments")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "log-config.json")
+	os.Setenv(LogConfigEnvProperty, path)
+	defer os.Unsetenv(LogConfigEnvProperty)
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "Valid", content: `{"format":"json","defaultLvl":"DEBUG"}`, want: "json"},
+		{name: "Misspelled", content: `{"fromat":"json","defaultLvl":"DEBUG"}`, want: "text"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if got := loadConfig(); got.Format != tt.want {
+				t.Errorf("loadConfig() format = %s, want %s", got.Format, tt.want)
+			}
+		})
+	}
+}
@@ -3,9 +3,12 @@ package logging
 import (
 	"bytes"
 	"fmt"
-	"go.appmanch.org/commons/textutils"
+	"reflect"
 	"sync"
 	"time"
+
+	"go.codemanch.com/commons/codec"
+	"go.codemanch.com/commons/textutils"
 )
 
 var logMsgPool = &sync.Pool{
@@ -37,7 +40,7 @@ func getLogMessageF(sev Severity, f string, v ...interface{}) *LogMessage {
 	msg.Time = time.Now()
 	msg.FnName = textutils.EmptyStr
 	msg.Line = 0
-	_, _ = fmt.Fprintf(msg.Content, f, v...)
+	_, _ = fmt.Fprintf(msg.Content, f, redactArgs(v)...)
 	return msg
 }
 
@@ -47,10 +50,30 @@ func getLogMessage(sev Severity, v ...interface{}) *LogMessage {
 	msg.Time = time.Now()
 	msg.FnName = textutils.EmptyStr
 	msg.Line = 0
-	_, _ = fmt.Fprint(msg.Content, v...)
+	_, _ = fmt.Fprint(msg.Content, redactArgs(v)...)
 	return msg
 }
 
+//redactArgs masks the fields tagged as sensitive in the values to be logged. The slice is copied only if a value
+//holds sensitive fields so that the caller's slice is never modified
+func redactArgs(v []interface{}) []interface{} {
+	var redacted []interface{}
+	for i, a := range v {
+		if !codec.HasSensitiveFields(reflect.TypeOf(a)) {
+			continue
+		}
+		if redacted == nil {
+			redacted = make([]interface{}, len(v))
+			copy(redacted, v)
+		}
+		redacted[i] = codec.Redact(a)
+	}
+	if redacted == nil {
+		return v
+	}
+	return redacted
+}
+
 func putLogMessage(logMsg *LogMessage) {
 	logMsg.Content.Reset()
 	logMsgPool.Put(logMsg)